@@ -0,0 +1,26 @@
+package vpe
+
+import (
+	"net/url"
+	"strings"
+
+	"k6-generator/envrefs"
+)
+
+// isSensitiveHeader reports whether a header value should be kept out of the script.
+func isSensitiveHeader(header Header) bool {
+	if header.Sensitive {
+		return true
+	}
+	return envrefs.LooksSensitive(header.Name)
+}
+
+// splitOrigin separates scheme://host from the rest of an absolute URL; relative URLs have no origin.
+func splitOrigin(rawURL string) (string, string) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return "", rawURL
+	}
+	origin := parsed.Scheme + "://" + parsed.Host
+	return origin, strings.TrimPrefix(rawURL, origin)
+}
@@ -1,4 +1,5 @@
-package main 
+// Package vpe generates k6 scripts from the VPE config folder and its header, body and extracter files.
+package vpe
 
 import (
 	"bufio"
@@ -7,14 +8,19 @@ import (
 	//"io/ioutil"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"gopkg.in/yaml.v2"
 "github.com/spf13/cobra"
+
+	"k6-generator/envrefs"
+	"k6-generator/jsemit"
 )
 
 type Header struct {
 	Name  string `yaml:"name"`
 	Value string `yaml:"value"`
+	Sensitive bool `yaml:"sensitive"`
 }
 
 type BodyJSON struct {
@@ -105,6 +111,7 @@ BitbucketURL 			string			`yaml:"bitbucketurl"`
 	ThreadGroup			ThreadGroup		`yaml:"threadgroup"`
 	TestType           string        `yaml:"testType"`
 	ApiErrors          *float64      `yaml:"apiErrors"`
+	EnvRefs            bool          `yaml:"envRefs"`
 }
 
 type VPEConfig struct {
@@ -127,9 +134,9 @@ type BodyJSONsConfig struct {
 var vpeconfigFolderPath string
 var K6VpeconfigCmd = &cobra.Command{
 	Use: "vpeconfig",
-	Short: "validate",
-	Long: `This command validates`,
-	Example: "Vpe config",
+	Short: "Validate a VPE config folder and generate its k6 script",
+	Long: `This command validates the VPE config folder and its header, body and extracter files, then generates the k6 script`,
+	Example: "k6 vpeconfig --path ./fitness",
 	Run: func(cmd *cobra.Command, args []string){
 		if vpeconfigFolderPath == ""{
 			fmt.Println("Error:- ")
@@ -145,11 +152,96 @@ var K6VpeconfigCmd = &cobra.Command{
 	},
 }
 
-// func init(){
-// 	k6Cmd.AddCommand(K6VpeconfigCmd)
-// 	K6VpeconfigCmd.Flags().StringVarP(&vpeconfigFolderPath, "path" , "p","","path to the fitness folder")
-// 	K6VpeconfigCmd.MarkFlagRequired("path")
-// }
+// The k6 binary registers K6VpeconfigCmd next to the swagger commands.
+func init(){
+	K6VpeconfigCmd.Flags().StringVarP(&vpeconfigFolderPath, "path" , "p","","path to the fitness folder")
+	K6VpeconfigCmd.MarkFlagRequired("path")
+}
+
+// variableReference matches the ${name} references to extracted variables in header names and values.
+var variableReference = regexp.MustCompile(`\$\{([^{}]*)\}`)
+
+// headerEntry writes one header of a headers object. A ${name} header name is the plain name, and
+// ${name} in a value reads the variable extracted under that name by an earlier request.
+func headerEntry(header Header, envRefs *envrefs.Registry) string {
+	if !strings.Contains(header.Name, "${") && !strings.Contains(header.Value, "${") {
+		if envRefs != nil && isSensitiveHeader(header) {
+			return fmt.Sprintf(" %s: %s,\n", jsemit.String(header.Name), envRefs.Ref(header.Name, header.Value))
+		}
+		return fmt.Sprintf(" %s: %s,\n", jsemit.String(header.Name), jsemit.String(header.Value))
+	}
+	name := variableReference.ReplaceAllString(header.Name, "$1")
+	return fmt.Sprintf(" %s: %s,\n", jsemit.String(name), headerValue(header.Value))
+}
+
+// headerValue returns the expression of a header value: the variable itself for a lone ${name}, and a
+// template literal interpolating the variables otherwise. References that are not identifiers stay text.
+func headerValue(value string) string {
+	references := variableReference.FindAllStringSubmatchIndex(value, -1)
+	if len(references) == 0 {
+		return jsemit.String(value)
+	}
+	if len(references) == 1 && references[0][0] == 0 && references[0][1] == len(value) {
+		if name := value[references[0][2]:references[0][3]]; jsemit.IsIdentifier(name) {
+			return name
+		}
+	}
+
+	var template strings.Builder
+	template.WriteByte('`')
+	last := 0
+	for _, reference := range references {
+		template.WriteString(jsemit.TemplateText(value[last:reference[0]]))
+		if name := value[reference[2]:reference[3]]; jsemit.IsIdentifier(name) {
+			template.WriteString("${" + name + "}")
+		} else {
+			template.WriteString(jsemit.TemplateText(value[reference[0]:reference[1]]))
+		}
+		last = reference[1]
+	}
+	template.WriteString(jsemit.TemplateText(value[last:]))
+	template.WriteByte('`')
+	return template.String()
+}
+
+// httpFunction returns the k6/http function sending a request with the given method.
+func httpFunction(method string) string {
+	function := strings.ToLower(method)
+	if function == "delete" {
+		return "del"
+	}
+	if err := jsemit.CheckIdentifier(function); err != nil {
+		log.Fatalf("error: method %s: %v", method, err)
+	}
+	return function
+}
+
+// scriptNames are the top-level names every script declares next to the endpoint Trends.
+var scriptNames = []string{"http", "check", "sleep", "options"}
+
+// validateTrendIdentifiers reports endpoint titles whose Trend variables would share a name, with
+// each other or with a name the script already declares; the script would not load.
+func validateTrendIdentifiers(threadGroup ThreadGroup) error {
+	owners := make(map[string]string)
+	for _, name := range scriptNames {
+		owners[name] = "the script's " + name
+	}
+
+	var problems []string
+	endpoints := append(append([]Endpoint{}, threadGroup.SessionEndpoint...), threadGroup.Endpoint...)
+	for _, endpoint := range endpoints {
+		identifier := jsemit.Identifier(endpoint.Title)
+		if owner, taken := owners[identifier]; taken {
+			problems = append(problems, fmt.Sprintf("title %q and %s share the Trend variable %s", endpoint.Title, owner, identifier))
+			continue
+		}
+		owners[identifier] = fmt.Sprintf("title %q", endpoint.Title)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%s; rename the titles", strings.Join(problems, "; "))
+	}
+	return nil
+}
 
 func removeNextClosingBrace(input string) string {
 	var result strings.Builder
@@ -224,6 +316,9 @@ func ValidateVpeconfigAndFiles(vpeconfigFolderPath string) error {
 	if err != nil {
 		log.Fatalf("error: %v", err)
 	}
+	if err := validateTrendIdentifiers(config.RequestInputXML.ThreadGroup); err != nil {
+		return fmt.Errorf("Config.yml: %w", err)
+	}
 
 	// Modified section: Use pointer for ThreadLoadPercentage and provide a default value
 	threadLoadPercentage := config.RequestInputXML.ThreadGroup.ThreadLoadPercentage
@@ -278,6 +373,12 @@ func ValidateVpeconfigAndFiles(vpeconfigFolderPath string) error {
 
 	testTypeList := strings.Split(testType, ",")
 
+	// With envRefs the base URLs and sensitive header values are read from __ENV instead of inlined
+	var envRefs *envrefs.Registry
+	if config.RequestInputXML.EnvRefs {
+		envRefs = envrefs.NewRegistry()
+	}
+
 	for _, testType := range testTypeList {
 		testType = strings.TrimSpace(testType)
 
@@ -286,7 +387,7 @@ func ValidateVpeconfigAndFiles(vpeconfigFolderPath string) error {
 		jsCode.WriteString("import { check, sleep } from 'k6';\n")
 
 		if testType != "" {
-			jsCode.WriteString(fmt.Sprintf("//testType=%s\n", testType))
+			jsCode.WriteString(fmt.Sprintf("//testType=%s\n", jsemit.Comment(testType)))
 		}
 		if config.RequestInputXML.ApiErrors != nil {
 			jsCode.WriteString(fmt.Sprintf("//apiError=%.2f\n", *config.RequestInputXML.ApiErrors))
@@ -349,12 +450,13 @@ func ValidateVpeconfigAndFiles(vpeconfigFolderPath string) error {
 		jsCode.WriteString(optionsBlock.String()) // Append the options block
 
 		for _, sessionEndpoint := range config.RequestInputXML.ThreadGroup.SessionEndpoint {
-			jsCode.WriteString(fmt.Sprintf("const %s = new Trend('%s'); \n", sessionEndpoint.Title, sessionEndpoint.Title))
+			jsCode.WriteString(fmt.Sprintf("const %s = new Trend(%s); \n", jsemit.Identifier(sessionEndpoint.Title), jsemit.String(sessionEndpoint.Title)))
 		}
 		for _, endpoint := range config.RequestInputXML.ThreadGroup.Endpoint {
-			jsCode.WriteString(fmt.Sprintf("const %s = new Trend('%s'); \n", endpoint.Title, endpoint.Title))
+			jsCode.WriteString(fmt.Sprintf("const %s = new Trend(%s); \n", jsemit.Identifier(endpoint.Title), jsemit.String(endpoint.Title)))
 		}
 
+		envCheckOffset := jsCode.Len()
 		jsCode.WriteString("export default function () {\n")
 
 		for sessionEndpointIndex, sessionEndpoint := range config.RequestInputXML.ThreadGroup.SessionEndpoint {
@@ -373,35 +475,7 @@ func ValidateVpeconfigAndFiles(vpeconfigFolderPath string) error {
 			jsCode.WriteString(fmt.Sprintf("const session_headers_%d = {\n", sessionEndpointIndex))
 			for _, header := range headersConfig.Headers.Header {
 				if header.Name != "" {
-
-					if !strings.Contains(header.Name, "${") && !strings.Contains(header.Value, "${") {
-						jsCode.WriteString(fmt.Sprintf(" '%s': '%s',\n", header.Name, header.Value))
-					} else {
-						headerNameFound := false
-						headerValueFound := false
-
-						if strings.Contains(header.Name, "${") && strings.Contains(header.Name, "}") {
-							header.Name = strings.ReplaceAll(header.Name, "${", "")
-							header.Name = strings.ReplaceAll(header.Name, "}", "")
-							headerNameFound = true
-						}
-
-						if strings.Contains(header.Value, "${") && strings.Contains(header.Value, "}") {
-							header.Value = strings.ReplaceAll(header.Value, "${", "")
-							header.Value = strings.ReplaceAll(header.Name, "}", "")
-							headerValueFound = true
-						}
-
-						if headerNameFound && headerValueFound {
-							jsCode.WriteString(fmt.Sprintf(" %s: %s,\n", header.Name, header.Value))
-						} else if headerNameFound && !headerValueFound {
-							jsCode.WriteString(fmt.Sprintf(" %s: '%s',\n", header.Name, header.Value))
-						} else if !headerNameFound && headerValueFound {
-							jsCode.WriteString(fmt.Sprintf(" '%s': %s,\n", header.Name, header.Value))
-						} else {
-							jsCode.WriteString(fmt.Sprintf(" '%s': '%s',\n", header.Name, header.Value))
-						}
-					}
+					jsCode.WriteString(headerEntry(header, envRefs))
 				}
 			}
 
@@ -422,7 +496,7 @@ func ValidateVpeconfigAndFiles(vpeconfigFolderPath string) error {
 					bodyJSONsDataStr = removeNextClosingBrace(bodyJSONsDataStr)
 					bodyJSONsData = []byte(bodyJSONsDataStr)
 
-					jsCode.WriteString(fmt.Sprintf("const session_body_%d_%d = `%s`;\n", sessionEndpointIndex, bodyIndex, string(bodyJSONsData))) // Use backticks for multiline strings
+					jsCode.WriteString(fmt.Sprintf("const session_body_%d_%d = %s;\n", sessionEndpointIndex, bodyIndex, jsemit.Template(string(bodyJSONsData)))) // Use backticks for multiline strings
 					bodyFound = true
 				} else {
 					if sessionEndpoint.APIName != "" && strings.Contains(sessionEndpoint.APIName, "{"+bodyjson.Name+"}") {
@@ -451,20 +525,24 @@ func ValidateVpeconfigAndFiles(vpeconfigFolderPath string) error {
 			}
 
 			fmt.Printf("Session API Name: %s\n", sessionEndpoint.APIName)
-			jsCode.WriteString(fmt.Sprintf("const session_url_%d = '%s';\n", sessionEndpointIndex, sessionEndpoint.APIName))
+			if origin, rest := splitOrigin(sessionEndpoint.APIName); envRefs != nil && origin != "" {
+				jsCode.WriteString(fmt.Sprintf("const session_url_%d = %s + %s;\n", sessionEndpointIndex, envRefs.Ref("BASE_URL", origin), jsemit.String(rest)))
+			} else {
+				jsCode.WriteString(fmt.Sprintf("const session_url_%d = %s;\n", sessionEndpointIndex, jsemit.String(sessionEndpoint.APIName)))
+			}
 
 			if bodyFound {
-				jsCode.WriteString(fmt.Sprintf("let session_res_%d = http.%s(session_url_%d, JSON.stringify(session_body_%d_0), {headers: session_headers_%d});\n", sessionEndpointIndex, strings.ToLower(sessionEndpoint.Method), sessionEndpointIndex, sessionEndpointIndex, sessionEndpointIndex))
+				jsCode.WriteString(fmt.Sprintf("let session_res_%d = http.%s(session_url_%d, JSON.stringify(session_body_%d_0), {headers: session_headers_%d});\n", sessionEndpointIndex, httpFunction(sessionEndpoint.Method), sessionEndpointIndex, sessionEndpointIndex, sessionEndpointIndex))
 			} else {
-				jsCode.WriteString(fmt.Sprintf("let session_res_%d = http.%s(session_url_%d, null, {headers: session_headers_%d});\n", sessionEndpointIndex, strings.ToLower(sessionEndpoint.Method), sessionEndpointIndex, sessionEndpointIndex))
+				jsCode.WriteString(fmt.Sprintf("let session_res_%d = http.%s(session_url_%d, null, {headers: session_headers_%d});\n", sessionEndpointIndex, httpFunction(sessionEndpoint.Method), sessionEndpointIndex, sessionEndpointIndex))
 			}
-			jsCode.WriteString(fmt.Sprintf("%s.add(session_res_%d.timings.waiting);\n", sessionEndpoint.Title, sessionEndpointIndex))
+			jsCode.WriteString(fmt.Sprintf("%s.add(session_res_%d.timings.waiting);\n", jsemit.Identifier(sessionEndpoint.Title), sessionEndpointIndex))
 
 			jsCode.WriteString(fmt.Sprintf("check(session_res_%d, {\n", sessionEndpointIndex))
-			jsCode.WriteString(fmt.Sprintf("'%s_status_200_check': (r) => r.status == 200,\n", sessionEndpoint.Title))
+			jsCode.WriteString(fmt.Sprintf("%s: (r) => r.status == 200,\n", jsemit.String(sessionEndpoint.Title+"_status_200_check")))
 
 			if sessionEndpoint.ResponseString != "" {
-				jsCode.WriteString(fmt.Sprintf("'%s_verify_response_text': (r) => r.body.includes('%s'),\n", sessionEndpoint.Title, sessionEndpoint.ResponseString))
+				jsCode.WriteString(fmt.Sprintf("%s: (r) => r.body.includes(%s),\n", jsemit.String(sessionEndpoint.Title+"_verify_response_text"), jsemit.String(sessionEndpoint.ResponseString)))
 			}
 			jsCode.WriteString("});\n")
 			jsCode.WriteString("sleep(1);\n")
@@ -482,10 +560,17 @@ func ValidateVpeconfigAndFiles(vpeconfigFolderPath string) error {
 				fmt.Printf("Parsed ExtracterConfig: %+v\n", extracterConfig)
 
 				for _, regexExtract := range extracterConfig.Extracter.RegexExtract {
+					if err := jsemit.CheckIdentifier(regexExtract.Name); err != nil {
+						return fmt.Errorf("extracter %s: %w", sessionEndpoint.Extracter, err)
+					}
 					if regexExtract.Type == "header" {
-						jsCode.WriteString(fmt.Sprintf("let %s = session_res_%d.headers['%s'];\n", regexExtract.Name, sessionEndpointIndex, strings.Split(regexExtract.Value, ":")[0]))
+						jsCode.WriteString(fmt.Sprintf("let %s = session_res_%d.headers[%s];\n", regexExtract.Name, sessionEndpointIndex, jsemit.String(strings.Split(regexExtract.Value, ":")[0])))
 					} else if regexExtract.Type == "body" {
-						jsCode.WriteString(fmt.Sprintf("let %s = session_res_%d.body.match(/%s/);\n", regexExtract.Name, sessionEndpointIndex, regexExtract.Value))
+						regex, err := jsemit.Regex(regexExtract.Value, "")
+						if err != nil {
+							return fmt.Errorf("extracter %s: %w", sessionEndpoint.Extracter, err)
+						}
+						jsCode.WriteString(fmt.Sprintf("let %s = session_res_%d.body.match(%s);\n", regexExtract.Name, sessionEndpointIndex, regex))
 
 						if strings.Contains(regexExtract.Value, "privateClaims") {
 							jsCode.WriteString(fmt.Sprintf("if (%s && %s[1]) { %s[1] = JSON.parse(\"{\"+%s[1]+\"}\"); }\n", regexExtract.Name, regexExtract.Name, regexExtract.Name, regexExtract.Name))
@@ -511,33 +596,7 @@ func ValidateVpeconfigAndFiles(vpeconfigFolderPath string) error {
 			jsCode.WriteString(fmt.Sprintf("const headers_%d = {\n", endpointIndex))
 			for _, header := range headersConfig.Headers.Header {
 				if header.Name != "" {
-					if !strings.Contains(header.Name, "${") && !strings.Contains(header.Value, "${") {
-						jsCode.WriteString(fmt.Sprintf(" '%s': '%s',\n", header.Name, header.Value))
-					} else {
-						headerNameFound := false
-						headerValueFound := false
-
-						if strings.Contains(header.Name, "${") && strings.Contains(header.Name, "}") {
-							header.Name = strings.Replace(header.Name, "${", "", -1)
-							header.Name = strings.Replace(header.Name, "}", "", -1)
-							headerNameFound = true
-						}
-						if strings.Contains(header.Value, "${") && strings.Contains(header.Value, "}") {
-							header.Value = strings.Replace(header.Value, "${", "", -1)
-							header.Value = strings.Replace(header.Value, "}", "", -1)
-							headerValueFound = true
-						}
-
-						if headerNameFound && headerValueFound {
-							jsCode.WriteString(fmt.Sprintf(" %s: %s,\n", header.Name, header.Value))
-						} else if headerNameFound && !headerValueFound {
-							jsCode.WriteString(fmt.Sprintf(" %s: '%s',\n", header.Name, header.Value))
-						} else if !headerNameFound && headerValueFound {
-							jsCode.WriteString(fmt.Sprintf(" '%s': %s,\n", header.Name, header.Value))
-						} else {
-							jsCode.WriteString(fmt.Sprintf(" '%s': '%s',\n", header.Name, header.Value))
-						}
-					}
+					jsCode.WriteString(headerEntry(header, envRefs))
 				}
 			}
 			jsCode.WriteString("};\n")
@@ -558,7 +617,7 @@ func ValidateVpeconfigAndFiles(vpeconfigFolderPath string) error {
 					bodyJSONsDataStr = removeNextClosingBrace(bodyJSONsDataStr)
 					bodyJSONsData = []byte(bodyJSONsDataStr)
 
-					jsCode.WriteString(fmt.Sprintf("const body_%d_%d = `%s`;\n", endpointIndex, bodyIndex, string(bodyJSONsData))) // Use backticks for multiline strings
+					jsCode.WriteString(fmt.Sprintf("const body_%d_%d = %s;\n", endpointIndex, bodyIndex, jsemit.Template(string(bodyJSONsData)))) // Use backticks for multiline strings
 					bodyFound = true
 				} else {
 					if endpoint.APIName != "" && strings.Contains(endpoint.APIName, "{"+bodyjson.Name+"}") {
@@ -586,24 +645,28 @@ func ValidateVpeconfigAndFiles(vpeconfigFolderPath string) error {
 			}
 
 			fmt.Printf("API Name: %s\n", endpoint.APIName)
-			jsCode.WriteString(fmt.Sprintf("const url_%d = '%s%s';\n", endpointIndex, endpoint.Domain, endpoint.APIName))
+			if envRefs != nil && endpoint.Domain != "" {
+				jsCode.WriteString(fmt.Sprintf("const url_%d = %s + %s;\n", endpointIndex, envRefs.Ref("BASE_URL", strings.TrimRight(endpoint.Domain, "/")), jsemit.String(endpoint.APIName)))
+			} else {
+				jsCode.WriteString(fmt.Sprintf("const url_%d = %s;\n", endpointIndex, jsemit.String(endpoint.Domain+endpoint.APIName)))
+			}
 			loopCount := endpoint.LoopCount
 			if endpoint.ExecuteOnce {
 				loopCount = 1
 			}
 			jsCode.WriteString(fmt.Sprintf("for (let i = 0; i < %d; i++) {\n", loopCount))
 			if bodyFound {
-				jsCode.WriteString(fmt.Sprintf("let res_%d = http.%s(url_%d, JSON.stringify(body_%d_0), {headers: headers_%d});\n", endpointIndex, strings.ToLower(endpoint.Method), endpointIndex, endpointIndex, endpointIndex))
+				jsCode.WriteString(fmt.Sprintf("let res_%d = http.%s(url_%d, JSON.stringify(body_%d_0), {headers: headers_%d});\n", endpointIndex, httpFunction(endpoint.Method), endpointIndex, endpointIndex, endpointIndex))
 			} else {
-				jsCode.WriteString(fmt.Sprintf("let res_%d = http.%s(url_%d, null, {headers: headers_%d});\n", endpointIndex, strings.ToLower(endpoint.Method), endpointIndex, endpointIndex))
+				jsCode.WriteString(fmt.Sprintf("let res_%d = http.%s(url_%d, null, {headers: headers_%d});\n", endpointIndex, httpFunction(endpoint.Method), endpointIndex, endpointIndex))
 			}
 
-			jsCode.WriteString(fmt.Sprintf("%s.add(res_%d.timings.waiting);\n", endpoint.Title, endpointIndex))
+			jsCode.WriteString(fmt.Sprintf("%s.add(res_%d.timings.waiting);\n", jsemit.Identifier(endpoint.Title), endpointIndex))
 			jsCode.WriteString(fmt.Sprintf("check(res_%d, {\n", endpointIndex))
-			jsCode.WriteString(fmt.Sprintf("'%s_status_200_check': (r) => r.status == 200,\n", endpoint.Title))
+			jsCode.WriteString(fmt.Sprintf("%s: (r) => r.status == 200,\n", jsemit.String(endpoint.Title+"_status_200_check")))
 
 			if endpoint.ResponseString != "" {
-				jsCode.WriteString(fmt.Sprintf("'%s_verify_response_text': (r) => r.body.includes('%s'),\n", endpoint.Title, endpoint.ResponseString))
+				jsCode.WriteString(fmt.Sprintf("%s: (r) => r.body.includes(%s),\n", jsemit.String(endpoint.Title+"_verify_response_text"), jsemit.String(endpoint.ResponseString)))
 			}
 			jsCode.WriteString("});\n")
 
@@ -621,10 +684,17 @@ func ValidateVpeconfigAndFiles(vpeconfigFolderPath string) error {
 				fmt.Printf("Parsed ExtracterConfig: %+v\n", extracterConfig)
 
 				for _, regexExtract := range extracterConfig.Extracter.RegexExtract {
+					if err := jsemit.CheckIdentifier(regexExtract.Name); err != nil {
+						return fmt.Errorf("extracter %s: %w", endpoint.Extracter, err)
+					}
 					if regexExtract.Type == "header" {
-						jsCode.WriteString(fmt.Sprintf("let %s = res_%d.headers['%s'];\n", regexExtract.Name, endpointIndex, strings.Split(regexExtract.Value, ":")[0]))
+						jsCode.WriteString(fmt.Sprintf("let %s = res_%d.headers[%s];\n", regexExtract.Name, endpointIndex, jsemit.String(strings.Split(regexExtract.Value, ":")[0])))
 					} else if regexExtract.Type == "body" {
-						jsCode.WriteString(fmt.Sprintf("let %s = res_%d.body.match(/%s/);\n", regexExtract.Name, endpointIndex, regexExtract.Value))
+						regex, err := jsemit.Regex(regexExtract.Value, "")
+						if err != nil {
+							return fmt.Errorf("extracter %s: %w", endpoint.Extracter, err)
+						}
+						jsCode.WriteString(fmt.Sprintf("let %s = res_%d.body.match(%s);\n", regexExtract.Name, endpointIndex, regex))
 					}
 				}
 			}
@@ -635,14 +705,26 @@ func ValidateVpeconfigAndFiles(vpeconfigFolderPath string) error {
 		jsCode.WriteString("// Generate HTML Report\n")
 		jsCode.WriteString("// export function handleSummary(data) {\n")
 		jsCode.WriteString(" // return {\n")
-		jsCode.WriteString(fmt.Sprintf(" // \"%s-summary.html\": htmlReport(data),\n", testType))
-		jsCode.WriteString(fmt.Sprintf(" // \"%s-summary.json\": JSON.stringify(data),\n", testType))
+		jsCode.WriteString(fmt.Sprintf(" // \"%s-summary.html\": htmlReport(data),\n", jsemit.Comment(testType)))
+		jsCode.WriteString(fmt.Sprintf(" // \"%s-summary.json\": JSON.stringify(data),\n", jsemit.Comment(testType)))
 		jsCode.WriteString(" // };\n")
 		jsCode.WriteString("// }\n")
 
 		// Modified section: Use the testType variable to generate the k6 script file name
 		k6ScriptFileName := filepath.Join(vpeconfigFolderPath, fmt.Sprintf("vpe-%s-script.js", testType))
-		err = os.WriteFile(k6ScriptFileName, []byte(jsCode.String()), 0644)
+		script := jsCode.String()
+		if len(envRefs.References()) > 0 {
+			envFileName := envrefs.FileName(filepath.Base(k6ScriptFileName))
+			script = script[:envCheckOffset] + envrefs.RequiredCheck(envRefs.Names(), envFileName) + script[envCheckOffset:]
+
+			// Secrets live only in the env file, so keep it private to the owner
+			err = os.WriteFile(filepath.Join(vpeconfigFolderPath, envFileName), []byte(envrefs.FileContent(filepath.Base(k6ScriptFileName), envRefs.References())), 0600)
+			if err != nil {
+				log.Fatalf("error: %v", err)
+			}
+			fmt.Println(envFileName, "has been generated with the __ENV values.")
+		}
+		err = os.WriteFile(k6ScriptFileName, []byte(script), 0644)
 		if err != nil {
 			log.Fatalf("error: %v", err)
 		}
@@ -659,6 +741,13 @@ func ValidateVpeconfigAndFiles(vpeconfigFolderPath string) error {
 
 		fmt.Println("Validating the generated", k6ScriptFileName, "test")
 	}
+	// Record which variables the generated scripts expect from __ENV
+	if len(envRefs.References()) > 0 {
+		if _, err := file.WriteString(fmt.Sprintf("export K6EnvVars=\"%s\"\n", strings.Join(envRefs.Names(), " "))); err != nil {
+			fmt.Println("Error writing to file:", err)
+			return nil
+		}
+	}
 	fmt.Println("All files validated")
 	return nil
 }
@@ -0,0 +1,109 @@
+package vpe
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"k6-generator/jsemit"
+)
+
+// hostileValues look like what Config.yml and its header, body and extracter files may carry into
+// the script.
+var hostileValues = []string{
+	"O'Brien",
+	`say "hi"`,
+	"line1\nline2",
+	"`${__ENV.SECRET}`",
+	"*/ throw new Error() /*",
+	`trailing\`,
+	"/users/{id}",
+	`"token":"([^"]+)"`,
+	"(",
+	"a{2,1}",
+	"[b-a]",
+	"*abc",
+	"emoji 🚀",
+}
+
+func TestValidateVpeconfigWithHostileValues(t *testing.T) {
+	for _, value := range hostileValues {
+		for _, envRefs := range []bool{false, true} {
+			folder := t.TempDir()
+			writeYAML(t, filepath.Join(folder, "headers.yml"), HeadersConfig{Headers: struct {
+				Header []Header `yaml:"header"`
+			}{Header: []Header{{Name: value, Value: value}, {Name: "X-Secret", Value: value, Sensitive: true}}}})
+			writeYAML(t, filepath.Join(folder, "extracter.yml"), ExtracterConfig{Extracter: Extracter{RegexExtract: []RegexExtract{
+				{Name: "token", Type: "body", Value: value},
+				{Name: "location", Type: "header", Value: value},
+			}}})
+			if err := os.WriteFile(filepath.Join(folder, "body.json"), []byte(value), 0644); err != nil {
+				t.Fatal(err)
+			}
+			endpoint := Endpoint{
+				Title:          value,
+				Method:         "POST",
+				Domain:         "https://api.example.com/",
+				APIName:        "/items/{id}",
+				HeadersFile:    "headers.yml",
+				Extracter:      "extracter.yml",
+				ResponseString: value,
+				LoopCount:      1,
+				BodyJSONs:      BodyJSONsConfig{BodyJson: []BodyJSON{{Name: "body", Value: "body.json"}, {Name: "id", Value: value}}},
+			}
+			session := endpoint
+			session.Title = "session " + value
+			session.APIName = "https://auth.example.com/token?q=" + value
+			config := VPEConfig{RequestInputXML: RequestInputXML{
+				TestType:    "load",
+				EnvRefs:     envRefs,
+				ThreadGroup: ThreadGroup{SessionEndpoint: []Endpoint{session}, Endpoint: []Endpoint{endpoint}},
+			}}
+			writeYAML(t, filepath.Join(folder, "Config.yml"), config)
+
+			if err := ValidateVpeconfigAndFiles(folder); err != nil {
+				// Only a pattern JavaScript cannot parse may stop the generation
+				if _, regexErr := jsemit.Regex(value, ""); regexErr == nil {
+					t.Fatalf("%q: %v", value, err)
+				}
+				continue
+			}
+			checkScriptSyntax(t, filepath.Join(folder, "vpe-load-script.js"))
+		}
+	}
+}
+
+func writeYAML(t *testing.T, path string, value interface{}) {
+	t.Helper()
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// checkScriptSyntax fails when node cannot parse the script at path as a module.
+func checkScriptSyntax(t *testing.T, path string) {
+	t.Helper()
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("node is not installed, the script is not parsed")
+	}
+	script, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// node only reads a script with import statements as a module from an .mjs file
+	module := filepath.Join(t.TempDir(), "script.mjs")
+	if err := os.WriteFile(module, script, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if output, err := exec.Command(node, "--check", module).CombinedOutput(); err != nil {
+		t.Fatalf("node rejected %s: %v\n%s\n%s", path, err, output, script)
+	}
+}
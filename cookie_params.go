@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// cookieFilePatterns lists the fitness files holding the cookie values of an operation, in lookup order:
+//
+//	cookies:
+//	  cookie:
+//	    - name: session
+//	      value: abc123
+//
+// The parameters/parameter layout of the other parameter files is accepted as well.
+func cookieFilePatterns(operationID string, endpoint string) []string {
+	endpointLastPart := filepath.Base(endpoint)
+	return []string{
+		fmt.Sprintf("%s_%s_cookie.yaml", operationID, endpointLastPart),
+		fmt.Sprintf("%s_%s_cookies.yaml", operationID, endpointLastPart),
+		fmt.Sprintf("%s_cookie.yaml", operationID),
+		fmt.Sprintf("%s_cookies.yaml", operationID),
+	}
+}
+
+// findCookieFile returns the first cookie file of an operation present in the environment folder.
+func findCookieFile(operationID string, endpoint string, fitnessPath string) (string, bool) {
+	for _, pattern := range cookieFilePatterns(operationID, endpoint) {
+		if fileExists(filepath.Join(fitnessPath, pattern)) {
+			return pattern, true
+		}
+	}
+	return "", false
+}
+
+// cookieParameterValues resolves the cookie parameters of an operation from its cookie file, falling
+// back to the Swagger examples. Cookies with neither are left out.
+func cookieParameterValues(operationID string, endpoint string, cookieParams []map[string]interface{}, fitnessPath string) map[string]string {
+	fileValues := make(map[string]string)
+	if fileName, found := findCookieFile(operationID, endpoint, fitnessPath); found {
+		if content, err := readFileContent(filepath.Join(fitnessPath, fileName)); err == nil {
+			fileValues = parseYamlManually(content)
+		}
+	}
+
+	values := make(map[string]string)
+	for _, param := range cookieParams {
+		name, ok := param["name"].(string)
+		if !ok {
+			continue
+		}
+		if value, ok := fileValues[name]; ok && strings.TrimSpace(value) != "" {
+			values[name] = value
+		} else if example, ok := getPathParamExample(name, cookieParams); ok {
+			values[name] = example
+		}
+	}
+	return values
+}
+
+// validateCookieParameters records the cookie parameters of an operation and reports required cookies
+// without a value: as a missing cookie file when there is none, otherwise as empty values of the file.
+// Cookies supplied by a dataset or flow need no value.
+func validateCookieParameters(cookieParams []map[string]interface{}, operationID string, endpoint string, fitnessPath string, supplied map[string]string, validationReport *ValidationReport) {
+	cookieParamNames := make([]string, 0, len(cookieParams))
+	for _, p := range cookieParams {
+		if name, ok := p["name"].(string); ok {
+			cookieParamNames = append(cookieParamNames, name)
+		}
+	}
+
+	endpointDetails := validationReport.Endpoints[operationID]
+	endpointDetails.CookieParams = cookieParamNames
+	validationReport.Endpoints[operationID] = endpointDetails
+
+	values := cookieParameterValues(operationID, endpoint, cookieParams, fitnessPath)
+	var unresolved []string
+	for _, param := range cookieParams {
+		name, _ := param["name"].(string)
+		if _, isSupplied := supplied[name]; isSupplied {
+			continue
+		}
+		if _, ok := values[name]; !ok && parameterRequired(param) {
+			unresolved = append(unresolved, name)
+		}
+	}
+
+	fileName, found := findCookieFile(operationID, endpoint, fitnessPath)
+	if !found {
+		if len(unresolved) > 0 {
+			fmt.Printf("❌ No cookie file or Swagger examples found for required cookies in operation: %s\n", operationID)
+			validationReport.MissingFiles = append(validationReport.MissingFiles, MissingFile{
+				File:        fmt.Sprintf("%s_cookies.yaml", operationID),
+				Type:        "cookie",
+				OperationID: operationID,
+			})
+			return
+		}
+		fmt.Printf("⚠️  Cookie file not found, using Swagger examples as fallback for operation: %s\n", operationID)
+		return
+	}
+
+	if len(unresolved) > 0 {
+		fmt.Printf("Warning: no value for required cookies %s in operation: %s\n", strings.Join(unresolved, ", "), operationID)
+		validationReport.EmptyValues = append(validationReport.EmptyValues, EmptyValue{
+			File:        fileName,
+			Type:        "cookie",
+			OperationID: operationID,
+			Issue:       fmt.Sprintf("No value for required cookies: %s", strings.Join(unresolved, ", ")),
+		})
+	}
+}
+
+// cookieParameterSpecs returns the cookie parameters among the merged parameters of an operation.
+func cookieParameterSpecs(specs []map[string]interface{}) []map[string]interface{} {
+	var cookies []map[string]interface{}
+	for _, spec := range specs {
+		if spec["in"] == "cookie" {
+			cookies = append(cookies, spec)
+		}
+	}
+	return cookies
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"k6-generator/jsemit"
+
+	"gopkg.in/yaml.v2"
+)
+
+// datasetsFileName links operations to CSV or JSON datasets of the environment folder, so every
+// iteration sends values from a dataset row instead of the single value of the fitness files:
+//
+//	operations:
+//	  getUser:
+//	    file: users.csv
+//	    strategy: random
+//	    path:
+//	      id: user_id
+//	    body:
+//	      profile.name: name
+//
+// Without path, query, headers, cookies or body mappings, the columns named like a parameter of the
+// operation drive that parameter.
+const datasetsFileName = "datasets.yaml"
+
+// Dataset row selection strategies
+const (
+	// datasetStrategySequential walks the rows in order, each VU starting at its own offset
+	datasetStrategySequential = "sequential"
+	// datasetStrategyRandom picks a random row on every iteration
+	datasetStrategyRandom = "random"
+	// datasetStrategyUnique gives every iteration of the scenario its own row and aborts the test when none are left
+	datasetStrategyUnique = "unique"
+)
+
+// papaparseURL is the k6 jslib build of papaparse, used to parse CSV datasets.
+const papaparseURL = "https://jslib.k6.io/papaparse/5.1.1/index.js"
+
+// OperationDataset maps the columns of a dataset to the request values of an operation. Row values
+// are sent percent-encoded as they are, whatever the style of the parameter.
+type OperationDataset struct {
+	File            string `yaml:"file" json:"file"`
+	Strategy        string `yaml:"strategy" json:"strategy,omitempty"`
+	RequestMappings `yaml:",inline"`
+}
+
+// DatasetConfig is the content of datasets.yaml, keyed by operationId.
+type DatasetConfig struct {
+	Operations map[string]OperationDataset `yaml:"operations" json:"operations"`
+}
+
+func (dataset OperationDataset) strategy() string {
+	if dataset.Strategy == "" {
+		return datasetStrategySequential
+	}
+	return dataset.Strategy
+}
+
+// loadDatasetConfig reads datasets.yaml from an environment folder; found is false when it does not exist.
+func loadDatasetConfig(fitnessPath string) (DatasetConfig, bool, error) {
+	var config DatasetConfig
+
+	configPath := filepath.Join(fitnessPath, datasetsFileName)
+	if !fileExists(configPath) {
+		return config, false, nil
+	}
+
+	content, err := readFileContent(configPath)
+	if err != nil {
+		return config, true, err
+	}
+	if err := yaml.UnmarshalStrict([]byte(content), &config); err != nil {
+		return config, true, fmt.Errorf("error parsing %s: %w", datasetsFileName, err)
+	}
+	return config, true, nil
+}
+
+// readDatasetColumns returns the columns and the number of rows of a dataset: the header row of a CSV
+// file, or the keys of the objects of a JSON array.
+func readDatasetColumns(fitnessPath string, fileName string) ([]string, int, error) {
+	datasetPath := filepath.Join(fitnessPath, fileName)
+	if !fileExists(datasetPath) {
+		return nil, 0, fmt.Errorf("dataset %s not found", fileName)
+	}
+	content, err := readFileContent(datasetPath)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".csv":
+		records, err := csv.NewReader(strings.NewReader(content)).ReadAll()
+		if err != nil {
+			return nil, 0, fmt.Errorf("error parsing dataset %s: %w", fileName, err)
+		}
+		if len(records) == 0 {
+			return nil, 0, nil
+		}
+		return records[0], len(records) - 1, nil
+
+	case ".json":
+		var rows []map[string]interface{}
+		if err := json.Unmarshal([]byte(content), &rows); err != nil {
+			return nil, 0, fmt.Errorf("dataset %s must be a JSON array of objects: %w", fileName, err)
+		}
+		seen := make(map[string]bool)
+		var columns []string
+		for _, row := range rows {
+			for _, column := range sortedKeys(row) {
+				if !seen[column] {
+					seen[column] = true
+					columns = append(columns, column)
+				}
+			}
+		}
+		return columns, len(rows), nil
+	}
+	return nil, 0, fmt.Errorf("dataset %s must be a .csv or .json file", fileName)
+}
+
+// datasetMappings returns the mappings of an operation's dataset, matching columns to parameters of
+// the same name when none are configured.
+func datasetMappings(dataset OperationDataset, details EndpointDetails, columns []string) OperationDataset {
+	if dataset.mapped() {
+		return dataset
+	}
+
+	byName := func(names []string) map[string]string {
+		matched := make(map[string]string)
+		for _, name := range names {
+			for _, column := range columns {
+				if column == name {
+					matched[name] = column
+				}
+			}
+		}
+		return matched
+	}
+	dataset.Path = byName(pathPlaceholderNames(details.Path))
+	dataset.Query = byName(details.QueryParams)
+	dataset.Headers = byName(details.HeaderParams)
+	dataset.Cookies = byName(details.CookieParams)
+	return dataset
+}
+
+// operationDataset returns the dataset of an operation with its resolved mappings.
+func operationDataset(config *DatasetConfig, operationID string, details EndpointDetails, fitnessPath string) (OperationDataset, bool) {
+	if config == nil {
+		return OperationDataset{}, false
+	}
+	dataset, ok := config.Operations[operationID]
+	if !ok {
+		return OperationDataset{}, false
+	}
+	columns, _, _ := readDatasetColumns(fitnessPath, dataset.File)
+	return datasetMappings(dataset, details, columns), true
+}
+
+// validateDatasetConfig checks that every dataset belongs to an operation, can be read and has rows,
+// and that its mappings name parameters of the operation and columns of the dataset.
+func validateDatasetConfig(config DatasetConfig, endpoints map[string]EndpointDetails, skipped []SkippedOperation, fitnessPath string) []string {
+	var problems []string
+
+	operationIDs := make([]string, 0, len(config.Operations))
+	for operationID := range config.Operations {
+		operationIDs = append(operationIDs, operationID)
+	}
+	sort.Strings(operationIDs)
+
+	for _, operationID := range operationIDs {
+		dataset := config.Operations[operationID]
+		prefix := "operations." + operationID
+
+		details, ok := endpoints[operationID]
+		if !ok {
+			problems = append(problems, "operations: "+missingOperation(operationID, skipped))
+			continue
+		}
+		switch dataset.strategy() {
+		case datasetStrategySequential, datasetStrategyRandom, datasetStrategyUnique:
+		default:
+			problems = append(problems, fmt.Sprintf("%s.strategy: unknown strategy %q (expected %s, %s or %s)", prefix, dataset.Strategy, datasetStrategySequential, datasetStrategyRandom, datasetStrategyUnique))
+		}
+		if dataset.File == "" {
+			problems = append(problems, prefix+".file is required")
+			continue
+		}
+		columns, rows, err := readDatasetColumns(fitnessPath, dataset.File)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s.file: %v", prefix, err))
+			continue
+		}
+		if rows == 0 {
+			problems = append(problems, fmt.Sprintf("%s.file: dataset %s has no rows", prefix, dataset.File))
+		}
+
+		hasColumn := make(map[string]bool, len(columns))
+		for _, column := range columns {
+			hasColumn[column] = true
+		}
+		problems = append(problems, dataset.problems(prefix, details, func(column string) string {
+			if hasColumn[column] {
+				return ""
+			}
+			return fmt.Sprintf("dataset %s has no column %s", dataset.File, column)
+		})...)
+	}
+	return problems
+}
+
+// loadDatasetFile reads an environment's datasets.yaml into the report before the operations are
+// validated, so path placeholders read from a dataset need no other value.
+func loadDatasetFile(fitnessPath string, validationReport *ValidationReport) {
+	config, found, err := loadDatasetConfig(fitnessPath)
+	if err != nil {
+		validationReport.DatasetIssues = append(validationReport.DatasetIssues, err.Error())
+		return
+	}
+	if !found {
+		return
+	}
+	fmt.Println("Using datasets:", datasetsFileName)
+	validationReport.Datasets = &config
+}
+
+// validateDatasetFile records the problems of the datasets once every operation is known.
+func validateDatasetFile(fitnessPath string, validationReport *ValidationReport) {
+	if validationReport.Datasets == nil {
+		return
+	}
+	validationReport.DatasetIssues = append(validationReport.DatasetIssues, validateDatasetConfig(*validationReport.Datasets, validationReport.Endpoints, validationReport.SkippedOperations, fitnessPath)...)
+}
+
+// datasetVariableName is the SharedArray holding a dataset; operations using the same file share it.
+func datasetVariableName(fileName string) string {
+	return "dataset_" + scenarioName(fileName)
+}
+
+// datasetInitCode declares a SharedArray for every dataset used by the operations, in file order, and
+// returns the imports they need.
+func datasetInitCode(config *DatasetConfig, operationIDs []string, fitnessPath string) (string, string) {
+	if config == nil {
+		return "", ""
+	}
+
+	files := make(map[string]bool)
+	usesCSV, usesUnique := false, false
+	for _, operationID := range operationIDs {
+		dataset, ok := config.Operations[operationID]
+		if !ok {
+			continue
+		}
+		files[dataset.File] = true
+		usesCSV = usesCSV || strings.EqualFold(filepath.Ext(dataset.File), ".csv")
+		usesUnique = usesUnique || dataset.strategy() == datasetStrategyUnique
+	}
+	if len(files) == 0 {
+		return "", ""
+	}
+
+	imports := "import { SharedArray } from 'k6/data';\n"
+	if usesCSV {
+		imports += fmt.Sprintf("import papaparse from %s;\n", jsemit.String(papaparseURL))
+	}
+	if usesUnique {
+		imports += "import exec from 'k6/execution';\n"
+	}
+
+	code := ""
+	for _, fileName := range sortedBoolKeys(files) {
+		openCall := fmt.Sprintf("open(%s)", jsemit.String(scriptRelativePath(fitnessPath, fileName)))
+		rows := fmt.Sprintf("JSON.parse(%s)", openCall)
+		if strings.EqualFold(filepath.Ext(fileName), ".csv") {
+			rows = fmt.Sprintf("papaparse.parse(%s, { header: true, skipEmptyLines: true }).data", openCall)
+		}
+		code += fmt.Sprintf("const %s = new SharedArray(%s, function () {\n\treturn %s;\n});\n", datasetVariableName(fileName), jsemit.String(fileName), rows)
+	}
+	return code, imports
+}
+
+// datasetRowCode selects the dataset row of the current iteration for an operation.
+func datasetRowCode(operationID string, dataset OperationDataset) string {
+	rows := datasetVariableName(dataset.File)
+	rowVariable := operationIdentifier(operationID) + "_row"
+
+	switch dataset.strategy() {
+	case datasetStrategyRandom:
+		return fmt.Sprintf("\tconst %s = %s[Math.floor(Math.random() * %s.length)];\n", rowVariable, rows, rows)
+	case datasetStrategyUnique:
+		code := fmt.Sprintf("\tif (exec.scenario.iterationInTest >= %s.length) {\n", rows)
+		code += fmt.Sprintf("\t\texec.test.abort(%s);\n", jsemit.String(fmt.Sprintf("dataset %s has no rows left for %s", dataset.File, operationID)))
+		code += "\t}\n"
+		return code + fmt.Sprintf("\tconst %s = %s[exec.scenario.iterationInTest];\n", rowVariable, rows)
+	}
+	return fmt.Sprintf("\tconst %s = %s[(__VU - 1 + __ITER) %% %s.length];\n", rowVariable, rows, rows)
+}
+
+// datasetValue is the expression reading a column of an operation's current row.
+func datasetValue(operationID string, column string) string {
+	return fmt.Sprintf("%s_row[%s]", operationIdentifier(operationID), jsonString(column))
+}
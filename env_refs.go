@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"k6-generator/envrefs"
+	"k6-generator/jsemit"
+
+	"gopkg.in/yaml.v2"
+)
+
+// documentBaseURLEnvName is the variable holding the document server URL when env refs are enabled.
+const documentBaseURLEnvName = "BASE_URL"
+
+// envVarsNameInvalidChars matches what an environment folder name cannot keep in an env_vars variable.
+var envVarsNameInvalidChars = regexp.MustCompile(`[^A-Z0-9_]+`)
+
+// EnvRefsConfig controls emitting __ENV lookups instead of literal values in generated scripts.
+type EnvRefsConfig struct {
+	// Enabled emits base URLs and sensitive values as __ENV references with a companion env file
+	Enabled bool `yaml:"enabled"`
+	// Sensitive lists extra parameter names whose values are secrets
+	Sensitive []string `yaml:"sensitive"`
+}
+
+// envRefsEnabled reports whether the config file or --env-refs asked for __ENV references.
+func envRefsEnabled() bool {
+	return generatorConfig.EnvRefs.Enabled || swaggerOptions.EnvRefs
+}
+
+// isSensitiveName reports whether a parameter value should not be written into the script: the
+// name looks like a credential, is listed in the config, or is marked sensitive in a fitness file.
+func isSensitiveName(name string, marked map[string]bool) bool {
+	if marked[name] {
+		return true
+	}
+	for _, configured := range generatorConfig.EnvRefs.Sensitive {
+		if strings.EqualFold(configured, name) {
+			return true
+		}
+	}
+	return envrefs.LooksSensitive(name)
+}
+
+// findSensitiveFitnessNames collects the names marked "sensitive: true" in the YAML fitness files of an operation.
+func findSensitiveFitnessNames(operationID string, fitnessPath string) map[string]bool {
+	marked := make(map[string]bool)
+
+	files, err := ioutil.ReadDir(fitnessPath)
+	if err != nil {
+		return marked
+	}
+
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || !strings.HasPrefix(name, operationID+"_") || !strings.HasSuffix(name, ".yaml") {
+			continue
+		}
+		content, err := readFileContent(filepath.Join(fitnessPath, name))
+		if err != nil {
+			continue
+		}
+
+		var parsed interface{}
+		if err := yaml.Unmarshal([]byte(content), &parsed); err != nil {
+			continue
+		}
+		collectSensitiveNames(normalizeYAMLValue(parsed), marked)
+	}
+	return marked
+}
+
+// collectSensitiveNames walks parameter and header entries, single or in lists, for sensitive: true.
+func collectSensitiveNames(node interface{}, marked map[string]bool) {
+	switch value := node.(type) {
+	case map[string]interface{}:
+		if name, ok := value["name"].(string); ok && name != "" {
+			if sensitive, _ := value["sensitive"].(bool); sensitive {
+				marked[name] = true
+			}
+		}
+		for _, key := range sortedKeys(value) {
+			collectSensitiveNames(value[key], marked)
+		}
+	case []interface{}:
+		for _, child := range value {
+			collectSensitiveNames(child, marked)
+		}
+	}
+}
+
+// pathExpression builds a JavaScript expression for a templated path, reading the values of
+// sensitive path parameters from __ENV and inlining the others, already encoded by encodedPathParams.
+func pathExpression(path string, encodedParams map[string]string, sensitive map[string]string) string {
+	var parts []string
+	literal := ""
+	last := 0
+	for _, match := range pathPlaceholderPattern.FindAllStringSubmatchIndex(path, -1) {
+		name := path[match[2]:match[3]]
+		literal += path[last:match[0]]
+		last = match[1]
+
+		if expression, ok := sensitive[name]; ok {
+			if literal != "" {
+				parts = append(parts, jsemit.String(literal))
+			}
+			literal = ""
+			parts = append(parts, fmt.Sprintf("encodeURIComponent(%s)", expression))
+			continue
+		}
+		literal += replacePathPlaceholders(path[match[0]:match[1]], encodedParams)
+	}
+	literal += path[last:]
+	if literal != "" || len(parts) == 0 {
+		parts = append(parts, jsemit.String(literal))
+	}
+	return strings.Join(parts, " + ")
+}
+
+// formatAsJSObject renders a header map like formatAsJSON, with the values of the given keys
+// replaced by JavaScript expressions.
+func formatAsJSObject(data map[string]string, expressions map[string]string) string {
+	var entries []string
+	for _, key := range sortedStringKeys(data) {
+		value := jsonString(data[key])
+		if expression, ok := expressions[key]; ok {
+			value = expression
+		}
+		entries = append(entries, jsonString(key)+":"+value)
+	}
+	return "{" + strings.Join(entries, ",") + "}"
+}
+
+// jsonString quotes a value as JSON, which is also a valid JavaScript string literal.
+func jsonString(value string) string {
+	return formatSchemaValue(value)
+}
+
+// recordScriptEnvVars writes one k6EnvVars_<environment> export per script to env_vars, listing the
+// variables that script reads from __ENV and replacing the line from an earlier run.
+func recordScriptEnvVars(envVarsFilePath string, scriptEnvVars map[string][]string) error {
+	content, err := readFileContent(envVarsFilePath)
+	if err != nil {
+		return err
+	}
+
+	lines := make(map[string]string)
+	var order []string
+	environments := make([]string, 0, len(scriptEnvVars))
+	for environment := range scriptEnvVars {
+		environments = append(environments, environment)
+	}
+	sort.Strings(environments)
+
+	for _, environment := range environments {
+		name := "k6EnvVars_" + strings.Trim(envVarsNameInvalidChars.ReplaceAllString(strings.ToUpper(environment), "_"), "_")
+		lines[name] = fmt.Sprintf("export %s=\"%s\"", name, strings.Join(scriptEnvVars[environment], " "))
+		order = append(order, name)
+	}
+
+	var kept []string
+	for _, line := range strings.Split(strings.TrimRight(content, "\n"), "\n") {
+		replaced := false
+		for _, name := range order {
+			if strings.HasPrefix(line, "export "+name+"=") {
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			kept = append(kept, line)
+		}
+	}
+	for _, name := range order {
+		kept = append(kept, lines[name])
+	}
+	return ioutil.WriteFile(envVarsFilePath, []byte(strings.Join(kept, "\n")+"\n"), 0644)
+}
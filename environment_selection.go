@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Environment selection modes for EnvironmentSelection.Mode
+const (
+	environmentModeLegacy = "legacy"
+	environmentModeAll    = "all"
+)
+
+// EnvironmentSelection decides which folders under fitness/ are treated as environments.
+// An explicit list wins over include globs, include globs win over the mode, and exclude
+// globs always apply. The legacy mode keeps the original dev/uat folder rule.
+type EnvironmentSelection struct {
+	Mode    string   `yaml:"mode"`
+	List    []string `yaml:"list"`
+	Include []string `yaml:"include"`
+	Exclude []string `yaml:"exclude"`
+}
+
+// SkippedEnvironment is a fitness subfolder that was not selected, with the reason.
+type SkippedEnvironment struct {
+	Folder string `json:"folder"`
+	Reason string `json:"reason"`
+}
+
+// mergeEnvironmentSelection overlays command-line settings on the config file settings.
+func mergeEnvironmentSelection(fromConfig EnvironmentSelection, fromFlags EnvironmentSelection) EnvironmentSelection {
+	merged := fromConfig
+	if len(fromFlags.List) > 0 {
+		merged.List = fromFlags.List
+	}
+	if len(fromFlags.Include) > 0 {
+		merged.Include = fromFlags.Include
+	}
+	if len(fromFlags.Exclude) > 0 {
+		merged.Exclude = fromFlags.Exclude
+	}
+	if fromFlags.Mode != "" {
+		merged.Mode = fromFlags.Mode
+	}
+	return merged
+}
+
+// selectEnvironmentFolders applies the selection to the subfolders of the fitness folder and returns
+// the chosen environments, a description of the rule used and the folders that were skipped.
+func selectEnvironmentFolders(fitnessPath string, selection EnvironmentSelection) ([]string, string, []SkippedEnvironment, error) {
+	mode := strings.ToLower(strings.TrimSpace(selection.Mode))
+	if mode != "" && mode != environmentModeLegacy && mode != environmentModeAll {
+		return nil, "", nil, fmt.Errorf("unknown environment mode %q (expected %s or %s)", selection.Mode, environmentModeLegacy, environmentModeAll)
+	}
+	for _, pattern := range append(append([]string{}, selection.Include...), selection.Exclude...) {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, "", nil, fmt.Errorf("invalid environment glob %q: %v", pattern, err)
+		}
+	}
+
+	files, err := ioutil.ReadDir(fitnessPath)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	var folders []string
+	for _, file := range files {
+		if file.IsDir() {
+			folders = append(folders, file.Name())
+		}
+	}
+	sort.Strings(folders)
+
+	var selected []string
+	var skipped []SkippedEnvironment
+	var rule string
+
+	switch {
+	case len(selection.List) > 0:
+		rule = "explicit list: " + strings.Join(selection.List, ", ")
+		listed := make(map[string]bool)
+		for _, environment := range selection.List {
+			listed[environment] = true
+			selected = append(selected, environment)
+		}
+		for _, folder := range folders {
+			if !listed[folder] {
+				skipped = append(skipped, SkippedEnvironment{Folder: folder, Reason: "not in the environment list"})
+			}
+		}
+	case len(selection.Include) > 0:
+		rule = "include globs: " + strings.Join(selection.Include, ", ")
+		for _, folder := range folders {
+			if matchesAnyGlob(folder, selection.Include) {
+				selected = append(selected, folder)
+			} else {
+				skipped = append(skipped, SkippedEnvironment{Folder: folder, Reason: "does not match the include globs"})
+			}
+		}
+	case mode == environmentModeAll:
+		rule = "all subfolders containing a Swagger/OpenAPI file"
+		for _, folder := range folders {
+			swaggerFile, err := findSwaggerFile(filepath.Join(fitnessPath, folder))
+			if err == nil && swaggerFile != "" {
+				selected = append(selected, folder)
+			} else {
+				skipped = append(skipped, SkippedEnvironment{Folder: folder, Reason: "no Swagger/OpenAPI file"})
+			}
+		}
+	default:
+		rule = "default dev/uat folder names (dev, uat, gt-dev*, sw-dev*, gt-uat*, sw-uat*)"
+		legacy := make(map[string]bool)
+		for _, environment := range detectEnvironmentFolders(fitnessPath) {
+			legacy[environment] = true
+			selected = append(selected, environment)
+		}
+		for _, folder := range folders {
+			if !legacy[folder] {
+				skipped = append(skipped, SkippedEnvironment{Folder: folder, Reason: "not a dev/uat folder name"})
+			}
+		}
+	}
+
+	if len(selection.Exclude) > 0 {
+		rule += "; exclude globs: " + strings.Join(selection.Exclude, ", ")
+		var kept []string
+		for _, environment := range selected {
+			if matchesAnyGlob(environment, selection.Exclude) {
+				skipped = append(skipped, SkippedEnvironment{Folder: environment, Reason: "matches the exclude globs"})
+				continue
+			}
+			kept = append(kept, environment)
+		}
+		selected = kept
+	}
+
+	sort.Slice(skipped, func(i, j int) bool { return skipped[i].Folder < skipped[j].Folder })
+	return selected, rule, skipped, nil
+}
+
+func matchesAnyGlob(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matched, err := filepath.Match(pattern, name); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+// Package envrefs keeps base URLs and secrets out of generated k6 scripts: the scripts read them from
+// __ENV, and a companion env file next to each script exports the values.
+package envrefs
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"k6-generator/jsemit"
+)
+
+var nameInvalidChars = regexp.MustCompile(`[^A-Z0-9_]+`)
+
+// sensitiveNameParts mark header and parameter names whose values are treated as secrets.
+var sensitiveNameParts = []string{"authorization", "cookie", "token", "secret", "password", "api-key", "api_key", "apikey"}
+
+// Reference is one variable a generated script reads from __ENV, with the value written to its env file.
+type Reference struct {
+	Name  string
+	Value string
+}
+
+// Registry hands out __ENV variable names in the order they are first used.
+type Registry struct {
+	references []Reference
+	byName     map[string]string
+}
+
+func NewRegistry() *Registry {
+	return &Registry{byName: make(map[string]string)}
+}
+
+// Ref returns the __ENV expression for a value, reusing the name when it already holds the same
+// value and adding a numeric suffix when it holds a different one.
+func (r *Registry) Ref(name string, value string) string {
+	base := VariableName(name)
+	candidate := base
+	for i := 2; ; i++ {
+		existing, taken := r.byName[candidate]
+		if !taken {
+			r.byName[candidate] = value
+			r.references = append(r.references, Reference{Name: candidate, Value: value})
+			break
+		}
+		if existing == value {
+			break
+		}
+		candidate = fmt.Sprintf("%s_%d", base, i)
+	}
+	return "__ENV." + candidate
+}
+
+// References returns the variables handed out so far; a nil registry has none.
+func (r *Registry) References() []Reference {
+	if r == nil {
+		return nil
+	}
+	return r.references
+}
+
+func (r *Registry) Names() []string {
+	return Names(r.References())
+}
+
+func Names(references []Reference) []string {
+	names := make([]string, 0, len(references))
+	for _, reference := range references {
+		names = append(names, reference.Name)
+	}
+	return names
+}
+
+// VariableName turns a header or parameter name into an upper-case shell variable name.
+func VariableName(name string) string {
+	converted := nameInvalidChars.ReplaceAllString(strings.ToUpper(name), "_")
+	converted = strings.Trim(converted, "_")
+	if converted == "" || (converted[0] >= '0' && converted[0] <= '9') {
+		converted = "_" + converted
+	}
+	return converted
+}
+
+// LooksSensitive reports whether a header or parameter name looks like a credential.
+func LooksSensitive(name string) bool {
+	lower := strings.ToLower(name)
+	for _, part := range sensitiveNameParts {
+		if strings.Contains(lower, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// RequiredCheck emits an init-time guard that fails fast when a variable of the env file is not set.
+func RequiredCheck(names []string, envFileName string) string {
+	return fmt.Sprintf(`
+// Values read from the environment; source %s before running k6
+for (const name of %s) {
+	if (!__ENV[name]) {
+		throw new Error('environment variable ' + name + %s);
+	}
+}
+`, jsemit.Comment(envFileName), jsemit.StringArray(names), jsemit.String(" is not set; source "+envFileName+" first"))
+}
+
+// FileContent renders the companion env file as shell exports.
+func FileContent(scriptName string, references []Reference) string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("# Variables read by %s through __ENV; keep this file out of version control\n", scriptName))
+	for _, reference := range references {
+		builder.WriteString(fmt.Sprintf("export %s='%s'\n", reference.Name, strings.ReplaceAll(reference.Value, "'", `'\''`)))
+	}
+	return builder.String()
+}
+
+// FileName is the companion env file of a generated script.
+func FileName(scriptName string) string {
+	return strings.TrimSuffix(scriptName, filepath.Ext(scriptName)) + ".env"
+}
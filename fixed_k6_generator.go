@@ -6,9 +6,13 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
-	"k6-generator/constants"
 	"gopkg.in/yaml.v2"
+
+	"k6-generator/envrefs"
+	"k6-generator/jsemit"
 )
 
 var swaggerIndicators = []string{
@@ -18,11 +22,29 @@ var swaggerIndicators = []string{
 	`'swagger':`,
 }
 
+// pathPlaceholderPattern matches OpenAPI path templating segments such as {id}.
+var pathPlaceholderPattern = regexp.MustCompile(`\{([^{}/]+)\}`)
+
 type ValidationReport struct {
-	MissingServerURL bool                           `json:"missingServerUrl"`
-	Endpoints        map[string]EndpointDetails     `json:"endpoints"`
-	MissingFiles     []MissingFile                  `json:"missingFiles"`
-	EmptyValues      []EmptyValue                   `json:"emptyValues"`
+	SpecVersion       string                     `json:"specVersion,omitempty"`
+	// ConversionWarnings lists the Swagger 2.0 features dropped when converting to OpenAPI 3
+	ConversionWarnings []string                  `json:"conversionWarnings,omitempty"`
+	MissingServerURL  bool                       `json:"missingServerUrl"`
+	ServerURL         string                     `json:"serverUrl,omitempty"`
+	ServerIssue       string                     `json:"serverIssue,omitempty"`
+	LoadProfile       *LoadProfile               `json:"loadProfile,omitempty"`
+	ProfileIssues     []string                   `json:"profileIssues,omitempty"`
+	SLO               *SLOConfig                 `json:"slo,omitempty"`
+	SLOIssues         []string                   `json:"sloIssues,omitempty"`
+	Datasets          *DatasetConfig             `json:"datasets,omitempty"`
+	DatasetIssues     []string                   `json:"datasetIssues,omitempty"`
+	Flow              *FlowConfig                `json:"flow,omitempty"`
+	FlowIssues        []string                   `json:"flowIssues,omitempty"`
+	LinkWarnings      []string                   `json:"linkWarnings,omitempty"`
+	SkippedOperations []SkippedOperation         `json:"skippedOperations,omitempty"`
+	Endpoints         map[string]EndpointDetails `json:"endpoints"`
+	MissingFiles      []MissingFile              `json:"missingFiles"`
+	EmptyValues       []EmptyValue               `json:"emptyValues"`
 }
 
 type EndpointDetails struct {
@@ -30,8 +52,18 @@ type EndpointDetails struct {
 	Method       string      `json:"method"`
 	QueryParams  []string    `json:"queryParams"`
 	HeaderParams []string    `json:"headerParams"`
+	CookieParams []string    `json:"cookieParams,omitempty"`
+	PathParams   map[string]string `json:"pathParams,omitempty"`
+	ExpectedStatus []string  `json:"expectedStatus,omitempty"`
 	BodyContent  interface{} `json:"bodyContent"`
 	BodyFile     string      `json:"bodyFile,omitempty"`
+	BodySource   string      `json:"bodySource,omitempty"`
+	ContentType  string      `json:"contentType,omitempty"`
+	ServerURL    string      `json:"serverUrl,omitempty"`
+	Security     []string    `json:"security,omitempty"`
+	Tags         []string    `json:"tags,omitempty"`
+	// Synthesized is set when the spec gave no operationId and one was made from the method and path
+	Synthesized  bool        `json:"synthesizedOperationId,omitempty"`
 	Issues       []Issue     `json:"issues"`
 }
 
@@ -128,11 +160,6 @@ func parseYamlManually(content string) map[string]string {
 	content = strings.Replace(content, "\r\n", "\n", -1)
 
 	// Define structs to match the expected YAML structure
-	type Parameter struct {
-		Name  string `yaml:"name"`
-		Value string `yaml:"value"`
-	}
-
 	type Root struct {
 		Parameters struct {
 			Parameter interface{} `yaml:"parameter"` // Can be single object or array
@@ -141,6 +168,9 @@ func parseYamlManually(content string) map[string]string {
 			Header interface{} `yaml:"header"` // Can be single object or array
 		} `yaml:"headers"`
 		Header interface{} `yaml:"header"` // Direct header field (alternative naming)
+		Cookies struct {
+			Cookie interface{} `yaml:"cookie"` // Can be single object or array
+		} `yaml:"cookies"`
 	}
 
 	var parsedYaml Root
@@ -150,26 +180,7 @@ func parseYamlManually(content string) map[string]string {
 	}
 
 	// Extract parameter values (handle both single and array)
-	if parsedYaml.Parameters.Parameter != nil {
-		switch params := parsedYaml.Parameters.Parameter.(type) {
-		case map[interface{}]interface{}: // Single parameter object
-			var singleParam Parameter
-			if err := mapToStruct(params, &singleParam); err == nil {
-				if singleParam.Name != "" && singleParam.Value != "" {
-					result[singleParam.Name] = singleParam.Value
-				}
-			}
-		case []interface{}: // Array of parameter objects
-			for _, item := range params {
-				var param Parameter
-				if err := mapToStruct(item, &param); err == nil {
-					if param.Name != "" && param.Value != "" {
-						result[param.Name] = param.Value
-					}
-				}
-			}
-		}
-	}
+	addEntries(result, parsedYaml.Parameters.Parameter)
 
 	// Extract header values - check both "headers.header" and direct "header"
 	var headerData interface{}
@@ -178,29 +189,51 @@ func parseYamlManually(content string) map[string]string {
 	} else if parsedYaml.Header != nil {
 		headerData = parsedYaml.Header
 	}
+	addEntries(result, headerData)
 
-	if headerData != nil {
-		switch headers := headerData.(type) {
-		case map[interface{}]interface{}: // Single header object
-			var singleHeader Parameter
-			if err := mapToStruct(headers, &singleHeader); err == nil {
-				if singleHeader.Name != "" && singleHeader.Value != "" {
-					result[singleHeader.Name] = singleHeader.Value
-				}
-			}
-		case []interface{}: // Array of header objects
-			for _, item := range headers {
-				var header Parameter
-				if err := mapToStruct(item, &header); err == nil {
-					if header.Name != "" && header.Value != "" {
-						result[header.Name] = header.Value
-					}
-				}
+	// Extract cookie values
+	addEntries(result, parsedYaml.Cookies.Cookie)
+
+	return result
+}
+
+// addEntries adds the name/value entries of a single parameter or header object, or a list of them.
+func addEntries(result map[string]string, entries interface{}) {
+	switch typed := entries.(type) {
+	case map[interface{}]interface{}: // Single object
+		if name, value := decodeParameterEntry(typed); name != "" && value != "" {
+			result[name] = value
+		}
+	case []interface{}: // Array of objects
+		for _, item := range typed {
+			if name, value := decodeParameterEntry(item); name != "" && value != "" {
+				result[name] = value
 			}
 		}
 	}
+}
 
-	return result
+// decodeParameterEntry reads the name and value of one entry. Scalar values keep their text as
+// written; array and object values are returned as JSON for decodeParameterValue.
+func decodeParameterEntry(item interface{}) (string, string) {
+	type Parameter struct {
+		Name  string `yaml:"name"`
+		Value string `yaml:"value"`
+	}
+	var param Parameter
+	if err := mapToStruct(item, &param); err == nil {
+		return param.Name, param.Value
+	}
+
+	type StructuredParameter struct {
+		Name  string      `yaml:"name"`
+		Value interface{} `yaml:"value"`
+	}
+	var structured StructuredParameter
+	if err := mapToStruct(item, &structured); err != nil {
+		return "", ""
+	}
+	return structured.Name, parameterValueString(structured.Value)
 }
 
 // Helper function to convert map to struct
@@ -226,6 +259,17 @@ func createValidationReport() ValidationReport {
 	}
 }
 
+// specVersion describes the document version, e.g. "Swagger 2.0" or "OpenAPI 3.0.1".
+func specVersion(swagger map[string]interface{}) string {
+	if version, ok := swagger["openapi"].(string); ok {
+		return "OpenAPI " + version
+	}
+	if version, ok := swagger["swagger"].(string); ok {
+		return "Swagger " + version
+	}
+	return ""
+}
+
 // Part 2: Validation Functions
 func validateParameterFile(fileName string, params []map[string]interface{}, paramType string, operationID string, fitnessPath string, validationReport *ValidationReport, swagger map[string]interface{}) error {
 	filePath := filepath.Join(fitnessPath, fileName)
@@ -260,8 +304,8 @@ func validateParameterFile(fileName string, params []map[string]interface{}, par
 
 	// Populate parameters in K6 script
 	fmt.Printf("Successfully parsed parameters from %s\n", fileName)
-	for name, value := range paramValues {
-		fmt.Printf("Parameter: %s = %s\n", name, value)
+	for _, name := range sortedStringKeys(paramValues) {
+		fmt.Printf("Parameter: %s = %s\n", name, paramValues[name])
 	}
 
 	return nil
@@ -282,23 +326,19 @@ func validateBodyFile(fileName string, operationID string, fitnessPath string, v
 		// Attempt to use example data from Swagger JSON - make this more generic
 		bodyContent := ""
 		if paths, ok := swagger["paths"].(map[string]interface{}); ok {
-			for _, pathValue := range paths {
-				if pathMap, ok := pathValue.(map[string]interface{}); ok {
-					for _, methodValue := range pathMap {
+			for _, pathKey := range sortedKeys(paths) {
+				if pathMap, ok := paths[pathKey].(map[string]interface{}); ok {
+					for _, methodKey := range sortedMethodKeys(pathMap) {
+						methodValue := pathMap[methodKey]
 						if operationMap, ok := methodValue.(map[string]interface{}); ok {
 							if opID, exists := operationMap["operationId"].(string); exists && opID == operationID {
 								if requestBody, ok := operationMap["requestBody"].(map[string]interface{}); ok {
 									if content, ok := requestBody["content"].(map[string]interface{}); ok {
 										if applicationJSON, ok := content["application/json"].(map[string]interface{}); ok {
 											if examples, ok := applicationJSON["examples"].(map[string]interface{}); ok {
-												// Try to get any example
-												for _, example := range examples {
-													if exampleMap, ok := example.(map[string]interface{}); ok {
-														if value, ok := exampleMap["value"]; ok {
-															bodyContent = fmt.Sprintf("%v", value)
-															break
-														}
-													}
+												// Use the first example by name
+												if value, ok := firstNamedExample(examples); ok {
+													bodyContent = fmt.Sprintf("%v", value)
 												}
 											}
 											// If no examples, try schema
@@ -350,23 +390,19 @@ func validateBodyFile(fileName string, operationID string, fitnessPath string, v
 		// Attempt to use example data from Swagger JSON - same logic as above
 		bodyContent := ""
 		if paths, ok := swagger["paths"].(map[string]interface{}); ok {
-			for _, pathValue := range paths {
-				if pathMap, ok := pathValue.(map[string]interface{}); ok {
-					for _, methodValue := range pathMap {
+			for _, pathKey := range sortedKeys(paths) {
+				if pathMap, ok := paths[pathKey].(map[string]interface{}); ok {
+					for _, methodKey := range sortedMethodKeys(pathMap) {
+						methodValue := pathMap[methodKey]
 						if operationMap, ok := methodValue.(map[string]interface{}); ok {
 							if opID, exists := operationMap["operationId"].(string); exists && opID == operationID {
 								if requestBody, ok := operationMap["requestBody"].(map[string]interface{}); ok {
 									if content, ok := requestBody["content"].(map[string]interface{}); ok {
 										if applicationJSON, ok := content["application/json"].(map[string]interface{}); ok {
 											if examples, ok := applicationJSON["examples"].(map[string]interface{}); ok {
-												// Try to get any example
-												for _, example := range examples {
-													if exampleMap, ok := example.(map[string]interface{}); ok {
-														if value, ok := exampleMap["value"]; ok {
-															bodyContent = fmt.Sprintf("%v", value)
-															break
-														}
-													}
+												// Use the first example by name
+												if value, ok := firstNamedExample(examples); ok {
+													bodyContent = fmt.Sprintf("%v", value)
 												}
 											}
 											// If no examples, try schema
@@ -581,154 +617,227 @@ func validateRequestBody(requestBody map[string]interface{}, operationID string,
 		return nil
 	}
 
-	if applicationJSON, ok := content["application/json"].(map[string]interface{}); ok {
-		fmt.Println("Request body: application/json")
+	mediaType, media := selectRequestMediaType(content)
+	if media == nil {
+		return nil
+	}
+	fmt.Println("Request body:", mediaType)
+	endpointDetails := validationReport.Endpoints[operationID]
+	endpointDetails.ContentType = mediaType
+	validationReport.Endpoints[operationID] = endpointDetails
 
-		schema, ok := applicationJSON["schema"].(map[string]interface{})
+	switch bodyEncoding(mediaType) {
+	case encodingJSON:
+		schema, ok := media["schema"].(map[string]interface{})
 		if !ok {
 			return nil
 		}
 
+		if err := validateBodyFilesAgainstSchema(schema, operationID, endpoint, fitnessPath, validationReport); err != nil {
+			return err
+		}
+
 		if schemaType, ok := schema["type"].(string); ok && schemaType == "array" {
 			items, ok := schema["items"].(map[string]interface{})
 			if !ok {
 				return nil
 			}
 
-			if ref, ok := items["$ref"].(string); ok {
-				schemaName := filepath.Base(ref)
+			if schemaName, ok := schemaRefName(items); ok {
 				schemaFileName := schemaName + ".json"
 				return validateBodyFile(schemaFileName, operationID, fitnessPath, validationReport, swagger)
 			}
-		} else if ref, ok := schema["$ref"].(string); ok {
-			schemaName := filepath.Base(ref)
+		} else if schemaName, ok := schemaRefName(schema); ok {
 			schemaFileName := schemaName + ".json"
 			return validateBodyFile(schemaFileName, operationID, fitnessPath, validationReport, swagger)
 		}
-	} else if multipartFormData, ok := content["multipart/form-data"].(map[string]interface{}); ok {
-		fmt.Println("Request body: multipart/form-data")
-
-		schema, ok := multipartFormData["schema"].(map[string]interface{})
-		if !ok {
-			return nil
-		}
-
-		required, ok := schema["required"].([]interface{})
-		if !ok {
-			return nil
-		}
-
-		for _, field := range required {
-			requiredField, ok := field.(string)
-			if !ok {
-				continue
-			}
-
-			fileName := requiredField + ".json"
-			return validateBodyFile(fileName, operationID, fitnessPath, validationReport, swagger)
+	case encodingForm:
+		if schema, ok := media["schema"].(map[string]interface{}); ok {
+			return validateBodyFilesAgainstSchema(schema, operationID, endpoint, fitnessPath, validationReport)
 		}
+	case encodingMultipart:
+		return validateMultipartBody(media, operationID, endpoint, validationReport.Endpoints[operationID].Method, fitnessPath, validationReport, swagger)
+	case encodingBinary:
+		validateBinaryBody(operationID, endpoint, fitnessPath, validationReport)
 	}
 
 	return nil
 }
 
-func getBodyData(operationID string, path string, method string, fitnessPath string, swagger map[string]interface{}) string {
-	// Try multiple file naming patterns
-	var bodyFilePath string
-	var content string
-	
+// Body sources recorded in EndpointDetails.BodySource
+const (
+	bodySourceFile        = "fitness file"
+	bodySourceExample     = "Swagger example"
+	bodySourceSynthesized = "synthesized from schema"
+)
+
+// findBodyFile returns the name and content of the first non-empty <operationId>[_<last path part>]_body
+// fitness file, or an empty name when there is none.
+func findBodyFile(operationID string, path string, fitnessPath string) (string, string) {
 	// Get the endpoint last part for file naming
 	endpointLastPart := filepath.Base(path)
-	
-	// Pattern 1: operationID_endpointLastPart_body.yaml
-	bodyFileName := fmt.Sprintf("%s_%s_body.yaml", operationID, endpointLastPart)
-	bodyFilePath = filepath.Join(fitnessPath, bodyFileName)
-	if _, err := os.Stat(bodyFilePath); err == nil {
-		content, err = readFileContent(bodyFilePath)
-		if err == nil && strings.TrimSpace(content) != "" {
-			return content
+
+	// Try multiple file naming patterns
+	bodyFilePatterns := []string{
+		fmt.Sprintf("%s_%s_body.yaml", operationID, endpointLastPart),
+		fmt.Sprintf("%s_%s_body.json", operationID, endpointLastPart),
+		fmt.Sprintf("%s_body.yaml", operationID),
+		fmt.Sprintf("%s_body.json", operationID),
+	}
+
+	for _, bodyFileName := range bodyFilePatterns {
+		bodyFilePath := filepath.Join(fitnessPath, bodyFileName)
+		if _, err := os.Stat(bodyFilePath); err == nil {
+			content, err := readFileContent(bodyFilePath)
+			if err == nil && strings.TrimSpace(content) != "" {
+				return bodyFileName, content
+			}
 		}
 	}
-	
-	// Pattern 2: operationID_endpointLastPart_body.json
-	bodyFileName = fmt.Sprintf("%s_%s_body.json", operationID, endpointLastPart)
-	bodyFilePath = filepath.Join(fitnessPath, bodyFileName)
-	if _, err := os.Stat(bodyFilePath); err == nil {
-		content, err = readFileContent(bodyFilePath)
-		if err == nil && strings.TrimSpace(content) != "" {
-			return content
+	return "", ""
+}
+
+// validateBodyFilesAgainstSchema checks the operation's body file and the <SchemaName>.json file,
+// when present, against the resolved request schema and records violations per file as endpoint issues.
+func validateBodyFilesAgainstSchema(schema map[string]interface{}, operationID string, endpoint string, fitnessPath string, validationReport *ValidationReport) error {
+	var bodyFileNames []string
+	if bodyFileName, _ := findBodyFile(operationID, endpoint, fitnessPath); bodyFileName != "" {
+		bodyFileNames = append(bodyFileNames, bodyFileName)
+	}
+
+	schemaNameSource := schema
+	if items, ok := schema["items"].(map[string]interface{}); ok {
+		schemaNameSource = items
+	}
+	if schemaName, ok := schemaRefName(schemaNameSource); ok {
+		schemaFileName := schemaName + ".json"
+		if len(bodyFileNames) == 0 || bodyFileNames[0] != schemaFileName {
+			bodyFileNames = append(bodyFileNames, schemaFileName)
 		}
 	}
-	
-	// Pattern 3: operationID_body.yaml
-	bodyFileName = fmt.Sprintf("%s_body.yaml", operationID)
-	bodyFilePath = filepath.Join(fitnessPath, bodyFileName)
-	if _, err := os.Stat(bodyFilePath); err == nil {
-		content, err = readFileContent(bodyFilePath)
-		if err == nil && strings.TrimSpace(content) != "" {
-			return content
+
+	for _, bodyFileName := range bodyFileNames {
+		bodyFilePath := filepath.Join(fitnessPath, bodyFileName)
+		if !fileExists(bodyFilePath) {
+			continue
 		}
+
+		content, err := readFileContent(bodyFilePath)
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(content) == "" {
+			continue
+		}
+
+		endpointDetails := validationReport.Endpoints[operationID]
+		body, err := parseBodyFileContent(content)
+		if err != nil {
+			fmt.Printf("❌ Unable to parse body file %s: %v\n", bodyFileName, err)
+			endpointDetails.Issues = append(endpointDetails.Issues, Issue{
+				File:  bodyFileName,
+				Issue: fmt.Sprintf("Cannot parse body file: %v", err),
+			})
+			validationReport.Endpoints[operationID] = endpointDetails
+			continue
+		}
+
+		result := validateBodyAgainstSchema(body, schema, schemaRequest)
+		if result.valid() {
+			fmt.Printf("✅ Body file %s matches the request schema\n", bodyFileName)
+			continue
+		}
+
+		fmt.Printf("❌ Body file %s does not match the request schema for operation: %s\n", bodyFileName, operationID)
+		endpointDetails.Issues = append(endpointDetails.Issues, Issue{
+			File:                      bodyFileName,
+			MissingRequiredProperties: result.MissingRequiredProperties,
+			TypeValidationErrors:      result.TypeValidationErrors,
+		})
+		validationReport.Endpoints[operationID] = endpointDetails
 	}
-	
-	// Pattern 4: operationID_body.json
-	bodyFileName = fmt.Sprintf("%s_body.json", operationID)
-	bodyFilePath = filepath.Join(fitnessPath, bodyFileName)
-	if _, err := os.Stat(bodyFilePath); err == nil {
-		content, err = readFileContent(bodyFilePath)
-		if err == nil && strings.TrimSpace(content) != "" {
-			return content
+
+	return nil
+}
+
+func getBodyData(operationID string, path string, method string, fitnessPath string, swagger map[string]interface{}) string {
+	content, _, _ := lookupBodyData(operationID, path, method, fitnessPath, swagger)
+	return content
+}
+
+// lookupBodyData returns the request body for an operation together with where it came from
+// (a fitness file, a Swagger example or a sample synthesized from the request schema) and,
+// for fitness files, the file name. Structured bodies are returned as JSON text, XML and text
+// bodies as they are sent, and binary bodies only by file name.
+func lookupBodyData(operationID string, path string, method string, fitnessPath string, swagger map[string]interface{}) (string, string, string) {
+	mediaType, media := requestBodyMedia(swagger, path, method)
+	switch bodyEncoding(mediaType) {
+	case encodingXML, encodingText:
+		return lookupRawBodyData(operationID, path, fitnessPath, bodyEncoding(mediaType), media)
+	case encodingBinary:
+		if bodyFileName := findRawBodyFile(operationID, path, fitnessPath, encodingBinary); bodyFileName != "" {
+			return "", bodySourceFile, bodyFileName
 		}
+		return "", "", ""
 	}
 
-	fmt.Printf("Warning: Body file not found for operationID: %s (tried multiple patterns)\n", operationID)
+	if bodyFileName, content := findBodyFile(operationID, path, fitnessPath); bodyFileName != "" {
+		return content, bodySourceFile, bodyFileName
+	}
 
-	// Fallback to example in Swagger JSON - use generic approach
-	if paths, ok := swagger["paths"].(map[string]interface{}); ok {
-		if pathMap, ok := paths[path].(map[string]interface{}); ok {
-			if operation, ok := pathMap[method].(map[string]interface{}); ok {
-				if requestBody, ok := operation["requestBody"].(map[string]interface{}); ok {
-					if content, ok := requestBody["content"].(map[string]interface{}); ok {
-						if applicationJSON, ok := content["application/json"].(map[string]interface{}); ok {
-							if examples, ok := applicationJSON["examples"].(map[string]interface{}); ok {
-								// Try to get any example
-								for _, example := range examples {
-									if exampleMap, ok := example.(map[string]interface{}); ok {
-										if value, ok := exampleMap["value"]; ok {
-											exampleBody, err := json.Marshal(value)
-											if err != nil {
-												fmt.Printf("Error marshaling example body: %v\n", err)
-												return "null"
-											}
-											return string(exampleBody)
-										}
-									}
-								}
-							}
-							// If no examples, try schema
-							if schema, ok := applicationJSON["schema"].(map[string]interface{}); ok {
-								if example, ok := schema["example"]; ok {
-									exampleBody, err := json.Marshal(example)
-									if err != nil {
-										fmt.Printf("Error marshaling schema example: %v\n", err)
-										return "null"
-									}
-									return string(exampleBody)
-								}
-							}
-						}
-					}
+	// Fallback to the examples of the chosen media type
+	if media != nil {
+		if examples, ok := media["examples"].(map[string]interface{}); ok {
+			// Use the first example by name
+			if value, ok := firstNamedExample(examples); ok {
+				exampleBody, err := json.Marshal(value)
+				if err != nil {
+					fmt.Printf("Error marshaling example body: %v\n", err)
+					return "null", "", ""
 				}
+				return string(exampleBody), bodySourceExample, ""
+			}
+		}
+		if example, ok := media["example"]; ok {
+			exampleBody, err := json.Marshal(example)
+			if err == nil {
+				return string(exampleBody), bodySourceExample, ""
+			}
+		}
+		// If no examples, try schema
+		schema, hasSchema := media["schema"].(map[string]interface{})
+		if example, ok := schema["example"]; ok {
+			exampleBody, err := json.Marshal(example)
+			if err != nil {
+				fmt.Printf("Error marshaling schema example: %v\n", err)
+				return "null", "", ""
+			}
+			return string(exampleBody), bodySourceExample, ""
+		}
+
+		// Only a body without file or example is worth a warning
+		fmt.Printf("Warning: Body file not found for operationID: %s (tried multiple patterns)\n", operationID)
+		if hasSchema {
+			// No hand-written data at all, so build a payload that satisfies the schema
+			sampleBody, err := json.Marshal(synthesizeSchemaSample(schema))
+			if err != nil {
+				fmt.Printf("Error marshaling synthesized body: %v\n", err)
+				return "null", "", ""
 			}
+			fmt.Printf("Synthesized request body from schema for operationID: %s\n", operationID)
+			return string(sampleBody), bodySourceSynthesized, ""
 		}
 	}
 
 	// Default to null if no file or example is found
-	return "null"
+	return "null", "", ""
 }
 
 func validateParameters(parameters []interface{}, operationID string, endpoint string, fitnessPath string, validationReport *ValidationReport, swagger map[string]interface{}) error {
 	queryParams := []map[string]interface{}{}
 	headerParams := []map[string]interface{}{}
+	cookieParams := []map[string]interface{}{}
+	pathParams := []map[string]interface{}{}
 
 	for _, param := range parameters {
 		if p, ok := param.(map[string]interface{}); ok {
@@ -738,6 +847,10 @@ func validateParameters(parameters []interface{}, operationID string, endpoint s
 					queryParams = append(queryParams, p)
 				case "header":
 					headerParams = append(headerParams, p)
+				case "cookie":
+					cookieParams = append(cookieParams, p)
+				case "path":
+					pathParams = append(pathParams, p)
 				}
 			}
 		}
@@ -745,6 +858,18 @@ func validateParameters(parameters []interface{}, operationID string, endpoint s
 
 	endpointLastPart := filepath.Base(endpoint)
 
+	// Values read from the operation's dataset or flow step need no fitness file or Swagger example
+	supplied := suppliedParameters(validationReport, operationID, EndpointDetails{
+		Path:         endpoint,
+		QueryParams:  parameterNames(queryParams),
+		HeaderParams: parameterNames(headerParams),
+		CookieParams: parameterNames(cookieParams),
+	}, fitnessPath)
+
+	if err := validatePathParameters(pathParams, operationID, endpoint, fitnessPath, supplied.Path, validationReport); err != nil {
+		return err
+	}
+
 	if len(queryParams) > 0 {
 		queryParamNames := make([]string, 0, len(queryParams))
 		for _, p := range queryParams {
@@ -759,9 +884,12 @@ func validateParameters(parameters []interface{}, operationID string, endpoint s
 
 		queryParamFileName := fmt.Sprintf("%s_%s_path.yaml", operationID, endpointLastPart)
 		queryFilePath := filepath.Join(fitnessPath, queryParamFileName)
+		queryParams = unsuppliedParameters(queryParams, supplied.Query)
 
 		// Check if file exists - if not, use Swagger examples as fallback
-		if !fileExists(queryFilePath) {
+		if len(queryParams) == 0 {
+			fmt.Printf("Using dataset or flow values for query parameters in operation: %s\n", operationID)
+		} else if !fileExists(queryFilePath) {
 			fmt.Printf("⚠️  File not found: %s, using Swagger examples as fallback\n", queryParamFileName)
 			// Use Swagger examples directly instead of creating file
 			if err := validateParameterFileWithSwaggerFallback(queryParamFileName, queryParams, "query", operationID, fitnessPath, validationReport, swagger); err != nil {
@@ -804,7 +932,10 @@ func validateParameters(parameters []interface{}, operationID string, endpoint s
 			}
 		}
 
-		if foundHeaderFile != "" {
+		headerParams = unsuppliedParameters(headerParams, supplied.Headers)
+		if len(headerParams) == 0 {
+			fmt.Printf("Using dataset or flow values for header parameters in operation: %s\n", operationID)
+		} else if foundHeaderFile != "" {
 			if err := validateHeaders(foundHeaderFile, headerParams, operationID, fitnessPath, validationReport, swagger); err != nil {
 				return err
 			}
@@ -817,9 +948,157 @@ func validateParameters(parameters []interface{}, operationID string, endpoint s
 		}
 	}
 
+	if len(cookieParams) > 0 {
+		validateCookieParameters(cookieParams, operationID, endpoint, fitnessPath, supplied.Cookies, validationReport)
+	}
+
+	return nil
+}
+
+// validatePathParameters resolves every {placeholder} in the endpoint path, first from the
+// environment's <operationId>_pathvars.yaml fitness file and then from the Swagger examples.
+// Placeholders that cannot be resolved are recorded as endpoint issues, which blocks script generation.
+func validatePathParameters(pathParams []map[string]interface{}, operationID string, endpoint string, fitnessPath string, supplied map[string]string, validationReport *ValidationReport) error {
+	placeholders := pathPlaceholderPattern.FindAllStringSubmatch(endpoint, -1)
+	if len(placeholders) == 0 {
+		return nil
+	}
+
+	endpointLastPart := filepath.Base(endpoint)
+	pathVarsPatterns := []string{
+		fmt.Sprintf("%s_pathvars.yaml", operationID),
+		fmt.Sprintf("%s_%s_pathvars.yaml", operationID, endpointLastPart),
+	}
+
+	pathVarsFileName := pathVarsPatterns[0]
+	fileValues := make(map[string]string)
+	for _, pattern := range pathVarsPatterns {
+		pathVarsFilePath := filepath.Join(fitnessPath, pattern)
+		if !fileExists(pathVarsFilePath) {
+			continue
+		}
+
+		content, err := readFileContent(pathVarsFilePath)
+		if err != nil {
+			return err
+		}
+
+		pathVarsFileName = pattern
+		fileValues = parseYamlManually(content)
+		if len(fileValues) == 0 {
+			fmt.Printf("Warning: Unable to parse content of %s or no path variables found\n", pattern)
+			validationReport.EmptyValues = append(validationReport.EmptyValues, EmptyValue{
+				File:        pattern,
+				Type:        "path",
+				OperationID: operationID,
+				Issue:       "Cannot parse content or no path variables found",
+			})
+		}
+		break
+	}
+
+	resolved := make(map[string]string)
+	var unresolved []string
+	for _, match := range placeholders {
+		name := match[1]
+		if _, done := resolved[name]; done {
+			continue
+		}
+
+		if value, ok := fileValues[name]; ok && strings.TrimSpace(value) != "" {
+			resolved[name] = value
+			continue
+		}
+
+		if value, ok := getPathParamExample(name, pathParams); ok {
+			fmt.Printf("Using Swagger example for path parameter %s in operation: %s\n", name, operationID)
+			resolved[name] = value
+			continue
+		}
+
+		if _, ok := supplied[name]; ok {
+			fmt.Printf("Using dataset or flow values for path parameter %s in operation: %s\n", name, operationID)
+			resolved[name] = ""
+			continue
+		}
+
+		unresolved = append(unresolved, name)
+	}
+
+	endpointDetails := validationReport.Endpoints[operationID]
+	endpointDetails.PathParams = resolved
+	if len(unresolved) > 0 {
+		fmt.Printf("❌ Unresolved path placeholders in %s for operation %s: %s\n", endpoint, operationID, strings.Join(unresolved, ", "))
+		endpointDetails.Issues = append(endpointDetails.Issues, Issue{
+			File:              pathVarsFileName,
+			MissingParameters: unresolved,
+			Issue:             fmt.Sprintf("Unresolved path placeholders in %s", endpoint),
+		})
+	}
+	validationReport.Endpoints[operationID] = endpointDetails
+
 	return nil
 }
 
+// getPathParamExample looks up a value for a path parameter from its example, schema.example or examples.
+func getPathParamExample(name string, pathParams []map[string]interface{}) (string, bool) {
+	for _, param := range pathParams {
+		if paramName, ok := param["name"].(string); !ok || paramName != name {
+			continue
+		}
+
+		if example, ok := param["example"]; ok && example != nil {
+			return parameterValueString(example), true
+		}
+
+		if schema, ok := param["schema"].(map[string]interface{}); ok {
+			if example, ok := schema["example"]; ok && example != nil {
+				return parameterValueString(example), true
+			}
+		}
+
+		if examples, ok := param["examples"].(map[string]interface{}); ok {
+			// Pick the first example by name so repeated runs choose the same value
+			if value, ok := firstNamedExample(examples); ok && value != nil {
+				return parameterValueString(value), true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// mergeParameters combines path-item level parameters with operation level ones.
+// Operation parameters override path-item parameters with the same name and location.
+func mergeParameters(pathItemParameters []interface{}, operationParameters []interface{}) []interface{} {
+	if len(pathItemParameters) == 0 {
+		return operationParameters
+	}
+
+	parameterKey := func(param interface{}) string {
+		p, ok := param.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		name, _ := p["name"].(string)
+		in, _ := p["in"].(string)
+		return in + ":" + name
+	}
+
+	overridden := make(map[string]bool)
+	for _, param := range operationParameters {
+		overridden[parameterKey(param)] = true
+	}
+
+	merged := make([]interface{}, 0, len(pathItemParameters)+len(operationParameters))
+	for _, param := range pathItemParameters {
+		if !overridden[parameterKey(param)] {
+			merged = append(merged, param)
+		}
+	}
+	return append(merged, operationParameters...)
+}
+
 func validateParameterFileWithSwaggerFallback(fileName string, expectedParams []map[string]interface{}, paramType string, operationID string, fitnessPath string, validationReport *ValidationReport, swagger map[string]interface{}) error {
 	// Extract examples from the CURRENT operation's parameters only
 	swaggerParams := make(map[string]string)
@@ -1141,43 +1420,59 @@ func generateHeaderFile(filePath string, headerParams []map[string]interface{},
 
 // Part 3: validateSwagger Function
 func validateSwagger(swagger map[string]interface{}, fitnessPath string, validationReport *ValidationReport) error {
-	servers, ok := swagger["servers"].([]interface{})
-	if !ok || len(servers) == 0 {
-		validationReport.MissingServerURL = true
-		fmt.Println("Warning: Missing server URL in Swagger file")
+	environment := filepath.Base(fitnessPath)
+	if swaggerOptions.BaseURLOverride != "" {
+		fmt.Println("Using base URL override:", swaggerOptions.BaseURLOverride)
+		validationReport.ServerURL = strings.TrimRight(swaggerOptions.BaseURLOverride, "/")
 	} else {
-		serverMap, ok := servers[0].(map[string]interface{})
-		if !ok {
+		servers, _ := swagger["servers"].([]interface{})
+		serverURL, err := selectServerURL(servers, environment)
+		if err != nil {
 			validationReport.MissingServerURL = true
-			fmt.Println("Warning: Missing server URL in Swagger file")
+			validationReport.ServerIssue = err.Error()
+			fmt.Println("Warning: Missing server URL in Swagger file:", err)
 		} else {
-			if _, ok := serverMap["url"].(string); !ok {
-				validationReport.MissingServerURL = true
-				fmt.Println("Warning: Missing server URL in Swagger file")
-			}
+			validationReport.ServerURL = serverURL
+			fmt.Println("Using server URL:", serverURL)
 		}
 	}
 
+	// Operations without an operationId get one before anything looks operations up by id
+	synthesized := synthesizeOperationIDs(swagger)
+
+	validateLoadProfileFile(fitnessPath, validationReport)
+	loadDatasetFile(fitnessPath, validationReport)
+	loadFlowFile(fitnessPath, validationReport)
+	inferLinkedFlow(swagger, environment, validationReport)
+
 	paths, ok := swagger["paths"].(map[string]interface{})
 	if !ok {
 		return nil
 	}
 
-	for endpoint, pathItem := range paths {
-		pathItemMap, ok := pathItem.(map[string]interface{})
+	for _, endpoint := range sortedKeys(paths) {
+		pathItemMap, ok := paths[endpoint].(map[string]interface{})
 		if !ok {
 			continue
 		}
 
-		for method, operation := range pathItemMap {
-			operationMap, ok := operation.(map[string]interface{})
+		for _, method := range sortedMethodKeys(pathItemMap) {
+			operationMap, ok := pathItemMap[method].(map[string]interface{})
 			if !ok {
 				continue
 			}
 
-			operationID, ok := operationMap["operationId"].(string)
-			if !ok {
-				fmt.Printf("Warning: Missing operationId for %s %s\n", strings.ToUpper(method), endpoint)
+			// Every operation has an operationId once they are synthesized
+			operationID, _ := operationMap["operationId"].(string)
+
+			if reason := operationSkipReason(environment, endpoint, method, pathItemMap, operationMap); reason != "" {
+				fmt.Printf("Skipping operation %s (%s %s): %s\n", operationID, strings.ToUpper(method), endpoint, reason)
+				validationReport.SkippedOperations = append(validationReport.SkippedOperations, SkippedOperation{
+					OperationID: operationID,
+					Method:      method,
+					Path:        endpoint,
+					Reason:      reason,
+				})
 				continue
 			}
 
@@ -1187,83 +1482,111 @@ func validateSwagger(swagger map[string]interface{}, fitnessPath string, validat
 				QueryParams:  []string{},
 				HeaderParams: []string{},
 				BodyContent:  nil,
+				ExpectedStatus: expectedStatusCodes(operationMap),
+				Tags:         operationTags(operationMap),
+				Synthesized:  synthesized[operationID],
 				Issues:       []Issue{},
 			}
 
-			if parameters, ok := operationMap["parameters"].([]interface{}); ok {
+			// Path- and operation-level servers replace the document servers for this operation
+			if swaggerOptions.BaseURLOverride == "" {
+				serverURL, overridden, err := operationServerURL(pathItemMap, operationMap, environment)
+				endpointDetails := validationReport.Endpoints[operationID]
+				if err != nil {
+					endpointDetails.Issues = append(endpointDetails.Issues, Issue{
+						File:  "servers",
+						Issue: fmt.Sprintf("Cannot select a server for %s %s: %v", strings.ToUpper(method), endpoint, err),
+					})
+				} else if overridden {
+					endpointDetails.ServerURL = serverURL
+				}
+				validationReport.Endpoints[operationID] = endpointDetails
+			}
+
+			pathItemParameters, _ := pathItemMap["parameters"].([]interface{})
+			operationParameters, _ := operationMap["parameters"].([]interface{})
+			parameters := mergeParameters(pathItemParameters, operationParameters)
+			if len(parameters) > 0 || pathPlaceholderPattern.MatchString(endpoint) {
 				if err := validateParameters(parameters, operationID, endpoint, fitnessPath, validationReport, swagger); err != nil {
 					return err
 				}
 			}
 
+			if requirements := operationSecurity(swagger, operationMap); len(requirements) > 0 {
+				validateOperationSecurity(requirements, operationID, fitnessPath, swagger, validationReport)
+			}
+
 			if requestBody, ok := operationMap["requestBody"].(map[string]interface{}); ok {
 				if err := validateRequestBody(requestBody, operationID, endpoint, swagger, fitnessPath, validationReport); err != nil {
 					return err
 				}
+
+				// Record where the generated script will take the body from
+				_, bodySource, bodyFile := lookupBodyData(operationID, endpoint, method, fitnessPath, swagger)
+				endpointDetails := validationReport.Endpoints[operationID]
+				endpointDetails.BodySource = bodySource
+				if bodyFile != "" {
+					endpointDetails.BodyFile = bodyFile
+				}
+				validationReport.Endpoints[operationID] = endpointDetails
 			}
 		}
 	}
 
+	validateOperationIdentifiers(validationReport)
+
+	if validationReport.LoadProfile != nil {
+		validationReport.ProfileIssues = append(validationReport.ProfileIssues, validateTrafficWeights(validationReport.LoadProfile.Weights, validationReport.Endpoints, validationReport.SkippedOperations)...)
+	}
+	validateSLOFile(fitnessPath, validationReport)
+	validateDatasetFile(fitnessPath, validationReport)
+	validateFlowFile(validationReport)
+
 	return nil
 }
 
-// Part 4: replacePathPlaceholders Function
-func replacePathPlaceholders(path string, parameters []interface{}) string {
-	for _, param := range parameters {
-		if paramMap, ok := param.(map[string]interface{}); ok {
-			// Check if the parameter is in the path
-			if paramMap["in"] == "path" {
-				fmt.Println("Parameter is in path:", paramMap)
-
-				// Extract the name and example value
-				paramName, nameOk := paramMap["name"].(string)
-				exampleValue, exampleOk := paramMap["example"].(string)
-
-				// Replace the placeholder with the example value
-				if nameOk && exampleOk {
-					placeholder := fmt.Sprintf("{%s}", paramName)
-					if strings.Contains(path, placeholder) {
-						fmt.Printf("Replacing placeholder %s with %s\n", placeholder, exampleValue)
-						path = strings.Replace(path, placeholder, exampleValue, -1)
-					} else {
-						fmt.Printf("Placeholder %s not found in path %s\n", placeholder, path)
-					}
-				} else {
-					fmt.Printf("Failed to extract name or example value for parameter: %+v\n", paramMap)
-				}
-			} else {
-				fmt.Printf("Parameter is not in path: %+v\n", paramMap)
-			}
+// operationTags returns the tags of an operation in spec order.
+func operationTags(operation map[string]interface{}) []string {
+	var tags []string
+	rawTags, _ := operation["tags"].([]interface{})
+	for _, tag := range rawTags {
+		if name, ok := tag.(string); ok {
+			tags = append(tags, name)
 		}
 	}
+	return tags
+}
 
-	if !strings.Contains(path, "{") {
-		fmt.Println("Path does not contain placeholders:", path)
-	}
-
-	return path
+// Part 4: replacePathPlaceholders Function
+// replacePathPlaceholders substitutes each {name} segment with its value, already serialized
+// and percent-encoded by encodedPathParams.
+func replacePathPlaceholders(path string, encodedParams map[string]string) string {
+	return pathPlaceholderPattern.ReplaceAllStringFunc(path, func(placeholder string) string {
+		name := strings.Trim(placeholder, "{}")
+		if value, ok := encodedParams[name]; ok {
+			return value
+		}
+		fmt.Printf("Placeholder %s not resolved in path %s\n", placeholder, path)
+		return placeholder
+	})
 }
 
 // Part 4: generateK6Script Function
 
-func generateK6Script(swagger map[string]interface{}, validationReport ValidationReport, environment string) (string, error) {
+// When env refs are enabled it also returns the variables the script reads from __ENV.
+func generateK6Script(swagger map[string]interface{}, validationReport ValidationReport, environment string) (string, []envrefs.Reference, error) {
 	if validationReport.MissingServerURL {
-		return "", fmt.Errorf("cannot generate k6 script: Missing server URL in Swagger file")
+		return "", nil, fmt.Errorf("cannot generate k6 script: Missing server URL in Swagger file")
 	}
 
-	servers, ok := swagger["servers"].([]interface{})
-	if !ok || len(servers) == 0 {
-		return "", fmt.Errorf("cannot generate k6 script: Missing server URL in Swagger file")
+	baseURL := validationReport.ServerURL
+	if baseURL == "" {
+		return "", nil, fmt.Errorf("cannot generate k6 script: Missing server URL in Swagger file")
 	}
 
-	serverMap, ok := servers[0].(map[string]interface{})
-	if !ok {
-		return "", fmt.Errorf("cannot generate k6 script: Missing server URL in Swagger file")
-	}
-
-	baseURL, ok := serverMap["url"].(string)
-	if !ok {
-		return "", fmt.Errorf("cannot generate k6 script: Missing server URL in Swagger file")
+	var envRegistry *envrefs.Registry
+	if envRefsEnabled() {
+		envRegistry = envrefs.NewRegistry()
 	}
 
 	k6Code := `import http from 'k6/http';
@@ -1271,77 +1594,316 @@ import { check, sleep } from 'k6';
 import { htmlReport } from './bundle.js';
 import { Trend } from 'k6/metrics';
 
-export const options = {
-	insecureSkipTLSVerify: true,
-	stages: [
-		{ duration: '1m', target: 1 },
-	],
-};
+`
+	profile := defaultLoadProfile()
+	if validationReport.LoadProfile != nil {
+		profile = *validationReport.LoadProfile
+	}
+	operationIDs := sortedEndpointIDs(validationReport.Endpoints)
 
+	// Weighted operations run in their own scenarios, each with its share of the load profile
+	scenarios := trafficScenarios(profile.Weights, operationIDs, validationReport.Endpoints)
+	totalWeight := 0
+	for _, scenario := range scenarios {
+		totalWeight += scenario.Weight
+	}
+	scenarioNames := make([]string, 0, len(scenarios))
+	scenarioOptions := make(map[string]string, len(scenarios))
+	for _, scenario := range scenarios {
+		scenarioNames = append(scenarioNames, scenario.Name)
+		scenarioOptions[scenario.Name] = scenarioCode(scaleLoadProfile(profile, float64(scenario.Weight)/float64(totalWeight)), scenario.Exec)
+	}
+	var sloExpressions map[string][]k6Threshold
+	if validationReport.SLO != nil {
+		sloExpressions = sloThresholdExpressions(*validationReport.SLO)
+	}
+	k6Code += optionsCode(profile, scenarioNames, scenarioOptions, sloExpressions)
+	k6Code += `
 // Add Trend metrics
 `
 
-	for operationID := range validationReport.Endpoints {
-		k6Code += fmt.Sprintf("const %sTrend = new Trend('%s');\n", operationID, operationID)
+	for _, operationID := range operationIDs {
+		identifier := operationIdentifier(operationID)
+		k6Code += fmt.Sprintf("const %sTrend = new Trend(%s);\n", identifier, jsemit.String(identifier))
 	}
+	envCheckOffset := len(k6Code)
 
-	k6Code += `
-export default function () {
-`
+	// Security schemes are resolved once in setup() and handed to every iteration as data.auth
+	securitySchemes := usedSecuritySchemes(validationReport.Endpoints)
+	if len(securitySchemes) > 0 {
+		setupCode, err := securitySetupCode(securitySchemes, swagger, envFitnessPath(environment), baseURL, envRegistry)
+		if err != nil {
+			return "", nil, fmt.Errorf("cannot generate k6 script: %v", err)
+		}
+		k6Code += setupCode
+	}
 
-	for operationID, endpointDetails := range validationReport.Endpoints {
+	operationCode := make(map[string]string, len(operationIDs))
+	initCode := ""
+	for _, operationID := range operationIDs {
+		code := ""
+		endpointDetails := validationReport.Endpoints[operationID]
 		path := endpointDetails.Path
 		method := endpointDetails.Method
+		identifier := operationIdentifier(operationID)
 
 		// Construct base URL
-		urlVariableName := fmt.Sprintf("%s_baseUrl", operationID)
-		queryParamsVariableName := fmt.Sprintf("%s_queryParams", operationID)
-		fullUrlVariableName := fmt.Sprintf("%s_url", operationID)
-		bodyVariableName := fmt.Sprintf("%s_body", operationID)
-		headersVariableName := fmt.Sprintf("%s_headers", operationID)
-		resVariableName := fmt.Sprintf("%s_res", operationID)
+		urlVariableName := fmt.Sprintf("%s_baseUrl", identifier)
+		queryParamsVariableName := fmt.Sprintf("%s_queryParams", identifier)
+		fullUrlVariableName := fmt.Sprintf("%s_url", identifier)
+		bodyVariableName := fmt.Sprintf("%s_body", identifier)
+		headersVariableName := fmt.Sprintf("%s_headers", identifier)
+		resVariableName := fmt.Sprintf("%s_res", identifier)
+
+		// Parameters are serialized for their OpenAPI style and explode settings
+		parameterSpecs := operationParameterSpecs(swagger, path, method)
+		pathValues := encodedPathParams(endpointDetails.PathParams, parameterSpecs)
+		resolvedPath := replacePathPlaceholders(path, pathValues)
+		if pathPlaceholderPattern.MatchString(resolvedPath) {
+			return "", nil, fmt.Errorf("cannot generate k6 script: unresolved path placeholders in %s for operation %s", path, operationID)
+		}
+
+		code += fmt.Sprintf("\n\t// %s: %s %s\n", jsemit.Comment(operationID), strings.ToUpper(method), jsemit.Comment(path))
+
+		// Operations with a dataset read their values from the row picked for this iteration, and flow
+		// steps from the values extracted by earlier steps
+		var valueRefs RequestMappings
+		if dataset, hasDataset := operationDataset(validationReport.Datasets, operationID, endpointDetails, envFitnessPath(environment)); hasDataset {
+			code += datasetRowCode(operationID, dataset)
+			valueRefs = dataset.expressions(func(column string) string { return datasetValue(operationID, column) })
+		}
+		flowStep, inFlow := validationReport.Flow.step(operationID)
+		if inFlow {
+			valueRefs = valueRefs.merge(flowStep.expressions(flowValue))
+		}
+
+		serverURL := baseURL
+		if endpointDetails.ServerURL != "" {
+			serverURL = endpointDetails.ServerURL
+		}
+		var sensitiveNames map[string]bool
+		if envRegistry != nil {
+			sensitiveNames = findSensitiveFitnessNames(operationID, envFitnessPath(environment))
+
+			serverEnvName := documentBaseURLEnvName
+			if endpointDetails.ServerURL != "" {
+				serverEnvName = operationID + "_" + documentBaseURLEnvName
+			}
+			pathRefs := make(map[string]string)
+			for _, name := range sortedStringKeys(endpointDetails.PathParams) {
+				if isSensitiveName(name, sensitiveNames) {
+					pathRefs[name] = envRegistry.Ref(name, endpointDetails.PathParams[name])
+				}
+			}
+			for name, expression := range valueRefs.Path {
+				pathRefs[name] = expression
+			}
+			code += fmt.Sprintf("\tconst %s = %s + %s;\n", urlVariableName, envRegistry.Ref(serverEnvName, serverURL), pathExpression(path, pathValues, pathRefs))
+		} else if len(valueRefs.Path) > 0 {
+			code += fmt.Sprintf("\tconst %s = %s + %s;\n", urlVariableName, jsemit.String(serverURL), pathExpression(path, pathValues, valueRefs.Path))
+		} else {
+			code += fmt.Sprintf("\tconst %s = %s;\n", urlVariableName, jsemit.String(serverURL+resolvedPath))
+		}
 
-		k6Code += fmt.Sprintf("\n\t// %s: %s %s\n", operationID, strings.ToUpper(method), path)
-		k6Code += fmt.Sprintf("\tconst %s = '%s%s';\n", urlVariableName, baseURL, path)
+		authHeaders, authQuery, authCookies := securityRequestParts(endpointDetails.Security, swagger)
 
-		queryParams := getQueryParams(operationID, endpointDetails.QueryParams, filepath.Join(constants.PathConstantsInstance.VPEConfigPath, "fitness", environment), swagger)
-		queryParamsString := generateQueryParamsString(queryParams)
-		k6Code += fmt.Sprintf("\tconst %s = `%s`;\n", queryParamsVariableName, queryParamsString)
+		queryValues := getQueryParams(operationID, endpointDetails.QueryParams, envFitnessPath(environment), swagger)
+		queryNames := append([]string{}, endpointDetails.QueryParams...)
+		for name := range authQuery {
+			queryNames = append(queryNames, name)
+		}
+		sort.Strings(queryNames)
+		var queryPairs []string
+		for _, name := range queryNames {
+			if expression, isAuth := authQuery[name]; isAuth {
+				queryPairs = append(queryPairs, encodeQueryComponent(name, false)+"="+expression)
+				continue
+			}
+			if expression, ok := valueRefs.Query[name]; ok {
+				queryPairs = append(queryPairs, encodeQueryComponent(name, false)+"=${encodeURIComponent("+expression+")}")
+				continue
+			}
+			spec := findParameterSpec(parameterSpecs, name, "query")
+			value, ok := queryValues[name]
+			if !ok {
+				// Optional parameters without a value are left out of the request
+				if !parameterRequired(spec) {
+					continue
+				}
+				value = exampleParameterValue
+			}
+			if envRegistry != nil && isSensitiveName(name, sensitiveNames) {
+				queryPairs = append(queryPairs, encodeQueryComponent(name, false)+"=${encodeURIComponent("+envRegistry.Ref(name, value)+")}")
+				continue
+			}
+			queryPairs = append(queryPairs, serializeQueryParameter(name, decodeParameterValue(value, spec), spec)...)
+		}
+		queryParamsString := generateQueryParamsString(queryPairs)
+		code += fmt.Sprintf("\tconst %s = `%s`;\n", queryParamsVariableName, queryParamsString)
 
 		// Combine base URL and query parameters
-		k6Code += fmt.Sprintf("\tconst %s = %s + %s;\n", fullUrlVariableName, urlVariableName, queryParamsVariableName)
+		code += fmt.Sprintf("\tconst %s = %s + %s;\n", fullUrlVariableName, urlVariableName, queryParamsVariableName)
 
-		// Get body data using the helper function
-		bodyContent := getBodyData(operationID, path, method, filepath.Join(constants.PathConstantsInstance.VPEConfigPath, "fitness", environment), swagger)
-		k6Code += fmt.Sprintf("\tconst %s = JSON.stringify(%s);\n", bodyVariableName, bodyContent)
+		// Encode the body for the media type chosen from the spec; uploaded files are opened in the init stage
+		bodyExpression, bodyInitCode, err := requestBodyExpression(operationID, endpointDetails, envFitnessPath(environment), swagger)
+		if err != nil {
+			return "", nil, fmt.Errorf("cannot generate k6 script: %v", err)
+		}
+		initCode += bodyInitCode
+		if len(valueRefs.Body) > 0 {
+			bodyExpression, err = bodyFieldsExpression(operationID, getBodyData(operationID, path, method, envFitnessPath(environment), swagger), valueRefs.Body)
+			if err != nil {
+				return "", nil, fmt.Errorf("cannot generate k6 script: %v", err)
+			}
+		}
+		code += fmt.Sprintf("\tconst %s = %s;\n", bodyVariableName, bodyExpression)
 
 		// Handle headers
-		headersContent := getHeadersContent(operationID, endpointDetails.HeaderParams, filepath.Join(constants.PathConstantsInstance.VPEConfigPath, "fitness", environment), swagger)
-		k6Code += fmt.Sprintf("\tconst %s = %s;\n", headersVariableName, formatAsJSON(headersContent))
+		headersContent := getHeadersContent(operationID, endpointDetails.HeaderParams, envFitnessPath(environment), swagger)
+		for _, name := range endpointDetails.HeaderParams {
+			spec := findParameterSpec(parameterSpecs, name, "header")
+			value, ok := headersContent[name]
+			if !ok {
+				if !parameterRequired(spec) {
+					continue
+				}
+				value = exampleParameterValue
+			}
+			headersContent[name] = serializeHeaderValue(decodeParameterValue(value, spec), spec)
+		}
+		setContentTypeHeader(headersContent, endpointDetails.ContentType)
+		headersLiteral := formatAsJSON(headersContent)
+		headerRefs := make(map[string]string)
+		if envRegistry != nil {
+			for _, name := range sortedStringKeys(headersContent) {
+				if isSensitiveName(name, sensitiveNames) {
+					headerRefs[name] = envRegistry.Ref(name, headersContent[name])
+				}
+			}
+		}
+		for name, expression := range valueRefs.Headers {
+			headersContent[name] = ""
+			headerRefs[name] = fmt.Sprintf("String(%s)", expression)
+		}
+		for name, expression := range authHeaders {
+			headersContent[name] = ""
+			headerRefs[name] = expression
+		}
+		if len(headerRefs) > 0 {
+			headersLiteral = formatAsJSObject(headersContent, headerRefs)
+		}
+		code += fmt.Sprintf("\tconst %s = %s;\n", headersVariableName, headersLiteral)
+
+		// Cookie parameters and API keys sent as cookies are added to the cookie jar's cookies for the request
+		cookieSpecs := cookieParameterSpecs(parameterSpecs)
+		cookieValues := cookieParameterValues(operationID, path, cookieSpecs, envFitnessPath(environment))
+		cookiesContent := make(map[string]string)
+		cookieRefs := make(map[string]string)
+		for _, name := range endpointDetails.CookieParams {
+			spec := findParameterSpec(cookieSpecs, name, "cookie")
+			value, ok := cookieValues[name]
+			if !ok {
+				if !parameterRequired(spec) {
+					continue
+				}
+				value = exampleParameterValue
+			}
+			cookiesContent[name] = serializeCookieValue(decodeParameterValue(value, spec))
+			if envRegistry != nil && isSensitiveName(name, sensitiveNames) {
+				cookieRefs[name] = envRegistry.Ref(name, cookiesContent[name])
+			}
+		}
+		for name, expression := range valueRefs.Cookies {
+			cookiesContent[name] = ""
+			cookieRefs[name] = fmt.Sprintf("encodeURIComponent(%s)", expression)
+		}
+		for name, expression := range authCookies {
+			cookiesContent[name] = ""
+			cookieRefs[name] = expression
+		}
+
+		requestParams := fmt.Sprintf("{ headers: %s }", headersVariableName)
+		if len(cookiesContent) > 0 {
+			cookiesVariableName := fmt.Sprintf("%s_cookies", identifier)
+			code += fmt.Sprintf("\tconst %s = %s;\n", cookiesVariableName, formatAsJSObject(cookiesContent, cookieRefs))
+			requestParams = fmt.Sprintf("{ headers: %s, cookies: %s }", headersVariableName, cookiesVariableName)
+		}
+
+		// Operations with their own SLOs tag requests and checks so their thresholds can select them
+		checkTags := ""
+		if hasOperationSLO(validationReport.SLO, operationID) {
+			requestParams = strings.TrimSuffix(requestParams, " }") + ", tags: " + operationTagExpression(operationID) + " }"
+			checkTags = ", " + operationTagExpression(operationID)
+		}
 
 		// Construct k6 request - ONLY CHANGE IS HERE
 		switch strings.ToLower(method) {
 		case "post":
-			k6Code += fmt.Sprintf("\tlet %s = http.post(%s, %s, { headers: %s });\n", resVariableName, fullUrlVariableName, bodyVariableName, headersVariableName)
+			code += fmt.Sprintf("\tlet %s = http.post(%s, %s, %s);\n", resVariableName, fullUrlVariableName, bodyVariableName, requestParams)
 		case "put":
-			k6Code += fmt.Sprintf("\tlet %s = http.put(%s, %s, { headers: %s });\n", resVariableName, fullUrlVariableName, bodyVariableName, headersVariableName)
+			code += fmt.Sprintf("\tlet %s = http.put(%s, %s, %s);\n", resVariableName, fullUrlVariableName, bodyVariableName, requestParams)
 		case "patch":
-			k6Code += fmt.Sprintf("\tlet %s = http.patch(%s, %s, { headers: %s });\n", resVariableName, fullUrlVariableName, bodyVariableName, headersVariableName)
+			code += fmt.Sprintf("\tlet %s = http.patch(%s, %s, %s);\n", resVariableName, fullUrlVariableName, bodyVariableName, requestParams)
 		case "delete":
-			k6Code += fmt.Sprintf("\tlet %s = http.del(%s, { headers: %s });\n", resVariableName, fullUrlVariableName, headersVariableName)
+			// k6 takes the params of a DELETE after its body
+			code += fmt.Sprintf("\tlet %s = http.del(%s, null, %s);\n", resVariableName, fullUrlVariableName, requestParams)
 		case "get":
-			k6Code += fmt.Sprintf("\tlet %s = http.get(%s, { headers: %s });\n", resVariableName, fullUrlVariableName, headersVariableName)
+			code += fmt.Sprintf("\tlet %s = http.get(%s, %s);\n", resVariableName, fullUrlVariableName, requestParams)
 		default:
 			// Default to GET for any unrecognized method
-			k6Code += fmt.Sprintf("\tlet %s = http.get(%s, { headers: %s });\n", resVariableName, fullUrlVariableName, headersVariableName)
+			code += fmt.Sprintf("\tlet %s = http.get(%s, %s);\n", resVariableName, fullUrlVariableName, requestParams)
+		}
+		code += fmt.Sprintf("\t%sTrend.add(%s.timings.duration);\n", identifier, resVariableName)
+
+		// Expected status codes and, when enabled, the response body contract come from the spec's responses
+		expectedStatus := endpointDetails.ExpectedStatus
+		if len(expectedStatus) == 0 {
+			expectedStatus = []string{"200"}
+		}
+		code += fmt.Sprintf("\tcheck(%s, {\n", resVariableName)
+		code += fmt.Sprintf("\t\t%s: (r) => %s,\n", jsemit.String(identifier+"_status_"+strings.Join(expectedStatus, "_")+"_check"), statusCheckExpression(expectedStatus))
+		if generatorConfig.ResponseChecks.Schema {
+			responseSchema := responseSchemaFor(findOperation(swagger, path, method), expectedStatus)
+			if responseSchema != nil {
+				code += fmt.Sprintf("\t\t%s: (r) => {\n\t\t\ttry {\n\t\t\t\tconst body = r.json();\n\t\t\t\treturn %s;\n\t\t\t} catch (e) {\n\t\t\t\treturn false;\n\t\t\t}\n\t\t},\n", jsemit.String(identifier+"_response_schema_check"), compileResponseSchemaCheck(responseSchema, "body", 0))
+			}
 		}
-		k6Code += fmt.Sprintf("\t%sTrend.add(%s.timings.waiting);\n", operationID, resVariableName)
-		k6Code += fmt.Sprintf("\tcheck(%s, {\n\t\t'%s_status_200_check': (r) => r.status == 200,\n\t});\n", resVariableName, operationID)
+		code += fmt.Sprintf("\t}%s);\n", checkTags)
+
+		if inFlow {
+			code += flowExtractionCode(operationID, resVariableName, flowStep, checkTags)
+			code = guardFlowStep(operationID, code, flowDependencies(flowStep))
+		}
+		operationCode[operationID] = code
 	}
 
-	// Add handleSummary function at the end
-	k6Code += `}
+	functionParameters := ""
+	if len(securitySchemes) > 0 {
+		functionParameters = "data"
+	}
+	if validationReport.Flow != nil {
+		k6Code += flowHelpersCode()
+	}
+	for _, scenario := range scenarios {
+		if scenario.Exec == "" {
+			k6Code += fmt.Sprintf("\nexport default function (%s) {\n", functionParameters)
+		} else {
+			k6Code += fmt.Sprintf("\n// Scenario %s: weight %d of %d\nexport function %s(%s) {\n", scenario.Name, scenario.Weight, totalWeight, scenario.Exec, functionParameters)
+		}
+		operations := flowOrder(validationReport.Flow, scenario.OperationIDs)
+		if len(operations) > 0 {
+			if _, ok := validationReport.Flow.step(operations[0]); ok {
+				k6Code += fmt.Sprintf("\tconst %s = {};\n", flowVariable)
+			}
+		}
+		for _, operationID := range operations {
+			k6Code += operationCode[operationID]
+		}
+		k6Code += "}\n"
+	}
 
+	// Add handleSummary function at the end
+	k6Code += `
 // Generate HTML Report
 export function handleSummary(data) {
 	return {
@@ -1351,7 +1913,24 @@ export function handleSummary(data) {
 }
 `
 
-	return k6Code, nil
+	if usesBasicAuth(securitySchemes, swagger) {
+		k6Code = strings.Replace(k6Code, "import { Trend } from 'k6/metrics';\n", "import { Trend } from 'k6/metrics';\nimport encoding from 'k6/encoding';\n", 1)
+		envCheckOffset += len("import encoding from 'k6/encoding';\n")
+	}
+	if initCode != "" {
+		k6Code = k6Code[:envCheckOffset] + "\n// Files uploaded by the requests, opened once in the init stage\n" + initCode + k6Code[envCheckOffset:]
+	}
+	if datasetCode, datasetImports := datasetInitCode(validationReport.Datasets, operationIDs, envFitnessPath(environment)); datasetCode != "" {
+		k6Code = strings.Replace(k6Code, "import { Trend } from 'k6/metrics';\n", "import { Trend } from 'k6/metrics';\n"+datasetImports, 1)
+		envCheckOffset += len(datasetImports)
+		k6Code = k6Code[:envCheckOffset] + "\n// Datasets shared by all VUs, loaded once in the init stage\n" + datasetCode + k6Code[envCheckOffset:]
+	}
+
+	if len(envRegistry.References()) == 0 {
+		return k6Code, nil, nil
+	}
+	k6Code = k6Code[:envCheckOffset] + envrefs.RequiredCheck(envRegistry.Names(), envrefs.FileName(scriptFileName(environment))) + k6Code[envCheckOffset:]
+	return k6Code, envRegistry.References(), nil
 }
 
 // Helper function to format a map as JSON
@@ -1370,9 +1949,10 @@ func getQueryParams(operationID string, queryParams []string, fitnessPath string
 	// Get endpoint path for this operationID
 	var endpointLastPart string
 	if paths, ok := swagger["paths"].(map[string]interface{}); ok {
-		for pathKey, pathValue := range paths {
-			if pathMap, ok := pathValue.(map[string]interface{}); ok {
-				for _, methodValue := range pathMap {
+		for _, pathKey := range sortedKeys(paths) {
+			if pathMap, ok := paths[pathKey].(map[string]interface{}); ok {
+				for _, methodKey := range sortedMethodKeys(pathMap) {
+					methodValue := pathMap[methodKey]
 					if operationMap, ok := methodValue.(map[string]interface{}); ok {
 						if opID, exists := operationMap["operationId"].(string); exists && opID == operationID {
 							endpointLastPart = filepath.Base(pathKey)
@@ -1420,13 +2000,11 @@ func getQueryParams(operationID string, queryParams []string, fitnessPath string
 		}
 	}
 
-	// Fallback to Swagger examples if file values are missing
+	// Fallback to Swagger examples if file values are missing; parameters without either are left out
 	for _, param := range queryParams {
 		if _, exists := paramsContent[param]; !exists {
 			if swaggerParam, ok := getSwaggerParamExample(param, swagger); ok {
 				paramsContent[param] = swaggerParam
-			} else {
-				paramsContent[param] = "example_value" // Default fallback
 			}
 		}
 	}
@@ -1434,15 +2012,6 @@ func getQueryParams(operationID string, queryParams []string, fitnessPath string
 	return paramsContent
 }
 
-// Helper function to generate query parameters string
-func generateQueryParamsString(params map[string]string) string {
-	var queryParams []string
-	for key, value := range params {
-		queryParams = append(queryParams, fmt.Sprintf("%s=%s", key, value))
-	}
-	return "?" + strings.Join(queryParams, "&")
-}
-
 // Helper function to get example value from Swagger
 func getSwaggerParamExample(paramName string, swagger map[string]interface{}) (string, bool) {
 	paths, ok := swagger["paths"].(map[string]interface{})
@@ -1450,14 +2019,14 @@ func getSwaggerParamExample(paramName string, swagger map[string]interface{}) (s
 		return "", false
 	}
 
-	for _, pathItem := range paths {
-		pathItemMap, ok := pathItem.(map[string]interface{})
+	for _, pathKey := range sortedKeys(paths) {
+		pathItemMap, ok := paths[pathKey].(map[string]interface{})
 		if !ok {
 			continue
 		}
 
-		for _, operation := range pathItemMap {
-			operationMap, ok := operation.(map[string]interface{})
+		for _, method := range sortedMethodKeys(pathItemMap) {
+			operationMap, ok := pathItemMap[method].(map[string]interface{})
 			if !ok {
 				continue
 			}
@@ -1479,7 +2048,7 @@ func getSwaggerParamExample(paramName string, swagger map[string]interface{}) (s
 				}
 
 				if example, ok := paramMap["example"]; ok {
-					return fmt.Sprintf("%v", example), true
+					return parameterValueString(example), true
 				}
 			}
 		}
@@ -1499,9 +2068,10 @@ func getHeadersContent(operationID string, headerParams []string, fitnessPath st
 	// Get endpoint path for this operationID
 	var endpointLastPart string
 	if paths, ok := swagger["paths"].(map[string]interface{}); ok {
-		for pathKey, pathValue := range paths {
-			if pathMap, ok := pathValue.(map[string]interface{}); ok {
-				for _, methodValue := range pathMap {
+		for _, pathKey := range sortedKeys(paths) {
+			if pathMap, ok := paths[pathKey].(map[string]interface{}); ok {
+				for _, methodKey := range sortedMethodKeys(pathMap) {
+					methodValue := pathMap[methodKey]
 					if operationMap, ok := methodValue.(map[string]interface{}); ok {
 						if opID, exists := operationMap["operationId"].(string); exists && opID == operationID {
 							endpointLastPart = filepath.Base(pathKey)
@@ -1574,13 +2144,11 @@ func getHeadersContent(operationID string, headerParams []string, fitnessPath st
 		fmt.Printf("Headers file not found for operationID: %s\n", operationID)
 	}
 
-	// Fallback to Swagger examples if file values are missing
+	// Fallback to Swagger examples if file values are missing; headers without either are left out
 	for _, header := range headerParams {
 		if _, exists := headersContent[header]; !exists {
 			if swaggerHeader, ok := getSwaggerHeaderExample(header, swagger); ok {
 				headersContent[header] = swaggerHeader
-			} else {
-				headersContent[header] = "example_value" // Default fallback
 			}
 		}
 	}
@@ -1595,14 +2163,14 @@ func getSwaggerHeaderExample(headerName string, swagger map[string]interface{})
 		return "", false
 	}
 
-	for _, pathItem := range paths {
-		pathItemMap, ok := pathItem.(map[string]interface{})
+	for _, pathKey := range sortedKeys(paths) {
+		pathItemMap, ok := paths[pathKey].(map[string]interface{})
 		if !ok {
 			continue
 		}
 
-		for _, operation := range pathItemMap {
-			operationMap, ok := operation.(map[string]interface{})
+		for _, method := range sortedMethodKeys(pathItemMap) {
+			operationMap, ok := pathItemMap[method].(map[string]interface{})
 			if !ok {
 				continue
 			}
@@ -1624,7 +2192,7 @@ func getSwaggerHeaderExample(headerName string, swagger map[string]interface{})
 				}
 
 				if example, ok := paramMap["example"]; ok {
-					return fmt.Sprintf("%v", example), true
+					return parameterValueString(example), true
 				}
 			}
 		}
@@ -1671,8 +2239,62 @@ func GenerateReport(validationReport ValidationReport) {
 	fmt.Println("           VALIDATION REPORT")
 	fmt.Println("===========================================")
 
+	if validationReport.SpecVersion != "" {
+		fmt.Println("Specification:", validationReport.SpecVersion)
+	}
+	if len(validationReport.ConversionWarnings) > 0 {
+		fmt.Println("\n⚠️  Swagger 2.0 features not converted:")
+		for _, warning := range validationReport.ConversionWarnings {
+			fmt.Println("   -", warning)
+		}
+	}
+
 	if validationReport.MissingServerURL {
 		fmt.Println("❌ Server URL is missing in the Swagger file")
+		if validationReport.ServerIssue != "" {
+			fmt.Println("   ", validationReport.ServerIssue)
+		}
+	} else if validationReport.ServerURL != "" {
+		fmt.Println("Server:", validationReport.ServerURL)
+	}
+
+	if len(validationReport.ProfileIssues) > 0 {
+		fmt.Printf("\n❌ Invalid load profile (%s):\n", loadProfileFileName)
+		for _, issue := range validationReport.ProfileIssues {
+			fmt.Println("   -", issue)
+		}
+	}
+
+	if len(validationReport.SLOIssues) > 0 {
+		fmt.Printf("\n❌ Invalid service level objectives (%s):\n", sloFileName)
+		for _, issue := range validationReport.SLOIssues {
+			fmt.Println("   -", issue)
+		}
+	}
+
+	if len(validationReport.DatasetIssues) > 0 {
+		fmt.Printf("\n❌ Invalid datasets (%s):\n", datasetsFileName)
+		for _, issue := range validationReport.DatasetIssues {
+			fmt.Println("   -", issue)
+		}
+	}
+
+	if len(validationReport.LinkWarnings) > 0 {
+		fmt.Println("\n⚠️  OpenAPI links not followed:")
+		for _, warning := range validationReport.LinkWarnings {
+			fmt.Println("   -", warning)
+		}
+	}
+
+	if len(validationReport.FlowIssues) > 0 {
+		flowSource := flowFileName
+		if validationReport.Flow != nil && validationReport.Flow.Inferred {
+			flowSource = "inferred from OpenAPI links"
+		}
+		fmt.Printf("\n❌ Invalid flow (%s):\n", flowSource)
+		for _, issue := range validationReport.FlowIssues {
+			fmt.Println("   -", issue)
+		}
 	}
 
 	if len(validationReport.MissingFiles) > 0 {
@@ -1693,10 +2315,26 @@ func GenerateReport(validationReport ValidationReport) {
 		}
 	}
 
+	if len(validationReport.SkippedOperations) > 0 {
+		fmt.Println("\n⏭️  Skipped operations:")
+		for _, skipped := range validationReport.SkippedOperations {
+			fmt.Printf("   - %s (%s %s): %s\n", skipped.OperationID, strings.ToUpper(skipped.Method), skipped.Path, skipped.Reason)
+		}
+	}
+
 	fmt.Println("\n📋 Endpoints found:")
-	for operationID, details := range validationReport.Endpoints {
+	for _, operationID := range sortedEndpointIDs(validationReport.Endpoints) {
+		details := validationReport.Endpoints[operationID]
 		fmt.Printf("   - %s (%s %s)\n", operationID, strings.ToUpper(details.Method), details.Path)
 
+		if details.Synthesized {
+			fmt.Println("     operationId: synthesized from the method and path (none in the spec)")
+		}
+
+		if details.BodySource == bodySourceSynthesized {
+			fmt.Println("     Request body: synthesized from schema (no body file or example)")
+		}
+
 		if len(details.QueryParams) > 0 {
 			// fmt.Println("     Query parameters:", strings.Join(details.QueryParams, ", "))
 		}
@@ -1727,7 +2365,7 @@ func GenerateReport(validationReport ValidationReport) {
 		}
 	}
 
-	hasIssues := validationReport.MissingServerURL || len(validationReport.MissingFiles) > 0 || len(validationReport.EmptyValues) > 0
+	hasIssues := validationReport.MissingServerURL || len(validationReport.MissingFiles) > 0 || len(validationReport.EmptyValues) > 0 || len(validationReport.ProfileIssues) > 0 || len(validationReport.SLOIssues) > 0 || len(validationReport.DatasetIssues) > 0 || len(validationReport.FlowIssues) > 0
 	for _, endpoint := range validationReport.Endpoints {
 		if len(endpoint.Issues) > 0 {
 			hasIssues = true
@@ -1778,9 +2416,9 @@ func detectEnvironmentFolders(fitnessPath string) []string {
 }
 
 func ValidateSwaggerAndFiles() error {
-	fitnessFolderPath := constants.PathConstantsInstance.VPEConfigPath
+	fitnessFolderPath := swaggerFolderPath()
 	if fitnessFolderPath == "" {
-		return fmt.Errorf("please provide folder path as an argument")
+		return fmt.Errorf("please provide the folder path with --path")
 	}
 
 	fmt.Println("Checking folder:", fitnessFolderPath)
@@ -1790,20 +2428,38 @@ func ValidateSwaggerAndFiles() error {
 		return fmt.Errorf("fitness folder does not exist")
 	}
 
+	config, err := loadGeneratorConfig(fitnessPath)
+	if err != nil {
+		return err
+	}
+	generatorConfig = config
+	if err := validateOperationSelection(mergeOperationSelection(generatorConfig.Operations, operationFlags)); err != nil {
+		return err
+	}
+
 	// Detect environment folders
-	environmentFolders := detectEnvironmentFolders(fitnessPath)
-	fmt.Println("\nDetected environment folders:", environmentFolders)
+	environmentFolders, selectionRule, skippedEnvironments, err := selectEnvironmentFolders(fitnessPath, mergeEnvironmentSelection(generatorConfig.Environments, environmentFlags))
+	if err != nil {
+		return fmt.Errorf("error selecting environment folders: %v", err)
+	}
+	fmt.Println("\nEnvironment selection:", selectionRule)
+	fmt.Println("Detected environment folders:", environmentFolders)
 
 	atLeastOneSuccess := false
 	successEnvironments := []string{}
 	failedEnvironments := []string{}
 	failureReasons := make(map[string]string)
+	environmentResults := make(map[string]*EnvironmentResult)
+	scriptEnvVars := make(map[string][]string)
 
 	for _, environment := range environmentFolders {
 		fmt.Println("\n===========================================")
 		fmt.Println("         Validating environment:", environment)
 		fmt.Println("===========================================")
 
+		environmentResult := &EnvironmentResult{Environment: environment}
+		environmentResults[environment] = environmentResult
+
 		envFitnessPath := filepath.Join(fitnessPath, environment)
 		if !directoryExists(envFitnessPath) {
 			fmt.Printf("Environment folder %s does not exist, skipping.\n", environment)
@@ -1829,6 +2485,7 @@ func ValidateSwaggerAndFiles() error {
 		}
 
 		fmt.Println("\nFound Swagger/OpenAPI file:", swaggerFile)
+		environmentResult.SwaggerFile = swaggerFile
 
 		// Read the Swagger content
 		swaggerContent, err := readFileContent(filepath.Join(envFitnessPath, swaggerFile))
@@ -1848,9 +2505,30 @@ func ValidateSwaggerAndFiles() error {
 				failureReasons[environment] = fmt.Sprintf("YAML parsing failed: %v", err)
 				continue
 			}
+			swagger = normalizeYAMLValue(swagger).(map[string]interface{})
+		}
+
+		// Dereference $ref pointers so shared components are validated like inline definitions
+		swagger, circularRefs, err := resolveSwaggerRefs(swagger, filepath.Join(envFitnessPath, swaggerFile))
+		if err != nil {
+			fmt.Printf("Error resolving $ref in environment %s: %v\n", environment, err)
+			failedEnvironments = append(failedEnvironments, environment)
+			failureReasons[environment] = fmt.Sprintf("$ref resolution error: %v", err)
+			continue
+		}
+		if len(circularRefs) > 0 {
+			fmt.Printf("Warning: Left %d circular $ref(s) unresolved: %s\n", len(circularRefs), strings.Join(circularRefs, ", "))
 		}
 
 		validationReport := createValidationReport()
+		validationReport.SpecVersion = specVersion(swagger)
+		environmentResult.Report = &validationReport
+		if isSwagger2Document(swagger) {
+			fmt.Println("Detected Swagger 2.0 document, converting to OpenAPI 3 layout")
+			validationReport.ConversionWarnings = swagger2ConversionWarnings(swagger)
+			swagger = convertSwagger2ToOpenAPI3(swagger)
+		}
+
 		if err := validateSwagger(swagger, envFitnessPath, &validationReport); err != nil {
 			fmt.Printf("Error validating Swagger for environment %s: %v\n", environment, err)
 			failedEnvironments = append(failedEnvironments, environment)
@@ -1860,7 +2538,7 @@ func ValidateSwaggerAndFiles() error {
 
 		GenerateReport(validationReport)
 
-		hasIssues := validationReport.MissingServerURL || len(validationReport.MissingFiles) > 0 || len(validationReport.EmptyValues) > 0
+		hasIssues := validationReport.MissingServerURL || len(validationReport.MissingFiles) > 0 || len(validationReport.EmptyValues) > 0 || len(validationReport.ProfileIssues) > 0 || len(validationReport.SLOIssues) > 0 || len(validationReport.DatasetIssues) > 0 || len(validationReport.FlowIssues) > 0
 		for _, endpoint := range validationReport.Endpoints {
 			if len(endpoint.Issues) > 0 {
 				hasIssues = true
@@ -1868,24 +2546,35 @@ func ValidateSwaggerAndFiles() error {
 			}
 		}
 
-		if !hasIssues {
+		if !hasIssues && swaggerOptions.Mode != swaggerModeGenerate {
+			atLeastOneSuccess = true
+			successEnvironments = append(successEnvironments, environment)
+		} else if !hasIssues {
 			atLeastOneSuccess = true
 			fmt.Println("\nGenerating k6 script for environment:", environment)
 
-			k6Script, err := generateK6Script(swagger, validationReport, environment)
+			k6Script, envReferences, err := generateK6Script(swagger, validationReport, environment)
 			if err != nil {
 				fmt.Printf("Error generating k6 script: %v\n", err)
 				failedEnvironments = append(failedEnvironments, environment)
 				failureReasons[environment] = fmt.Sprintf("K6 script generation error: %v", err)
+			} else if swaggerOptions.DryRun {
+				k6FileName := scriptFileName(environment)
+				fmt.Printf("--- %s (dry run, not written) ---\n%s\n", k6FileName, k6Script)
+				if len(envReferences) > 0 {
+					fmt.Printf("%s would define: %s\n", envrefs.FileName(k6FileName), strings.Join(envrefs.Names(envReferences), ", "))
+				}
+				environmentResult.ScriptFile = k6FileName
+				successEnvironments = append(successEnvironments, environment)
 			} else {
-				k6FolderPath := filepath.Join(fitnessFolderPath, "k6")
+				k6FolderPath := swaggerOutputDir()
 				err := os.MkdirAll(k6FolderPath, os.ModePerm)
 				if err != nil {
 					fmt.Printf("Error creating k6 folder: %v\n", err)
 					return err
 				}
 
-				k6FileName := fmt.Sprintf("vpe-default-k6-swagger_%s.js", environment)
+				k6FileName := scriptFileName(environment)
 				k6FilePath := filepath.Join(k6FolderPath, k6FileName)
 
 				err = ioutil.WriteFile(k6FilePath, []byte(k6Script), 0644)
@@ -1895,9 +2584,24 @@ func ValidateSwaggerAndFiles() error {
 					failureReasons[environment] = fmt.Sprintf("Error writing k6 script to file: %v", err)
 				} else {
 					fmt.Println("✅ Successfully generated k6 script:", k6FileName)
+					environmentResult.ScriptFile = k6FileName
 					successEnvironments = append(successEnvironments, environment)
+
+					if len(envReferences) > 0 {
+						// Secrets live only in the env file, so keep it private to the owner
+						envFilePath := filepath.Join(k6FolderPath, envrefs.FileName(k6FileName))
+						if err := ioutil.WriteFile(envFilePath, []byte(envrefs.FileContent(k6FileName, envReferences)), 0600); err != nil {
+							fmt.Printf("Error writing env file: %v\n", err)
+						} else {
+							fmt.Println("✅ Wrote __ENV values to", envrefs.FileName(k6FileName))
+							scriptEnvVars[environment] = envrefs.Names(envReferences)
+						}
+					}
 				}
 			}
+		} else if swaggerOptions.Mode != swaggerModeGenerate {
+			failedEnvironments = append(failedEnvironments, environment)
+			failureReasons[environment] = "Validation issues found"
 		} else {
 			fmt.Printf("Skipping k6 script generation for %s due to validation issues.\n", environment)
 			failedEnvironments = append(failedEnvironments, environment)
@@ -1908,8 +2612,8 @@ func ValidateSwaggerAndFiles() error {
 	}
 
 	// Create or append to env_vars file only if at least one test script is successfully generated
-	if len(successEnvironments) > 0 {
-		k6FolderPath := filepath.Join(fitnessFolderPath, "k6")
+	if len(successEnvironments) > 0 && swaggerOptions.Mode == swaggerModeGenerate && !swaggerOptions.DryRun {
+		k6FolderPath := swaggerOutputDir()
 		err := os.MkdirAll(k6FolderPath, os.ModePerm)
 		if err != nil {
 			fmt.Printf("Error creating k6 folder: %v\n", err)
@@ -1949,26 +2653,80 @@ func ValidateSwaggerAndFiles() error {
 				fmt.Println("env_vars file inside fitness/k6 folder already contains the required exports.")
 			}
 		}
+
+		if len(scriptEnvVars) > 0 {
+			if err := recordScriptEnvVars(envVarsFileName, scriptEnvVars); err != nil {
+				return fmt.Errorf("error recording expected variables in env_vars file: %v", err)
+			}
+			fmt.Println("Recorded the __ENV variables each script expects in env_vars")
+		}
 	}
 
 	fmt.Println("\n===========================================")
 	fmt.Println("                 SUMMARY")
 	fmt.Println("===========================================")
 
+	fmt.Println("\nEnvironment selection:", selectionRule)
+	if len(skippedEnvironments) > 0 {
+		fmt.Println("\n⏭️  Skipped folders:")
+		for _, skipped := range skippedEnvironments {
+			fmt.Printf("   - %s: %s\n", skipped.Folder, skipped.Reason)
+		}
+	}
+
+	skippedOperationsShown := false
+	for _, environment := range environmentFolders {
+		result := environmentResults[environment]
+		if result == nil || result.Report == nil {
+			continue
+		}
+		for _, skipped := range result.Report.SkippedOperations {
+			if !skippedOperationsShown {
+				fmt.Println("\n⏭️  Skipped operations:")
+				skippedOperationsShown = true
+			}
+			fmt.Printf("   - %s: %s (%s %s): %s\n", environment, skipped.OperationID, strings.ToUpper(skipped.Method), skipped.Path, skipped.Reason)
+		}
+	}
+
+	successHeading, failureHeading := "Successfully generated k6 scripts for:", "Failed to generate k6 scripts for:"
+	if swaggerOptions.Mode != swaggerModeGenerate {
+		successHeading, failureHeading = "Validation passed for:", "Validation failed for:"
+	} else if swaggerOptions.DryRun {
+		successHeading = "Generated k6 scripts (dry run, nothing written) for:"
+	}
+
 	if len(successEnvironments) > 0 {
-		fmt.Println("\n✅ Successfully generated k6 scripts for:")
+		fmt.Println("\n✅", successHeading)
 		for _, env := range successEnvironments {
 			fmt.Println("   -", env)
 		}
 	}
 
 	if len(failedEnvironments) > 0 {
-		fmt.Println("\n❌ Failed to generate k6 scripts for:")
+		fmt.Println("\n❌", failureHeading)
 		for _, env := range failedEnvironments {
 			fmt.Printf("   - %s: %s\n", env, failureReasons[env])
 		}
 	}
 
+	if reportFormat != "" {
+		runReport := buildRunReport(fitnessPath, swaggerOptions.Mode, environmentFolders, environmentResults, failureReasons, successEnvironments)
+		runReport.EnvironmentSelection = selectionRule
+		runReport.SkippedEnvironments = skippedEnvironments
+		reportPath := reportOut
+		if reportPath == "" {
+			reportPath = filepath.Join(swaggerOutputDir(), defaultReportFileName(reportFormat))
+		}
+		if err := writeRunReport(runReport, reportFormat, reportPath); err != nil {
+			return fmt.Errorf("error writing %s report: %v", reportFormat, err)
+		}
+		fmt.Printf("\n📄 Wrote %s validation report to %s\n", reportFormat, reportPath)
+	}
+
+	if !atLeastOneSuccess && swaggerOptions.Mode != swaggerModeGenerate {
+		return fmt.Errorf("no environment passed validation")
+	}
 	if !atLeastOneSuccess {
 		return fmt.Errorf("no k6 scripts were successfully generated")
 	}
@@ -1977,8 +2735,13 @@ func ValidateSwaggerAndFiles() error {
 }
 
 func main() {
-	if err := ValidateSwaggerAndFiles(); err != nil {
+	// Without a subcommand keep the original behaviour of generating scripts for the VPE config path
+	if len(os.Args) == 1 {
+		k6Cmd.SetArgs([]string{"swagger", "generate"})
+	}
+
+	if err := k6Cmd.Execute(); err != nil {
 		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// hostileValues look like what specs and fitness files may carry into a script.
+var hostileValues = []string{
+	"getUser",
+	"get-user",
+	"delete",
+	"123abc",
+	"O'Brien",
+	`say "hi"`,
+	"line1\nline2",
+	"`${__ENV.SECRET}`",
+	"$${}",
+	"*/ throw new Error() /*",
+	`trailing\`,
+	"emoji 🚀",
+}
+
+// TestGenerateK6ScriptWithHostileValues uses each hostile value as an operationId, path segment,
+// parameter example, header and body, and checks that node can parse the generated script.
+func TestGenerateK6ScriptWithHostileValues(t *testing.T) {
+	savedOptions, savedConfig := swaggerOptions, generatorConfig
+	defer func() {
+		swaggerOptions, generatorConfig = savedOptions, savedConfig
+	}()
+	swaggerOptions.FolderPath = t.TempDir()
+	fitnessPath := filepath.Join(swaggerOptions.FolderPath, "fitness")
+	envFitnessPath := filepath.Join(fitnessPath, "dev")
+	if err := os.MkdirAll(envFitnessPath, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	config, err := loadGeneratorConfig(fitnessPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	generatorConfig = config
+
+	paths := map[string]interface{}{}
+	for i, value := range hostileValues {
+		path := fmt.Sprintf("/op%d/{id}", i)
+		if !strings.ContainsAny(value, "{}") {
+			path = fmt.Sprintf("/op%d/%s/{id}", i, value)
+		}
+		paths[path] = map[string]interface{}{
+			"post": map[string]interface{}{
+				"operationId": value,
+				"summary":     value,
+				"parameters": []interface{}{
+					map[string]interface{}{"name": "id", "in": "path", "required": true, "schema": map[string]interface{}{"type": "string", "example": value}},
+					map[string]interface{}{"name": "q", "in": "query", "schema": map[string]interface{}{"type": "string", "example": value}},
+					map[string]interface{}{"name": "X-Value", "in": "header", "required": true, "schema": map[string]interface{}{"type": "string", "example": value}},
+				},
+				"requestBody": map[string]interface{}{
+					"content": map[string]interface{}{
+						"application/json": map[string]interface{}{
+							"schema": map[string]interface{}{
+								"type":       "object",
+								"properties": map[string]interface{}{"name": map[string]interface{}{"type": "string", "example": value}},
+							},
+							"example": map[string]interface{}{"name": value, value: value},
+						},
+					},
+				},
+				"responses": map[string]interface{}{"200": map[string]interface{}{"description": value}},
+			},
+		}
+
+		// Fitness files are named after the operationId, so only values a file name can hold get them
+		if strings.ContainsAny(value, "/\x00") {
+			continue
+		}
+		header := fmt.Sprintf("headers:\n  header:\n    - name: X-Value\n      value: %q\n", value)
+		if err := os.WriteFile(filepath.Join(envFitnessPath, value+"_header.yaml"), []byte(header), 0644); err != nil {
+			t.Fatal(err)
+		}
+		body, _ := json.Marshal(map[string]interface{}{"name": value, value: []interface{}{value}})
+		if err := os.WriteFile(filepath.Join(envFitnessPath, value+"_body.json"), body, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	spec, _ := json.Marshal(map[string]interface{}{
+		"openapi": "3.0.0",
+		"info":    map[string]interface{}{"title": "hostile", "version": "1"},
+		"servers": []interface{}{map[string]interface{}{
+			"url":       "https://api.example.com/`${x}`'",
+			"variables": map[string]interface{}{"x": map[string]interface{}{"default": "${'`"}},
+		}},
+		"paths": paths,
+	})
+	if err := os.WriteFile(filepath.Join(envFitnessPath, "openapi.json"), spec, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	script, _ := generateGolden(t, fitnessPath, "dev")
+	checkScriptSyntax(t, script)
+}
+
+// checkScriptSyntax fails when node cannot parse script as a module.
+func checkScriptSyntax(t *testing.T, script string) {
+	t.Helper()
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("node is not installed, the script is not parsed")
+	}
+	// node only reads a script with import statements as a module from an .mjs file
+	path := filepath.Join(t.TempDir(), "script.mjs")
+	if err := os.WriteFile(path, []byte(script), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if output, err := exec.Command(node, "--check", path).CombinedOutput(); err != nil {
+		t.Fatalf("node rejected the script: %v\n%s\n%s", err, output, script)
+	}
+}
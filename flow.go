@@ -0,0 +1,385 @@
+package main
+
+import (
+	"fmt"
+	"net/textproto"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"k6-generator/jsemit"
+
+	"gopkg.in/yaml.v2"
+)
+
+// flowFileName chains operations of an environment: the steps run in order, and the values they extract
+// from their responses feed the requests of later steps:
+//
+//	steps:
+//	  - operation: createOrder
+//	    extract:
+//	      orderId:
+//	        body: $.id
+//	      etag:
+//	        header: ETag
+//	  - operation: getOrder
+//	    path:
+//	      id: orderId
+//	    headers:
+//	      If-None-Match: etag
+//
+// A step whose values were not extracted is skipped for the iteration.
+const flowFileName = "flow.yaml"
+
+// flowVariable holds the values extracted during an iteration.
+const flowVariable = "flow"
+
+var flowVariableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// FlowExtraction reads one value from a response: a JSONPath into the body, a header, or the first
+// group of a regular expression matched against the body (the whole match when it has no group).
+type FlowExtraction struct {
+	Body   string `yaml:"body" json:"body,omitempty"`
+	Header string `yaml:"header" json:"header,omitempty"`
+	Regex  string `yaml:"regex" json:"regex,omitempty"`
+}
+
+// FlowStep runs an operation, feeding its request from variables extracted by earlier steps.
+type FlowStep struct {
+	Operation       string                    `yaml:"operation" json:"operation"`
+	Extract         map[string]FlowExtraction `yaml:"extract" json:"extract,omitempty"`
+	RequestMappings `yaml:",inline"`
+}
+
+// FlowConfig is the content of flow.yaml.
+type FlowConfig struct {
+	Steps []FlowStep `yaml:"steps" json:"steps"`
+	// Inferred is set for flows built from the links of the spec rather than read from flow.yaml
+	Inferred bool `yaml:"-" json:"inferred,omitempty"`
+}
+
+// step returns the step running an operation.
+func (config *FlowConfig) step(operationID string) (FlowStep, bool) {
+	if config == nil {
+		return FlowStep{}, false
+	}
+	for _, step := range config.Steps {
+		if step.Operation == operationID {
+			return step, true
+		}
+	}
+	return FlowStep{}, false
+}
+
+// loadFlowConfig reads flow.yaml from an environment folder; found is false when it does not exist.
+func loadFlowConfig(fitnessPath string) (FlowConfig, bool, error) {
+	var config FlowConfig
+
+	flowPath := filepath.Join(fitnessPath, flowFileName)
+	if !fileExists(flowPath) {
+		return config, false, nil
+	}
+
+	content, err := readFileContent(flowPath)
+	if err != nil {
+		return config, true, err
+	}
+	if err := yaml.UnmarshalStrict([]byte(content), &config); err != nil {
+		return config, true, fmt.Errorf("error parsing %s: %w", flowFileName, err)
+	}
+	return config, true, nil
+}
+
+// jsonPathSelector converts a JSONPath made of $, .name, ['name'] and [index] segments to the
+// selector of k6's Response.json().
+func jsonPathSelector(path string) (string, error) {
+	if !strings.HasPrefix(path, "$") {
+		return "", fmt.Errorf("JSONPath %q must start with $", path)
+	}
+
+	var segments []string
+	rest := path[1:]
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, "["):
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return "", fmt.Errorf("JSONPath %q has an unclosed [", path)
+			}
+			segment := rest[1:end]
+			rest = rest[end+1:]
+			if len(segment) >= 2 && (segment[0] == '\'' || segment[0] == '"') && segment[len(segment)-1] == segment[0] {
+				segments = append(segments, segment[1:len(segment)-1])
+				continue
+			}
+			if _, err := strconv.Atoi(segment); err != nil {
+				return "", fmt.Errorf("JSONPath %q only supports names and indexes in brackets", path)
+			}
+			segments = append(segments, segment)
+
+		case strings.HasPrefix(rest, "."):
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 || rest[:end] == "*" {
+				return "", fmt.Errorf("JSONPath %q only supports names and indexes", path)
+			}
+			segments = append(segments, rest[:end])
+			rest = rest[end:]
+
+		default:
+			return "", fmt.Errorf("JSONPath %q is not supported", path)
+		}
+	}
+
+	// Characters with a meaning in k6 selectors are escaped in names
+	for i, segment := range segments {
+		var escaped strings.Builder
+		for _, char := range segment {
+			if strings.ContainsRune(`.*?|#@\`, char) {
+				escaped.WriteRune('\\')
+			}
+			escaped.WriteRune(char)
+		}
+		segments[i] = escaped.String()
+	}
+	return strings.Join(segments, "."), nil
+}
+
+// validateExtraction checks that an extraction has exactly one valid source.
+func validateExtraction(extraction FlowExtraction) error {
+	sources := 0
+	for _, source := range []string{extraction.Body, extraction.Header, extraction.Regex} {
+		if source != "" {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return fmt.Errorf("exactly one of body, header or regex is required")
+	}
+	if extraction.Body != "" {
+		if _, err := jsonPathSelector(extraction.Body); err != nil {
+			return err
+		}
+	}
+	if extraction.Regex != "" {
+		if _, err := regexp.Compile(extraction.Regex); err != nil {
+			return fmt.Errorf("invalid regex %q: %v", extraction.Regex, err)
+		}
+	}
+	return nil
+}
+
+// validateFlowConfig checks that every step runs a distinct operation of the spec, that extracted
+// variables are valid and unique, that mappings only read variables of earlier steps, and that the
+// steps run in the same scenario when the traffic is weighted.
+func validateFlowConfig(config FlowConfig, endpoints map[string]EndpointDetails, skipped []SkippedOperation, weights TrafficWeights) []string {
+	var problems []string
+
+	extracted := make(map[string]bool)
+	seen := make(map[string]bool)
+	for index, step := range config.Steps {
+		prefix := fmt.Sprintf("steps[%d]", index)
+
+		details, ok := endpoints[step.Operation]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s.operation: %s", prefix, missingOperation(step.Operation, skipped)))
+			continue
+		}
+		if seen[step.Operation] {
+			problems = append(problems, fmt.Sprintf("%s.operation: %s already runs in an earlier step", prefix, step.Operation))
+		}
+		seen[step.Operation] = true
+
+		problems = append(problems, step.problems(prefix, details, func(variable string) string {
+			if extracted[variable] {
+				return ""
+			}
+			return fmt.Sprintf("no earlier step extracts %s", variable)
+		})...)
+
+		for _, name := range sortedExtractionNames(step.Extract) {
+			if !flowVariableNamePattern.MatchString(name) {
+				problems = append(problems, fmt.Sprintf("%s.extract.%s: variable names must be JavaScript identifiers", prefix, name))
+			}
+			if extracted[name] {
+				problems = append(problems, fmt.Sprintf("%s.extract.%s: already extracted by an earlier step", prefix, name))
+			}
+			if err := validateExtraction(step.Extract[name]); err != nil {
+				problems = append(problems, fmt.Sprintf("%s.extract.%s: %v", prefix, name, err))
+			}
+		}
+		for name := range step.Extract {
+			extracted[name] = true
+		}
+	}
+
+	if weights.configured() {
+		scenarioOf := make(map[string]string)
+		for _, scenario := range trafficScenarios(weights, sortedEndpointIDs(endpoints), endpoints) {
+			for _, operationID := range scenario.OperationIDs {
+				scenarioOf[operationID] = scenario.Name
+			}
+		}
+		for index, step := range config.Steps {
+			if scenario, ok := scenarioOf[step.Operation]; ok && index > 0 && scenario != scenarioOf[config.Steps[0].Operation] {
+				problems = append(problems, fmt.Sprintf("steps[%d].operation: %s runs in scenario %s, not in scenario %s with the rest of the flow", index, step.Operation, scenario, scenarioOf[config.Steps[0].Operation]))
+			}
+		}
+	}
+	return problems
+}
+
+func sortedExtractionNames(extractions map[string]FlowExtraction) []string {
+	names := make([]string, 0, len(extractions))
+	for name := range extractions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// loadFlowFile reads an environment's flow.yaml into the report before the operations are validated,
+// so path placeholders read from the flow need no other value.
+func loadFlowFile(fitnessPath string, validationReport *ValidationReport) {
+	config, found, err := loadFlowConfig(fitnessPath)
+	if err != nil {
+		validationReport.FlowIssues = append(validationReport.FlowIssues, err.Error())
+		return
+	}
+	if !found {
+		return
+	}
+	fmt.Println("Using flow:", flowFileName)
+	validationReport.Flow = &config
+}
+
+// validateFlowFile records the problems of the flow once every operation is known.
+func validateFlowFile(validationReport *ValidationReport) {
+	if validationReport.Flow == nil {
+		return
+	}
+	var weights TrafficWeights
+	if validationReport.LoadProfile != nil {
+		weights = validationReport.LoadProfile.Weights
+	}
+	validationReport.FlowIssues = append(validationReport.FlowIssues, validateFlowConfig(*validationReport.Flow, validationReport.Endpoints, validationReport.SkippedOperations, weights)...)
+}
+
+// flowOrder runs the flow's operations first, in step order, followed by the other operations.
+func flowOrder(config *FlowConfig, operationIDs []string) []string {
+	if config == nil {
+		return operationIDs
+	}
+	inScenario := make(map[string]bool, len(operationIDs))
+	for _, operationID := range operationIDs {
+		inScenario[operationID] = true
+	}
+
+	ordered := make([]string, 0, len(operationIDs))
+	inFlow := make(map[string]bool)
+	for _, step := range config.Steps {
+		if inScenario[step.Operation] && !inFlow[step.Operation] {
+			ordered = append(ordered, step.Operation)
+			inFlow[step.Operation] = true
+		}
+	}
+	for _, operationID := range operationIDs {
+		if !inFlow[operationID] {
+			ordered = append(ordered, operationID)
+		}
+	}
+	return ordered
+}
+
+// flowValue is the expression reading an extracted variable.
+func flowValue(name string) string {
+	return flowVariable + "." + name
+}
+
+// flowHelpersCode declares the functions extracting values from the body of a response.
+func flowHelpersCode() string {
+	return `
+// Values extracted by the flow; undefined when the response does not hold them
+function extractJSON(res, selector) {
+	try {
+		const value = selector === '' ? res.json() : res.json(selector);
+		return value === null ? undefined : value;
+	} catch (e) {
+		return undefined;
+	}
+}
+
+function extractRegex(res, pattern) {
+	const match = new RegExp(pattern).exec(res.body || '');
+	if (!match) {
+		return undefined;
+	}
+	return match.length > 1 ? match[1] : match[0];
+}
+`
+}
+
+// flowExtractionCode stores the values a step extracts from its response and checks they were found.
+func flowExtractionCode(operationID string, resVariableName string, step FlowStep, checkTags string) string {
+	names := sortedExtractionNames(step.Extract)
+	if len(names) == 0 {
+		return ""
+	}
+
+	code := ""
+	for _, name := range names {
+		extraction := step.Extract[name]
+		switch {
+		case extraction.Body != "":
+			selector, _ := jsonPathSelector(extraction.Body)
+			code += fmt.Sprintf("\t%s = extractJSON(%s, %s);\n", flowValue(name), resVariableName, jsemit.String(selector))
+		case extraction.Header != "":
+			code += fmt.Sprintf("\t%s = %s.headers[%s];\n", flowValue(name), resVariableName, jsemit.String(textproto.CanonicalMIMEHeaderKey(extraction.Header)))
+		default:
+			code += fmt.Sprintf("\t%s = extractRegex(%s, %s);\n", flowValue(name), resVariableName, jsemit.String(extraction.Regex))
+		}
+	}
+	code += fmt.Sprintf("\tcheck(%s, {\n", resVariableName)
+	for _, name := range names {
+		code += fmt.Sprintf("\t\t%s: () => %s !== undefined,\n", jsemit.String(operationIdentifier(operationID)+"_extract_"+name+"_check"), flowValue(name))
+	}
+	return code + fmt.Sprintf("\t}%s);\n", checkTags)
+}
+
+// flowDependencies returns the variables a step reads, in name order.
+func flowDependencies(step FlowStep) []string {
+	used := make(map[string]bool)
+	for _, values := range []map[string]string{step.Path, step.Query, step.Headers, step.Cookies, step.Body} {
+		for _, variable := range values {
+			used[variable] = true
+		}
+	}
+	return sortedBoolKeys(used)
+}
+
+// guardFlowStep runs the code of a step only when the variables it reads were extracted.
+func guardFlowStep(operationID string, code string, dependencies []string) string {
+	if len(dependencies) == 0 {
+		return code
+	}
+	conditions := make([]string, 0, len(dependencies))
+	for _, variable := range dependencies {
+		conditions = append(conditions, flowValue(variable)+" !== undefined")
+	}
+
+	guarded := fmt.Sprintf("\n\t// %s runs only when the values it reads were extracted\n", jsemit.Comment(operationID))
+	guarded += fmt.Sprintf("\tif (%s) {", strings.Join(conditions, " && "))
+	for _, line := range strings.Split(strings.Trim(code, "\n"), "\n") {
+		if line == "" {
+			guarded += "\n"
+			continue
+		}
+		guarded += "\n\t" + line
+	}
+	return guarded + "\n\t}\n"
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+)
+
+// generatorConfigFileName is the optional generator settings file at the root of the fitness folder.
+const generatorConfigFileName = "k6-generator.yaml"
+
+// GeneratorConfig holds the optional settings read from fitness/k6-generator.yaml.
+type GeneratorConfig struct {
+	ResponseChecks ResponseChecksConfig `yaml:"responseChecks"`
+	Environments   EnvironmentSelection `yaml:"environments"`
+	// Servers chooses the server per environment folder name
+	Servers map[string]ServerOverride `yaml:"servers"`
+	EnvRefs EnvRefsConfig             `yaml:"envRefs"`
+	Links   LinksConfig               `yaml:"links"`
+	// Operations filters the operations that are validated and load tested
+	Operations OperationSelection `yaml:"operations"`
+}
+
+// ResponseChecksConfig controls which response contract checks are emitted in generated scripts.
+type ResponseChecksConfig struct {
+	// Schema adds checks for required fields and property types compiled from the response schema
+	Schema bool `yaml:"schema"`
+}
+
+// generatorConfig is loaded once per run by ValidateSwaggerAndFiles.
+var generatorConfig GeneratorConfig
+
+// environmentFlags holds the --env, --env-include, --env-exclude and --all-envs settings,
+// which override the environments section of the config file.
+var environmentFlags EnvironmentSelection
+
+// loadGeneratorConfig reads fitness/k6-generator.yaml; a missing file yields the default settings.
+// Unknown keys are errors, so a misspelt setting is not silently ignored.
+func loadGeneratorConfig(fitnessPath string) (GeneratorConfig, error) {
+	var config GeneratorConfig
+
+	configFilePath := filepath.Join(fitnessPath, generatorConfigFileName)
+	if !fileExists(configFilePath) {
+		return config, nil
+	}
+
+	content, err := readFileContent(configFilePath)
+	if err != nil {
+		return config, err
+	}
+	if err := yaml.UnmarshalStrict([]byte(content), &config); err != nil {
+		return config, fmt.Errorf("error parsing %s: %w", generatorConfigFileName, err)
+	}
+
+	fmt.Println("Loaded generator settings from", generatorConfigFileName)
+	return config, nil
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadGeneratorConfigRejectsUnknownKeys(t *testing.T) {
+	cases := []struct {
+		name    string
+		content string
+		valid   bool
+	}{
+		{"known keys", "environments:\n  mode: all\n  exclude: [tmp*]\n", true},
+		{"misspelt section", "enviroments:\n  mode: all\n", false},
+		{"misspelt environments key", "environments:\n  exlude: [tmp*]\n", false},
+		{"misspelt servers key", "servers:\n  dev:\n    ulr: https://example.com\n", false},
+		{"misspelt operations key", "operations:\n  tag: [orders]\n", false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fitnessPath := t.TempDir()
+			if err := os.WriteFile(filepath.Join(fitnessPath, generatorConfigFileName), []byte(c.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := loadGeneratorConfig(fitnessPath); (err == nil) != c.valid {
+				t.Fatalf("loadGeneratorConfig error = %v, want valid %v", err, c.valid)
+			}
+		})
+	}
+}
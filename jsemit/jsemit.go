@@ -0,0 +1,213 @@
+// Package jsemit writes the parts of generated k6 scripts that carry values from specs, config and
+// fitness files. Quotes, backticks, ${, slashes and line breaks in those values stay data: every
+// literal it returns is well-formed JavaScript whatever the input, and names that must be
+// identifiers are checked or made into one.
+package jsemit
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+var identifierInvalidChars = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// reservedWords cannot be used as variable names, in strict mode included.
+var reservedWords = map[string]bool{
+	"await": true, "break": true, "case": true, "catch": true, "class": true, "const": true,
+	"continue": true, "debugger": true, "default": true, "delete": true, "do": true, "else": true,
+	"enum": true, "export": true, "extends": true, "false": true, "finally": true, "for": true,
+	"function": true, "if": true, "implements": true, "import": true, "in": true, "instanceof": true,
+	"interface": true, "let": true, "new": true, "null": true, "package": true, "private": true,
+	"protected": true, "public": true, "return": true, "static": true, "super": true, "switch": true,
+	"this": true, "throw": true, "true": true, "try": true, "typeof": true, "var": true, "void": true,
+	"while": true, "with": true, "yield": true, "arguments": true, "eval": true, "undefined": true,
+	"NaN": true, "Infinity": true,
+}
+
+// String returns value as a single-quoted string literal.
+func String(value string) string {
+	var builder strings.Builder
+	builder.WriteByte('\'')
+	for _, r := range value {
+		switch r {
+		case '\\':
+			builder.WriteString(`\\`)
+		case '\'':
+			builder.WriteString(`\'`)
+		default:
+			writeEscapedRune(&builder, r)
+		}
+	}
+	builder.WriteByte('\'')
+	return builder.String()
+}
+
+// StringArray returns the values as an array of string literals.
+func StringArray(values []string) string {
+	literals := make([]string, 0, len(values))
+	for _, value := range values {
+		literals = append(literals, String(value))
+	}
+	return "[" + strings.Join(literals, ", ") + "]"
+}
+
+// Template returns value as a template literal on a single line that interpolates nothing.
+func Template(value string) string {
+	return "`" + TemplateText(value) + "`"
+}
+
+// TemplateText escapes value for the text between the interpolations of a template literal.
+func TemplateText(value string) string {
+	var builder strings.Builder
+	for index, r := range value {
+		switch {
+		case r == '\\':
+			builder.WriteString(`\\`)
+		case r == '`':
+			builder.WriteString("\\`")
+		case r == '$' && strings.HasPrefix(value[index+1:], "{"):
+			builder.WriteString(`\$`)
+		default:
+			writeEscapedRune(&builder, r)
+		}
+	}
+	return builder.String()
+}
+
+// regexFlags are the flags a regular expression literal accepts.
+const regexFlags = "dgimsuy"
+
+// Regex returns pattern as a regular expression literal with the given flags, with slashes and line
+// breaks escaped. Unknown or repeated flags and a pattern JavaScript rejects, such as an open group, a
+// quantifier with nothing to repeat or a class range out of order, are errors, since the script would
+// not load.
+func Regex(pattern string, flags string) (string, error) {
+	for index, flag := range flags {
+		if !strings.ContainsRune(regexFlags, flag) {
+			return "", fmt.Errorf("invalid regular expression flag %q", flag)
+		}
+		if strings.ContainsRune(flags[:index], flag) {
+			return "", fmt.Errorf("duplicate regular expression flag %q", flag)
+		}
+	}
+	if err := checkRegexPattern(pattern, strings.ContainsRune(flags, 'u')); err != nil {
+		return "", fmt.Errorf("regular expression %q: %w", pattern, err)
+	}
+	if pattern == "" {
+		return "/(?:)/" + flags, nil
+	}
+
+	var builder strings.Builder
+	builder.WriteByte('/')
+	escaped := false
+	for _, r := range pattern {
+		if escaped {
+			escaped = false
+			// An escaped line break becomes the escape sequence matching it
+			switch r {
+			case '\n':
+				builder.WriteByte('n')
+			case '\r':
+				builder.WriteByte('r')
+			case '\u2028', '\u2029':
+				builder.WriteString(fmt.Sprintf("u%04x", r))
+			default:
+				builder.WriteRune(r)
+			}
+			continue
+		}
+		switch r {
+		case '\\':
+			escaped = true
+			builder.WriteByte('\\')
+		case '/':
+			builder.WriteString(`\/`)
+		default:
+			writeEscapedRune(&builder, r)
+		}
+	}
+	builder.WriteByte('/')
+	return builder.String() + flags, nil
+}
+
+// Comment returns text for a comment, with line breaks turned into spaces and */ split so it cannot
+// end a line or block comment early.
+func Comment(text string) string {
+	return strings.ReplaceAll(strings.Map(func(r rune) rune {
+		if isLineTerminator(r) {
+			return ' '
+		}
+		return r
+	}, text), "*/", "* /")
+}
+
+// IsIdentifier reports whether name can be used as a variable name.
+func IsIdentifier(name string) bool {
+	return identifierPattern.MatchString(name) && !reservedWords[name]
+}
+
+// CheckIdentifier returns an error when name cannot be used as a variable name.
+func CheckIdentifier(name string) error {
+	if !IsIdentifier(name) {
+		return fmt.Errorf("%q is not a JavaScript identifier", name)
+	}
+	return nil
+}
+
+// Identifier makes name into a variable name: runs of other characters than letters, digits and _
+// become _, and names that start with a digit, are empty or are reserved words get a leading _.
+func Identifier(name string) string {
+	converted := identifierInvalidChars.ReplaceAllString(name, "_")
+	if converted == "" || (converted[0] >= '0' && converted[0] <= '9') || reservedWords[converted] {
+		converted = "_" + converted
+	}
+	return converted
+}
+
+// Property returns name as an object literal key: bare when it is an identifier, quoted otherwise.
+func Property(name string) string {
+	if IsIdentifier(name) {
+		return name
+	}
+	return String(name)
+}
+
+// Member returns the expression reading property name of expr, with dot notation for identifiers
+// and brackets for array indexes and other names.
+func Member(expr string, name string) string {
+	if IsIdentifier(name) {
+		return expr + "." + name
+	}
+	if index, err := strconv.Atoi(name); err == nil && index >= 0 && strconv.Itoa(index) == name {
+		return fmt.Sprintf("%s[%d]", expr, index)
+	}
+	return expr + "[" + String(name) + "]"
+}
+
+func isLineTerminator(r rune) bool {
+	return r == '\n' || r == '\r' || r == '\u2028' || r == '\u2029'
+}
+
+// writeEscapedRune writes r, escaping the characters no string or regular expression literal may
+// hold as they are.
+func writeEscapedRune(builder *strings.Builder, r rune) {
+	switch {
+	case r == '\n':
+		builder.WriteString(`\n`)
+	case r == '\r':
+		builder.WriteString(`\r`)
+	case r == '\t':
+		builder.WriteRune(r)
+	case r < 0x20 || r == 0x7f:
+		builder.WriteString(fmt.Sprintf(`\x%02x`, r))
+	case r == '\u2028' || r == '\u2029' || r == utf8.RuneError:
+		builder.WriteString(fmt.Sprintf(`\u%04x`, r))
+	default:
+		builder.WriteRune(r)
+	}
+}
@@ -0,0 +1,335 @@
+package jsemit
+
+import (
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// hostileValues look like what specs, config and fitness files may carry into a script.
+var hostileValues = []string{
+	"",
+	"getUser",
+	"get-user",
+	"delete",
+	"123abc",
+	"O'Brien",
+	`say "hi"`,
+	"line1\nline2",
+	"crlf\r\nend",
+	"a b c",
+	"`${__ENV.SECRET}`",
+	"${",
+	"$${}",
+	"*/ throw new Error() /*",
+	"/users/{id}",
+	`trailing\`,
+	`\`,
+	"[abc",
+	"[/]",
+	"*abc",
+	"tab\there",
+	"nul\x00bel\x07",
+	"\xff\xfe",
+	"emoji 🚀",
+}
+
+func FuzzString(f *testing.F) {
+	for _, value := range hostileValues {
+		f.Add(value)
+	}
+	f.Fuzz(func(t *testing.T, value string) {
+		literal := String(value)
+		checkQuotedToken(t, literal, '\'')
+		if utf8.ValidString(value) {
+			if decoded := decodeEscapes(t, literal[1:len(literal)-1]); decoded != value {
+				t.Fatalf("%s decodes to %q, want %q", literal, decoded, value)
+			}
+		}
+	})
+}
+
+func FuzzTemplate(f *testing.F) {
+	for _, value := range hostileValues {
+		f.Add(value)
+	}
+	f.Fuzz(func(t *testing.T, value string) {
+		literal := Template(value)
+		checkQuotedToken(t, literal, '`')
+		if utf8.ValidString(value) {
+			if decoded := decodeEscapes(t, literal[1:len(literal)-1]); decoded != value {
+				t.Fatalf("%s decodes to %q, want %q", literal, decoded, value)
+			}
+		}
+	})
+}
+
+func FuzzRegex(f *testing.F) {
+	for _, value := range hostileValues {
+		f.Add(value, "")
+	}
+	f.Add("a+", "gi")
+	f.Add("a+", "gg")
+	f.Add("a+", "x")
+	f.Add("a+", "dgimsuy")
+	f.Fuzz(func(t *testing.T, pattern string, flags string) {
+		literal, err := Regex(pattern, flags)
+		if err != nil {
+			return
+		}
+		checkRegexToken(t, literal, flags)
+	})
+}
+
+func FuzzComment(f *testing.F) {
+	for _, value := range hostileValues {
+		f.Add(value)
+	}
+	f.Fuzz(func(t *testing.T, text string) {
+		comment := Comment(text)
+		if index := strings.IndexFunc(comment, isLineTerminator); index >= 0 {
+			t.Fatalf("%q keeps a line break at %d", comment, index)
+		}
+		if strings.Contains(comment, "*/") {
+			t.Fatalf("%q can close a block comment", comment)
+		}
+	})
+}
+
+func FuzzIdentifier(f *testing.F) {
+	for _, value := range hostileValues {
+		f.Add(value)
+	}
+	f.Fuzz(func(t *testing.T, name string) {
+		identifier := Identifier(name)
+		if !IsIdentifier(identifier) {
+			t.Fatalf("Identifier(%q) = %q, which is not an identifier", name, identifier)
+		}
+	})
+}
+
+func TestRegexFlags(t *testing.T) {
+	cases := []struct {
+		flags string
+		valid bool
+	}{
+		{"", true},
+		{"gi", true},
+		{"dgimsuy", true},
+		{"gg", false},
+		{"x", false},
+		{"G", false},
+		{"g/", false},
+	}
+	for _, c := range cases {
+		_, err := Regex("a+", c.flags)
+		if (err == nil) != c.valid {
+			t.Errorf("Regex(%q, %q) error = %v, want valid %v", "a+", c.flags, err, c.valid)
+		}
+	}
+}
+
+// regexSyntaxCases pair patterns with whether JavaScript accepts them under the given flags.
+var regexSyntaxCases = []struct {
+	pattern string
+	flags   string
+	valid   bool
+}{
+	{"", "", true},
+	{"a+b*?c{2,3}", "g", true},
+	{"(", "", false},
+	{")", "", false},
+	{"(a|b", "", false},
+	{"(?:a)(?=b)(?!c)(?<=d)(?<!e)", "", true},
+	{"(?x)", "", false},
+	{"(?<id>\\d+)-\\k<id>", "", true},
+	{"(?<id>a)(?<id>b)", "", false},
+	{"(?<1a>a)", "", false},
+	{"\\k<id>", "", true},
+	{"(?<id>a)\\k<other>", "", false},
+	{"*abc", "", false},
+	{"a**", "", false},
+	{"a|*", "", false},
+	{"(*)", "", false},
+	{"^*", "", false},
+	{"\\b+", "", false},
+	{"(?<=a)?", "", false},
+	{"(?=a)?", "", true},
+	{"(?=a)?", "u", false},
+	{"a{2,1}", "", false},
+	{"a{1,2}", "", true},
+	{"a{2}{3}", "", false},
+	{"{1}", "", false},
+	{"a{", "", true},
+	{"a{,5}", "", true},
+	{"a{", "u", false},
+	{"}]", "", true},
+	{"]", "u", false},
+	{"[b-a]", "", false},
+	{"[a-b]", "", true},
+	{"[\\x62-\\x61]", "", false},
+	{"[\\d-z]", "", true},
+	{"[\\d-z]", "u", false},
+	{"[a-]", "", true},
+	{"[abc", "", false},
+	{"[/]", "", true},
+	{"[\\]]", "", true},
+	{"a/b", "", true},
+	{"trailing\\", "", false},
+	{"\\", "", false},
+	{"\\c1", "", true},
+	{"\\c1", "u", false},
+	{"[\\c_]", "", true},
+	{"\\q", "", true},
+	{"\\q", "u", false},
+	{"\\x4", "", true},
+	{"\\x4", "u", false},
+	{"\\u{1F680}", "u", true},
+	{"\\u{110000}", "u", false},
+	{"\\2(a)", "", true},
+	{"\\2(a)", "u", false},
+	{"\\1(a)", "u", true},
+	{"\\00", "", true},
+	{"\\00", "u", false},
+	{"line1\nline2", "", true},
+	{"\\\nend", "", true},
+	{"${", "", true},
+	{"$${}", "", true},
+	{"/users/{id}", "", true},
+	{"*/ throw new Error() /*", "", false},
+}
+
+func TestRegexSyntax(t *testing.T) {
+	var literals []string
+	for _, c := range regexSyntaxCases {
+		literal, err := Regex(c.pattern, c.flags)
+		if (err == nil) != c.valid {
+			t.Errorf("Regex(%q, %q) error = %v, want valid %v", c.pattern, c.flags, err, c.valid)
+			continue
+		}
+		if err == nil {
+			checkRegexToken(t, literal, c.flags)
+			literals = append(literals, literal)
+		}
+	}
+
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("node is not installed, the literals are not parsed")
+	}
+	// Every accepted literal must load, and the engine must reject every pattern Regex rejects
+	script := "const valid = [" + strings.Join(literals, ", ") + "];\n"
+	for _, c := range regexSyntaxCases {
+		if !c.valid {
+			script += "try { new RegExp(" + String(c.pattern) + ", " + String(c.flags) + "); throw new Error('accepted ' + " + String(c.pattern) + "); } catch (e) { if (!(e instanceof SyntaxError)) throw e; }\n"
+		}
+	}
+	if output, err := exec.Command(node, "-e", script).CombinedOutput(); err != nil {
+		t.Fatalf("node rejected the script: %v\n%s", err, output)
+	}
+}
+
+// checkQuotedToken fails unless literal is a single string or template token delimited by quote,
+// on one line, with every quote, backtick or ${ inside it escaped.
+func checkQuotedToken(t *testing.T, literal string, quote byte) {
+	t.Helper()
+	if len(literal) < 2 || literal[0] != quote || literal[len(literal)-1] != quote {
+		t.Fatalf("%q is not delimited by %c", literal, quote)
+	}
+	if index := strings.IndexFunc(literal, isLineTerminator); index >= 0 {
+		t.Fatalf("%q holds a line break at %d", literal, index)
+	}
+	body := literal[1 : len(literal)-1]
+	for i := 0; i < len(body); i++ {
+		switch {
+		case body[i] == '\\':
+			if i == len(body)-1 {
+				t.Fatalf("%q escapes its closing %c", literal, quote)
+			}
+			i++
+		case body[i] == quote:
+			t.Fatalf("%q ends at %d, before its closing %c", literal, i+1, quote)
+		case quote == '`' && body[i] == '$' && i+1 < len(body) && body[i+1] == '{':
+			t.Fatalf("%q interpolates at %d", literal, i+1)
+		}
+	}
+}
+
+// checkRegexToken fails unless literal is a single regular expression token with the given flags,
+// read the way a JavaScript lexer reads one.
+func checkRegexToken(t *testing.T, literal string, flags string) {
+	t.Helper()
+	if !strings.HasSuffix(literal, flags) {
+		t.Fatalf("%q does not end with flags %q", literal, flags)
+	}
+	for index, flag := range flags {
+		if !strings.ContainsRune(regexFlags, flag) || strings.ContainsRune(flags[:index], flag) {
+			t.Fatalf("Regex accepted flags %q", flags)
+		}
+	}
+	if index := strings.IndexFunc(literal, isLineTerminator); index >= 0 {
+		t.Fatalf("%q holds a line break at %d", literal, index)
+	}
+
+	body := strings.TrimSuffix(literal, flags)
+	if len(body) < 3 || body[0] != '/' || body[len(body)-1] != '/' {
+		t.Fatalf("%q is not delimited by slashes", literal)
+	}
+	if body[1] == '*' || body[1] == '/' {
+		t.Fatalf("%q starts a comment", literal)
+	}
+	inClass := false
+	for i := 1; i < len(body)-1; i++ {
+		switch {
+		case body[i] == '\\':
+			if i == len(body)-2 {
+				t.Fatalf("%q escapes its closing slash", literal)
+			}
+			i++
+		case body[i] == '[':
+			inClass = true
+		case body[i] == ']':
+			inClass = false
+		case body[i] == '/' && !inClass:
+			t.Fatalf("%q ends at %d, before its closing slash", literal, i)
+		}
+	}
+	if inClass {
+		t.Fatalf("%q reads its closing slash as part of a character class", literal)
+	}
+}
+
+// decodeEscapes reads the body of a string or template literal back into the value it holds.
+func decodeEscapes(t *testing.T, body string) string {
+	t.Helper()
+	var builder strings.Builder
+	for i := 0; i < len(body); i++ {
+		if body[i] != '\\' {
+			builder.WriteByte(body[i])
+			continue
+		}
+		i++
+		switch body[i] {
+		case 'n':
+			builder.WriteByte('\n')
+		case 'r':
+			builder.WriteByte('\r')
+		case 'x', 'u':
+			size := 2
+			if body[i] == 'u' {
+				size = 4
+			}
+			code, err := strconv.ParseUint(body[i+1:i+1+size], 16, 32)
+			if err != nil {
+				t.Fatalf("bad escape in %q: %v", body, err)
+			}
+			builder.WriteRune(rune(code))
+			i += size
+		default:
+			builder.WriteByte(body[i])
+		}
+	}
+	return builder.String()
+}
@@ -0,0 +1,532 @@
+package jsemit
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// regexParser checks a pattern against the ECMAScript RegExp grammar: the web compatible one of Annex B
+// without the u flag, the strict one with it. It only reports whether the pattern is valid.
+type regexParser struct {
+	pattern    []rune
+	position   int
+	unicode    bool
+	groupCount int
+	groupNames map[string]bool
+}
+
+// checkRegexPattern returns an error when pattern would be rejected as the source of a regular
+// expression literal, the way a JavaScript engine rejects it when loading the script.
+func checkRegexPattern(pattern string, unicodeMode bool) error {
+	parser := &regexParser{pattern: []rune(pattern), unicode: unicodeMode}
+	if err := parser.countGroups(); err != nil {
+		return err
+	}
+	if err := parser.disjunction(); err != nil {
+		return err
+	}
+	if !parser.done() {
+		// Only an unmatched ) stops a top level disjunction early
+		return parser.errorf("unmatched )")
+	}
+	return nil
+}
+
+func (p *regexParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid regular expression at %d: %s", p.position, fmt.Sprintf(format, args...))
+}
+
+func (p *regexParser) done() bool {
+	return p.position >= len(p.pattern)
+}
+
+func (p *regexParser) peek(offset int) rune {
+	if p.position+offset >= len(p.pattern) {
+		return -1
+	}
+	return p.pattern[p.position+offset]
+}
+
+func (p *regexParser) lookingAt(text string) bool {
+	for i, r := range []rune(text) {
+		if p.peek(i) != r {
+			return false
+		}
+	}
+	return true
+}
+
+// countGroups counts the capturing groups and collects their names, which back references may use
+// before the group appears.
+func (p *regexParser) countGroups() error {
+	p.groupNames = make(map[string]bool)
+	inClass := false
+	for i := 0; i < len(p.pattern); i++ {
+		switch r := p.pattern[i]; {
+		case r == '\\':
+			i++
+		case inClass:
+			inClass = r != ']'
+		case r == '[':
+			inClass = true
+		case r == '(':
+			if i+1 < len(p.pattern) && p.pattern[i+1] == '?' {
+				if i+2 >= len(p.pattern) || p.pattern[i+2] != '<' || (i+3 < len(p.pattern) && (p.pattern[i+3] == '=' || p.pattern[i+3] == '!')) {
+					continue
+				}
+				end := i + 3
+				for end < len(p.pattern) && p.pattern[end] != '>' {
+					end++
+				}
+				name := string(p.pattern[i+3 : minInt(end, len(p.pattern))])
+				if p.groupNames[name] {
+					p.position = i
+					return p.errorf("duplicate group name %s", name)
+				}
+				p.groupNames[name] = true
+			}
+			p.groupCount++
+		}
+	}
+	return nil
+}
+
+func (p *regexParser) disjunction() error {
+	for {
+		if err := p.alternative(); err != nil {
+			return err
+		}
+		if p.peek(0) != '|' {
+			return nil
+		}
+		p.position++
+	}
+}
+
+func (p *regexParser) alternative() error {
+	for !p.done() && p.peek(0) != '|' && p.peek(0) != ')' {
+		if err := p.term(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *regexParser) term() error {
+	quantifiable := true
+	switch r := p.peek(0); {
+	case r == '^' || r == '$':
+		p.position++
+		quantifiable = false
+	case r == '\\' && (p.peek(1) == 'b' || p.peek(1) == 'B'):
+		p.position += 2
+		quantifiable = false
+	case r == '(':
+		lookbehind := p.lookingAt("(?<=") || p.lookingAt("(?<!")
+		lookahead := p.lookingAt("(?=") || p.lookingAt("(?!")
+		if err := p.group(); err != nil {
+			return err
+		}
+		// Annex B lets a lookahead be quantified, but never a lookbehind
+		quantifiable = !lookbehind && !(lookahead && p.unicode)
+	case r == '*' || r == '+' || r == '?':
+		return p.errorf("nothing to repeat")
+	case r == '{':
+		if p.unicode {
+			return p.errorf("lone {")
+		}
+		if _, ok, err := p.bracedQuantifier(); err != nil {
+			return err
+		} else if ok {
+			return p.errorf("nothing to repeat")
+		}
+		p.position++
+	case r == '}' || r == ']':
+		if p.unicode {
+			return p.errorf("lone %c", r)
+		}
+		p.position++
+	case r == '[':
+		if err := p.class(); err != nil {
+			return err
+		}
+	case r == '\\':
+		if err := p.atomEscape(); err != nil {
+			return err
+		}
+	default:
+		p.position++
+	}
+	return p.quantifier(quantifiable)
+}
+
+// quantifier reads an optional quantifier after a term.
+func (p *regexParser) quantifier(quantifiable bool) error {
+	switch p.peek(0) {
+	case '*', '+', '?':
+		p.position++
+	case '{':
+		length, ok, err := p.bracedQuantifier()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			if p.unicode {
+				return p.errorf("incomplete quantifier")
+			}
+			return nil
+		}
+		p.position += length
+	default:
+		return nil
+	}
+	if !quantifiable {
+		return p.errorf("nothing to repeat")
+	}
+	if p.peek(0) == '?' {
+		p.position++
+	}
+	return nil
+}
+
+// bracedQuantifier reads {n}, {n,} or {n,m} at the current position without consuming it. ok is false
+// when the brace does not start a quantifier.
+func (p *regexParser) bracedQuantifier() (int, bool, error) {
+	i := 1
+	digits := func() string {
+		start := i
+		for p.peek(i) >= '0' && p.peek(i) <= '9' {
+			i++
+		}
+		return string(p.pattern[p.position+start : p.position+i])
+	}
+	minimum := digits()
+	if minimum == "" {
+		return 0, false, nil
+	}
+	maximum := minimum
+	if p.peek(i) == ',' {
+		i++
+		maximum = digits()
+	}
+	if p.peek(i) != '}' {
+		return 0, false, nil
+	}
+	if maximum != "" && compareDecimal(minimum, maximum) > 0 {
+		return 0, false, p.errorf("numbers out of order in {} quantifier")
+	}
+	return i + 1, true, nil
+}
+
+func (p *regexParser) group() error {
+	start := p.position
+	p.position++
+	if p.peek(0) == '?' {
+		switch {
+		case p.lookingAt("?:"), p.lookingAt("?="), p.lookingAt("?!"):
+			p.position += 2
+		case p.lookingAt("?<="), p.lookingAt("?<!"):
+			p.position += 3
+		case p.lookingAt("?<"):
+			p.position += 2
+			if err := p.groupName(); err != nil {
+				return err
+			}
+		default:
+			return p.errorf("invalid group")
+		}
+	}
+	if err := p.disjunction(); err != nil {
+		return err
+	}
+	if p.peek(0) != ')' {
+		p.position = start
+		return p.errorf("unterminated group")
+	}
+	p.position++
+	return nil
+}
+
+// groupName reads the name of a named group or back reference up to and including its >.
+func (p *regexParser) groupName() error {
+	start := p.position
+	for !p.done() && p.peek(0) != '>' {
+		r := p.peek(0)
+		if !(r == '$' || r == '_' || unicode.IsLetter(r) || (p.position > start && (unicode.IsDigit(r) || r == '‌' || r == '‍'))) {
+			return p.errorf("invalid group name")
+		}
+		p.position++
+	}
+	if p.done() || p.position == start {
+		return p.errorf("invalid group name")
+	}
+	p.position++
+	return nil
+}
+
+func (p *regexParser) atomEscape() error {
+	p.position++
+	r := p.peek(0)
+	switch {
+	case r == -1:
+		return p.errorf("\\ at end of pattern")
+	case r >= '1' && r <= '9':
+		start := p.position
+		number := ""
+		for p.peek(0) >= '0' && p.peek(0) <= '9' {
+			number += string(p.peek(0))
+			p.position++
+		}
+		if p.unicode && compareDecimal(number, fmt.Sprint(p.groupCount)) > 0 {
+			p.position = start
+			return p.errorf("invalid escape")
+		}
+		// Without the u flag a number above the group count is an octal escape or a digit
+		return nil
+	case r == 'k':
+		if len(p.groupNames) == 0 && !p.unicode {
+			p.position++
+			return nil
+		}
+		p.position++
+		if p.peek(0) != '<' {
+			return p.errorf("invalid named reference")
+		}
+		p.position++
+		start := p.position
+		if err := p.groupName(); err != nil {
+			return err
+		}
+		if name := string(p.pattern[start : p.position-1]); !p.groupNames[name] {
+			return p.errorf("invalid named capture referenced")
+		}
+		return nil
+	}
+	return p.characterEscape(false)
+}
+
+// characterEscape reads the escape after a backslash that is neither a back reference nor \k.
+func (p *regexParser) characterEscape(inClass bool) error {
+	r := p.peek(0)
+	switch {
+	case strings.ContainsRune("dDsSwWfnrtv", r):
+		p.position++
+	case r == 'b' && inClass:
+		p.position++
+	case r == '-' && inClass:
+		p.position++
+	case r == 'p' || r == 'P':
+		if p.unicode {
+			return p.errorf("unicode property escapes are not supported")
+		}
+		p.position++
+	case r == 'c':
+		if next := p.peek(1); isASCIILetter(next) || (inClass && !p.unicode && (next == '_' || (next >= '0' && next <= '9'))) {
+			p.position += 2
+			return nil
+		}
+		if p.unicode {
+			return p.errorf("invalid unicode escape")
+		}
+		// Annex B reads the backslash on its own and c as the next character
+	case r == '0':
+		p.position++
+		if p.unicode && p.peek(0) >= '0' && p.peek(0) <= '9' {
+			return p.errorf("invalid decimal escape")
+		}
+	case r >= '1' && r <= '9':
+		if p.unicode {
+			return p.errorf("invalid class escape")
+		}
+		p.position++
+	case r == 'x':
+		p.position++
+		if isHex(p.peek(0)) && isHex(p.peek(1)) {
+			p.position += 2
+		} else if p.unicode {
+			return p.errorf("invalid escape")
+		}
+	case r == 'u':
+		p.position++
+		if isHex(p.peek(0)) && isHex(p.peek(1)) && isHex(p.peek(2)) && isHex(p.peek(3)) {
+			p.position += 4
+		} else if p.unicode && p.peek(0) == '{' {
+			return p.codePointEscape()
+		} else if p.unicode {
+			return p.errorf("invalid unicode escape")
+		}
+	case r == 'k' && inClass && (p.unicode || len(p.groupNames) > 0):
+		return p.errorf("invalid escape")
+	default:
+		if p.unicode && !strings.ContainsRune(`^$\.*+?()[]{}|/`, r) {
+			return p.errorf("invalid escape")
+		}
+		p.position++
+	}
+	return nil
+}
+
+// codePointEscape reads the {hex} of a \u{hex} escape.
+func (p *regexParser) codePointEscape() error {
+	p.position++
+	value := 0
+	digits := 0
+	for isHex(p.peek(0)) {
+		value = value*16 + hexValue(p.peek(0))
+		if value > unicode.MaxRune {
+			return p.errorf("invalid unicode escape")
+		}
+		digits++
+		p.position++
+	}
+	if digits == 0 || p.peek(0) != '}' {
+		return p.errorf("invalid unicode escape")
+	}
+	p.position++
+	return nil
+}
+
+func (p *regexParser) class() error {
+	start := p.position
+	p.position++
+	if p.peek(0) == '^' {
+		p.position++
+	}
+	for {
+		if p.done() {
+			p.position = start
+			return p.errorf("unterminated character class")
+		}
+		if p.peek(0) == ']' {
+			p.position++
+			return nil
+		}
+		low, lowIsSet, err := p.classAtom()
+		if err != nil {
+			return err
+		}
+		if p.peek(0) != '-' || p.peek(1) == ']' || p.peek(1) == -1 {
+			continue
+		}
+		p.position++
+		high, highIsSet, err := p.classAtom()
+		if err != nil {
+			return err
+		}
+		if lowIsSet || highIsSet {
+			if p.unicode {
+				return p.errorf("invalid character class")
+			}
+			// Annex B reads the - between a class escape and another atom literally
+			continue
+		}
+		if low > high {
+			return p.errorf("range out of order in character class")
+		}
+	}
+}
+
+// classAtom reads one atom of a class, returning the character it stands for, or isSet for \d and
+// the other escapes that stand for several characters.
+func (p *regexParser) classAtom() (rune, bool, error) {
+	r := p.peek(0)
+	if r != '\\' {
+		p.position++
+		return r, false, nil
+	}
+	p.position++
+	escaped := p.peek(0)
+	if escaped == -1 {
+		return 0, false, p.errorf("\\ at end of pattern")
+	}
+	start := p.position
+	if err := p.characterEscape(true); err != nil {
+		return 0, false, err
+	}
+	if p.position == start {
+		// An Annex B \c without a control letter stands for the backslash
+		return '\\', false, nil
+	}
+	return escapedValue(p.pattern[start:p.position]), strings.ContainsRune("dDsSwWpP", escaped), nil
+}
+
+// escapedValue is the character a class escape, without its backslash, stands for.
+func escapedValue(escape []rune) rune {
+	switch escape[0] {
+	case 'b':
+		return '\b'
+	case 'f':
+		return '\f'
+	case 'n':
+		return '\n'
+	case 'r':
+		return '\r'
+	case 't':
+		return '\t'
+	case 'v':
+		return '\v'
+	case 'c':
+		return escape[1] % 32
+	case 'x', 'u':
+		if len(escape) == 1 {
+			return escape[0]
+		}
+		value := 0
+		for _, digit := range escape[1:] {
+			if digit != '{' && digit != '}' {
+				value = value*16 + hexValue(digit)
+			}
+		}
+		return rune(value)
+	}
+	if escape[0] >= '0' && escape[0] <= '7' {
+		// Legacy octal escape, up to three digits with a value below 256
+		value := 0
+		for i, digit := range escape {
+			if digit < '0' || digit > '7' || i == 3 || value*8+int(digit-'0') > 255 {
+				break
+			}
+			value = value*8 + int(digit-'0')
+		}
+		return rune(value)
+	}
+	return escape[0]
+}
+
+func isASCIILetter(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+func isHex(r rune) bool {
+	return (r >= '0' && r <= '9') || (r >= 'a' && r <= 'f') || (r >= 'A' && r <= 'F')
+}
+
+func hexValue(r rune) int {
+	switch {
+	case r >= '0' && r <= '9':
+		return int(r - '0')
+	case r >= 'a' && r <= 'f':
+		return int(r-'a') + 10
+	}
+	return int(r-'A') + 10
+}
+
+// compareDecimal compares two unsigned decimal numbers of any length.
+func compareDecimal(a string, b string) int {
+	a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+	if len(a) != len(b) {
+		if len(a) < len(b) {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
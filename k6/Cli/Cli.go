@@ -9,6 +9,8 @@ import (
 	"os"
 	"strings"
 	"text/template"
+
+	"k6-generator/jsemit"
 )
 
 // TestCase represents a single test case
@@ -63,43 +65,43 @@ export default async function () {
     const browserInstance = await browser.newPage(); // Create a new browser instance
     const page = browserInstance;
 {{range $index, $test := .TestCases}}
-    console.log('Navigating to {{$.BaseURL}}{{$test.URL}}');
-    await page.goto('{{$.BaseURL}}{{$test.URL}}');
+    console.log({{jsString "Navigating to " $.BaseURL $test.URL}});
+    await page.goto({{jsString $.BaseURL $test.URL}});
     const pageVisible_{{add $index 1}} = await page.waitForSelector('body', { state: 'visible', timeout: 5000 });
     const pageNavigated_{{add $index 1}} = check(pageVisible_{{add $index 1}}, {
-        '{{$test.Name}} navigated successfully to {{$.BaseURL}}{{$test.URL}}': (element) => element !== null
+        {{jsString $test.Name " navigated successfully to " $.BaseURL $test.URL}}: (element) => element !== null
     });
 
     const navigationScreenshotPath_{{add $index 1}} = 'screenshot_navigation_{{add $index 1}}.png';
     await page.screenshot({ fullPage: true, path: navigationScreenshotPath_{{add $index 1}} });
-    console.log(` + "`" + `Screenshot saved at: screenshot_navigation_{{add $index 1}}.png` + "`" + `);
+    console.log('Screenshot saved at: screenshot_navigation_{{add $index 1}}.png');
 
     if (!pageNavigated_{{add $index 1}}) {
         console.error('Failed to navigate to URL.');
-        check(null, { "{{$test.Name}} navigation failed to {{$.BaseURL}}{{$test.URL}}": false });
+        check(null, { {{jsString $test.Name " navigation failed to " $.BaseURL $test.URL}}: false });
         return;
     }
 
     try {
-        console.log('Checking for text: "{{$test.Text}}"...');
-        const elementText_{{add $index 1}} = await page.locator('{{$test.Selector}}').textContent();
+        console.log({{jsString "Checking for text: \"" $test.Text "\"..."}});
+        const elementText_{{add $index 1}} = await page.locator({{jsString $test.Selector}}).textContent();
         const textCheck_{{add $index 1}} = check(elementText_{{add $index 1}}, {
-            "{{$test.Name}} Text '{{$test.Text}}' is present on the page": (text) => text && text.includes('{{$test.Text}}')
+            {{jsString $test.Name " Text '" $test.Text "' is present on the page"}}: (text) => text && text.includes({{jsString $test.Text}})
         });
 
         if (!textCheck_{{add $index 1}}) {
-            console.error('Text "{{$test.Text}}" not found on the page.');
-            check(null, { '{{$test.Name}} Text "{{$test.Text}}" presence check failed': false });
+            console.error({{jsString "Text \"" $test.Text "\" not found on the page."}});
+            check(null, { {{jsString $test.Name " Text \"" $test.Text "\" presence check failed"}}: false });
         }
     } catch (error) {
-        console.error('Error while checking for text: "{{$test.Text}}"', error.message);
-        check(null, { '{{$test.Name}} Text "{{$test.Text}}" presence check failed': false });
+        console.error({{jsString "Error while checking for text: \"" $test.Text "\""}}, error.message);
+        check(null, { {{jsString $test.Name " Text \"" $test.Text "\" presence check failed"}}: false });
     }
 
-    const screenshotPath_{{add $index 1}} = 'screenshot_{{$test.Name}}_{{add $index 1}}.png';
+    const screenshotPath_{{add $index 1}} = {{jsString "screenshot_" $test.Name "_" (add $index 1) ".png"}};
     await page.screenshot({ fullPage: true, path: screenshotPath_{{add $index 1}} });
-    console.log('Screenshot saved at: screenshot_{{$test.Name}}_{{add $index 1}}.png');
-    console.log('{{$test.Name}} completed.');
+    console.log({{jsString "Screenshot saved at: screenshot_" $test.Name "_" (add $index 1) ".png"}});
+    console.log({{jsString $test.Name " completed."}});
 {{end}}
     await page.close();
 }
@@ -136,6 +138,14 @@ func createTemplate() (*template.Template, error) {
 		"add": func(a, b int) int {
 			return a + b
 		},
+		// jsString joins its arguments into one JavaScript string literal
+		"jsString": func(parts ...interface{}) string {
+			var value strings.Builder
+			for _, part := range parts {
+				value.WriteString(fmt.Sprint(part))
+			}
+			return jsemit.String(value.String())
+		},
 	}
 
 	tmpl, err := template.New("k6script").Funcs(funcMap).Parse(k6Template)
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// hostileValues look like what a config.json may carry into the script.
+var hostileValues = []string{
+	"",
+	"O'Brien",
+	`say "hi"`,
+	"line1\nline2",
+	"`${__ENV.SECRET}`",
+	"*/ throw new Error() /*",
+	`trailing\`,
+	"{{.BaseURL}}",
+	"emoji 🚀",
+}
+
+func TestGenerateScriptWithHostileValues(t *testing.T) {
+	config := &Config{BaseURL: "https://example.com/'`"}
+	for _, value := range hostileValues {
+		config.TestCases = append(config.TestCases, TestCase{Name: value, URL: value, Selector: value, Text: value})
+	}
+
+	// node only reads a script with import statements as a module from an .mjs file
+	outputFile := filepath.Join(t.TempDir(), "script.mjs")
+	if err := generateScript(config, outputFile); err != nil {
+		t.Fatal(err)
+	}
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("node is not installed, the script is not parsed")
+	}
+	if output, err := exec.Command(node, "--check", outputFile).CombinedOutput(); err != nil {
+		t.Fatalf("node rejected the script: %v\n%s", err, output)
+	}
+}
@@ -0,0 +1,470 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LinksConfig controls the flows inferred from the links of OpenAPI responses. Environments without a
+// flow.yaml chain the operations connected by links, so producers run before their consumers and pass
+// them the values the links name.
+type LinksConfig struct {
+	// Ignore turns the inference off
+	Ignore bool `yaml:"ignore"`
+	// NamingHeuristics also feeds the {resourceId} placeholder of /things/{resourceId} operations with
+	// the id returned by POST /things when no link covers it
+	NamingHeuristics bool `yaml:"namingHeuristics"`
+}
+
+// operationLink passes values from the response of a producer operation to the request of a consumer.
+type operationLink struct {
+	Producer string
+	Consumer string
+	Name     string
+	// Values maps a consumer parameter, as location and name, to the extraction reading it
+	Values map[parameterLocation]FlowExtraction
+}
+
+// parameterLocation names a parameter by where it is sent.
+type parameterLocation struct {
+	In   string
+	Name string
+}
+
+// linkedOperation locates an operation of the spec.
+type linkedOperation struct {
+	Path      string
+	Method    string
+	Operation map[string]interface{}
+	// Skipped is set for operations left out by the operation filters, whose links are not followed
+	Skipped bool
+}
+
+var linkedParameterPrefix = regexp.MustCompile(`^(path|query|header|cookie)\.(.+)$`)
+
+// specOperations indexes the operations of a spec by operationId, marking those skipped in an environment.
+func specOperations(swagger map[string]interface{}, environment string) map[string]linkedOperation {
+	operations := make(map[string]linkedOperation)
+	paths, _ := swagger["paths"].(map[string]interface{})
+	for _, path := range sortedKeys(paths) {
+		pathItem, _ := paths[path].(map[string]interface{})
+		for _, method := range sortedMethodKeys(pathItem) {
+			operation, ok := pathItem[method].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if operationID, ok := operation["operationId"].(string); ok {
+				skipped := operationSkipReason(environment, path, method, pathItem, operation) != ""
+				operations[operationID] = linkedOperation{Path: path, Method: method, Operation: operation, Skipped: skipped}
+			}
+		}
+	}
+	return operations
+}
+
+// operationRefTarget resolves a local operationRef such as #/paths/~1orders~1{id}/get to its operationId.
+func operationRefTarget(operationRef string, operations map[string]linkedOperation) (string, bool) {
+	tokens := strings.Split(strings.TrimPrefix(operationRef, "#/"), "/")
+	if !strings.HasPrefix(operationRef, "#/") || len(tokens) != 3 || tokens[0] != "paths" {
+		return "", false
+	}
+	path := strings.NewReplacer("~1", "/", "~0", "~").Replace(tokens[1])
+	for operationID, operation := range operations {
+		if operation.Path == path && strings.EqualFold(operation.Method, tokens[2]) {
+			return operationID, true
+		}
+	}
+	return "", false
+}
+
+// runtimeExpressionExtraction turns a $response.body#/pointer or $response.header.Name runtime
+// expression into an extraction.
+func runtimeExpressionExtraction(expression string) (FlowExtraction, error) {
+	switch {
+	case strings.HasPrefix(expression, "$response.body#"):
+		pointer := strings.TrimPrefix(expression, "$response.body#")
+		if pointer != "" && !strings.HasPrefix(pointer, "/") {
+			return FlowExtraction{}, fmt.Errorf("invalid JSON pointer in %s", expression)
+		}
+		jsonPath := "$"
+		if pointer != "" {
+			for _, token := range strings.Split(pointer[1:], "/") {
+				token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+				if _, err := strconv.Atoi(token); err == nil {
+					jsonPath += "[" + token + "]"
+					continue
+				}
+				if strings.ContainsAny(token, "'\"[]") {
+					return FlowExtraction{}, fmt.Errorf("unsupported property name %q in %s", token, expression)
+				}
+				jsonPath += "['" + token + "']"
+			}
+		}
+		return FlowExtraction{Body: jsonPath}, nil
+
+	case strings.HasPrefix(expression, "$response.header."):
+		return FlowExtraction{Header: strings.TrimPrefix(expression, "$response.header.")}, nil
+	}
+	return FlowExtraction{}, fmt.Errorf("unsupported runtime expression %s", expression)
+}
+
+// linkedParameter finds the consumer parameter a link names, either qualified as path.id or by name alone.
+func linkedParameter(key string, specs []map[string]interface{}) (parameterLocation, bool) {
+	if match := linkedParameterPrefix.FindStringSubmatch(key); match != nil {
+		if findParameterSpec(specs, match[2], match[1]) != nil {
+			return parameterLocation{In: match[1], Name: match[2]}, true
+		}
+	}
+	for _, in := range []string{"path", "query", "header", "cookie"} {
+		if findParameterSpec(specs, key, in) != nil {
+			return parameterLocation{In: in, Name: key}, true
+		}
+	}
+	return parameterLocation{}, false
+}
+
+// collectOperationLinks reads the links of the success responses of every operation. Links whose target
+// or values cannot be used are reported as warnings.
+func collectOperationLinks(swagger map[string]interface{}, operations map[string]linkedOperation) ([]operationLink, []string) {
+	var links []operationLink
+	var warnings []string
+
+	producerIDs := make([]string, 0, len(operations))
+	for operationID := range operations {
+		producerIDs = append(producerIDs, operationID)
+	}
+	sort.Strings(producerIDs)
+
+	for _, producer := range producerIDs {
+		if operations[producer].Skipped {
+			continue
+		}
+		responses, _ := operations[producer].Operation["responses"].(map[string]interface{})
+		for _, code := range sortedKeys(responses) {
+			if !strings.HasPrefix(code, "2") {
+				continue
+			}
+			response, _ := responses[code].(map[string]interface{})
+			responseLinks, _ := response["links"].(map[string]interface{})
+			for _, name := range sortedKeys(responseLinks) {
+				link, _ := responseLinks[name].(map[string]interface{})
+				prefix := fmt.Sprintf("%s response %s link %s", producer, code, name)
+
+				consumer, _ := link["operationId"].(string)
+				if operationRef, ok := link["operationRef"].(string); ok && consumer == "" {
+					consumer, _ = operationRefTarget(operationRef, operations)
+				}
+				target, ok := operations[consumer]
+				if !ok {
+					warnings = append(warnings, prefix+": target operation not found")
+					continue
+				}
+				if consumer == producer || target.Skipped {
+					continue
+				}
+
+				specs := operationParameterSpecs(swagger, target.Path, target.Method)
+				values := make(map[parameterLocation]FlowExtraction)
+				parameters, _ := link["parameters"].(map[string]interface{})
+				for _, key := range sortedKeys(parameters) {
+					location, ok := linkedParameter(key, specs)
+					if !ok {
+						warnings = append(warnings, fmt.Sprintf("%s: %s has no parameter %s", prefix, consumer, key))
+						continue
+					}
+					expression, _ := parameters[key].(string)
+					extraction, err := runtimeExpressionExtraction(expression)
+					if err != nil {
+						warnings = append(warnings, fmt.Sprintf("%s: %v", prefix, err))
+						continue
+					}
+					values[location] = extraction
+				}
+				if _, ok := link["requestBody"]; ok {
+					warnings = append(warnings, prefix+": requestBody values are not passed")
+				}
+				if len(values) > 0 {
+					links = append(links, operationLink{Producer: producer, Consumer: consumer, Name: name, Values: values})
+				}
+			}
+		}
+	}
+	return links, warnings
+}
+
+// heuristicLinks feeds the unlinked {resourceId} placeholder of /things/{resourceId} operations from
+// the response of POST /things, reading the property named like the placeholder, or id.
+func heuristicLinks(operations map[string]linkedOperation, links []operationLink) []operationLink {
+	linked := make(map[string]map[string]bool)
+	for _, link := range links {
+		for location := range link.Values {
+			if location.In == "path" {
+				if linked[link.Consumer] == nil {
+					linked[link.Consumer] = make(map[string]bool)
+				}
+				linked[link.Consumer][location.Name] = true
+			}
+		}
+	}
+
+	creators := make(map[string]string)
+	for operationID, operation := range operations {
+		if strings.EqualFold(operation.Method, "post") && !operation.Skipped {
+			creators[strings.TrimRight(operation.Path, "/")] = operationID
+		}
+	}
+
+	var inferred []operationLink
+	consumers := make([]string, 0, len(operations))
+	for operationID, operation := range operations {
+		if !operation.Skipped {
+			consumers = append(consumers, operationID)
+		}
+	}
+	sort.Strings(consumers)
+	for _, consumer := range consumers {
+		path := operations[consumer].Path
+		for _, match := range pathPlaceholderPattern.FindAllStringSubmatchIndex(path, -1) {
+			name := path[match[2]:match[3]]
+			if linked[consumer][name] || !strings.HasSuffix(strings.ToLower(name), "id") {
+				continue
+			}
+			producer, ok := creators[strings.TrimRight(path[:match[0]], "/")]
+			if !ok || producer == consumer {
+				continue
+			}
+
+			property := "id"
+			producerOperation := operations[producer].Operation
+			if schema := responseSchemaFor(producerOperation, expectedStatusCodes(producerOperation)); schema != nil {
+				if properties, ok := schema["properties"].(map[string]interface{}); ok {
+					if _, ok := properties[name]; ok {
+						property = name
+					}
+				}
+			}
+			inferred = append(inferred, operationLink{
+				Producer: producer,
+				Consumer: consumer,
+				Name:     name,
+				Values:   map[parameterLocation]FlowExtraction{{In: "path", Name: name}: {Body: "$['" + property + "']"}},
+			})
+		}
+	}
+	return inferred
+}
+
+// orderLinkedOperations sorts the linked operations so producers come before consumers, deletes running
+// after the other operations the links leave unordered. Links closing a cycle are dropped and reported.
+func orderLinkedOperations(links []operationLink, operations map[string]linkedOperation) ([]string, []operationLink, []string) {
+	consumersOf := make(map[string][]string)
+	nodes := make(map[string]bool)
+	for _, link := range links {
+		nodes[link.Producer] = true
+		nodes[link.Consumer] = true
+		if !containsString(consumersOf[link.Producer], link.Consumer) {
+			consumersOf[link.Producer] = append(consumersOf[link.Producer], link.Consumer)
+		}
+	}
+	for producer := range consumersOf {
+		sort.Strings(consumersOf[producer])
+	}
+
+	// A depth-first search finds the links pointing back into the current chain
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int)
+	backEdges := make(map[[2]string]bool)
+	var warnings []string
+	var stack []string
+	var visit func(operationID string)
+	visit = func(operationID string) {
+		state[operationID] = visiting
+		stack = append(stack, operationID)
+		for _, consumer := range consumersOf[operationID] {
+			switch state[consumer] {
+			case unvisited:
+				visit(consumer)
+			case visiting:
+				backEdges[[2]string{operationID, consumer}] = true
+				start := 0
+				for i, id := range stack {
+					if id == consumer {
+						start = i
+					}
+				}
+				cycle := append(append([]string{}, stack[start:]...), consumer)
+				warnings = append(warnings, fmt.Sprintf("links form a cycle %s; the link from %s to %s is ignored", strings.Join(cycle, " -> "), operationID, consumer))
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[operationID] = done
+	}
+	// Starting from the operations no link feeds keeps the links of a chain and drops the one closing it
+	consumed := make(map[string]bool)
+	for _, link := range links {
+		consumed[link.Consumer] = true
+	}
+	var starts, rest []string
+	for _, operationID := range sortedBoolKeys(nodes) {
+		if consumed[operationID] {
+			rest = append(rest, operationID)
+		} else {
+			starts = append(starts, operationID)
+		}
+	}
+	for _, operationID := range append(starts, rest...) {
+		if state[operationID] == unvisited {
+			visit(operationID)
+		}
+	}
+
+	var kept []operationLink
+	incoming := make(map[string]int)
+	for _, link := range links {
+		if backEdges[[2]string{link.Producer, link.Consumer}] {
+			continue
+		}
+		kept = append(kept, link)
+	}
+	edges := make(map[[2]string]bool)
+	for _, link := range kept {
+		edge := [2]string{link.Producer, link.Consumer}
+		if !edges[edge] {
+			edges[edge] = true
+			incoming[link.Consumer]++
+		}
+	}
+
+	// Kahn's algorithm, taking ready operations in name order with deletes last
+	runsBefore := func(a, b string) bool {
+		aDeletes := strings.EqualFold(operations[a].Method, "delete")
+		bDeletes := strings.EqualFold(operations[b].Method, "delete")
+		if aDeletes != bDeletes {
+			return bDeletes
+		}
+		return a < b
+	}
+	var order []string
+	ready := []string{}
+	for _, operationID := range sortedBoolKeys(nodes) {
+		if incoming[operationID] == 0 {
+			ready = append(ready, operationID)
+		}
+	}
+	sort.Slice(ready, func(i, j int) bool { return runsBefore(ready[i], ready[j]) })
+	for len(ready) > 0 {
+		operationID := ready[0]
+		ready = ready[1:]
+		order = append(order, operationID)
+		for _, consumer := range consumersOf[operationID] {
+			if !edges[[2]string{operationID, consumer}] {
+				continue
+			}
+			incoming[consumer]--
+			if incoming[consumer] == 0 {
+				ready = append(ready, consumer)
+				sort.Slice(ready, func(i, j int) bool { return runsBefore(ready[i], ready[j]) })
+			}
+		}
+	}
+	return order, kept, warnings
+}
+
+// linkedFlow builds a flow from the links: every producer extracts the values its links pass, once
+// per distinct extraction, and every consumer reads them into its parameters.
+func linkedFlow(order []string, links []operationLink) FlowConfig {
+	steps := make(map[string]*FlowStep, len(order))
+	for _, operationID := range order {
+		steps[operationID] = &FlowStep{Operation: operationID}
+	}
+
+	variables := make(map[string]map[FlowExtraction]string)
+	used := make(map[string]bool)
+	for _, link := range links {
+		producer, consumer := steps[link.Producer], steps[link.Consumer]
+		locations := make([]parameterLocation, 0, len(link.Values))
+		for location := range link.Values {
+			locations = append(locations, location)
+		}
+		sort.Slice(locations, func(i, j int) bool {
+			return locations[i].In+"."+locations[i].Name < locations[j].In+"."+locations[j].Name
+		})
+
+		for _, location := range locations {
+			extraction := link.Values[location]
+			if variables[link.Producer] == nil {
+				variables[link.Producer] = make(map[FlowExtraction]string)
+			}
+			variable, ok := variables[link.Producer][extraction]
+			if !ok {
+				base := scenarioName(link.Producer + "_" + location.Name)
+				variable = base
+				for suffix := 2; used[variable]; suffix++ {
+					variable = fmt.Sprintf("%s_%d", base, suffix)
+				}
+				used[variable] = true
+				variables[link.Producer][extraction] = variable
+				if producer.Extract == nil {
+					producer.Extract = make(map[string]FlowExtraction)
+				}
+				producer.Extract[variable] = extraction
+			}
+
+			var mapping *map[string]string
+			switch location.In {
+			case "path":
+				mapping = &consumer.Path
+			case "query":
+				mapping = &consumer.Query
+			case "header":
+				mapping = &consumer.Headers
+			default:
+				mapping = &consumer.Cookies
+			}
+			if *mapping == nil {
+				*mapping = make(map[string]string)
+			}
+			(*mapping)[location.Name] = variable
+		}
+	}
+
+	config := FlowConfig{Inferred: true}
+	for _, operationID := range order {
+		config.Steps = append(config.Steps, *steps[operationID])
+	}
+	return config
+}
+
+// inferLinkedFlow chains the operations of an environment without flow.yaml through the links of
+// their responses, recording links that cannot be followed and cycles as warnings.
+func inferLinkedFlow(swagger map[string]interface{}, environment string, validationReport *ValidationReport) {
+	if validationReport.Flow != nil || generatorConfig.Links.Ignore {
+		return
+	}
+
+	operations := specOperations(swagger, environment)
+	links, warnings := collectOperationLinks(swagger, operations)
+	if generatorConfig.Links.NamingHeuristics {
+		links = append(links, heuristicLinks(operations, links)...)
+	}
+	validationReport.LinkWarnings = append(validationReport.LinkWarnings, warnings...)
+	if len(links) == 0 {
+		return
+	}
+
+	order, kept, cycles := orderLinkedOperations(links, operations)
+	validationReport.LinkWarnings = append(validationReport.LinkWarnings, cycles...)
+	if len(kept) == 0 {
+		return
+	}
+	flow := linkedFlow(order, kept)
+	fmt.Printf("Using flow inferred from OpenAPI links: %d operations\n", len(flow.Steps))
+	validationReport.Flow = &flow
+}
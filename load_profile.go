@@ -0,0 +1,342 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"k6-generator/jsemit"
+
+	"gopkg.in/yaml.v2"
+)
+
+// loadProfileFileName is the optional per-environment load profile, for example:
+//
+//	executor: ramping-arrival-rate
+//	startRate: 5
+//	timeUnit: 1s
+//	preAllocatedVUs: 20
+//	maxVUs: 100
+//	stages:
+//	  - duration: 2m
+//	    target: 50
+//	thresholds:
+//	  http_req_failed:
+//	    - rate<0.01
+//	insecureSkipTLSVerify: false
+const loadProfileFileName = "load-profile.yaml"
+
+// k6 executors supported in load profiles
+const (
+	executorConstantVUs         = "constant-vus"
+	executorRampingVUs          = "ramping-vus"
+	executorConstantArrivalRate = "constant-arrival-rate"
+	executorRampingArrivalRate  = "ramping-arrival-rate"
+	executorPerVUIterations     = "per-vu-iterations"
+	executorSharedIterations    = "shared-iterations"
+)
+
+// executorFields lists the profile fields each executor requires and the ones it also accepts.
+var executorFields = map[string]struct{ required, optional []string }{
+	executorConstantVUs:         {required: []string{"vus", "duration"}},
+	executorRampingVUs:          {required: []string{"stages"}, optional: []string{"startVUs"}},
+	executorConstantArrivalRate: {required: []string{"rate", "duration", "preAllocatedVUs"}, optional: []string{"timeUnit", "maxVUs"}},
+	executorRampingArrivalRate:  {required: []string{"stages", "preAllocatedVUs"}, optional: []string{"startRate", "timeUnit", "maxVUs"}},
+	executorPerVUIterations:     {required: []string{"vus", "iterations"}, optional: []string{"maxDuration"}},
+	executorSharedIterations:    {required: []string{"vus", "iterations"}, optional: []string{"maxDuration"}},
+}
+
+// LoadStage is one step of a ramping executor.
+type LoadStage struct {
+	Duration string `yaml:"duration" json:"duration"`
+	Target   int    `yaml:"target" json:"target"`
+}
+
+// LoadProfile describes the k6 scenario and options of the script generated for one environment.
+type LoadProfile struct {
+	Executor        string      `yaml:"executor" json:"executor"`
+	VUs             int         `yaml:"vus" json:"vus,omitempty"`
+	Duration        string      `yaml:"duration" json:"duration,omitempty"`
+	Iterations      int         `yaml:"iterations" json:"iterations,omitempty"`
+	MaxDuration     string      `yaml:"maxDuration" json:"maxDuration,omitempty"`
+	Rate            int         `yaml:"rate" json:"rate,omitempty"`
+	TimeUnit        string      `yaml:"timeUnit" json:"timeUnit,omitempty"`
+	PreAllocatedVUs int         `yaml:"preAllocatedVUs" json:"preAllocatedVUs,omitempty"`
+	MaxVUs          int         `yaml:"maxVUs" json:"maxVUs,omitempty"`
+	StartVUs        int         `yaml:"startVUs" json:"startVUs,omitempty"`
+	StartRate       int         `yaml:"startRate" json:"startRate,omitempty"`
+	Stages          []LoadStage `yaml:"stages" json:"stages,omitempty"`
+	GracefulStop    string      `yaml:"gracefulStop" json:"gracefulStop,omitempty"`
+	// Thresholds maps a k6 metric, optionally with tags, to its threshold expressions
+	Thresholds map[string][]string `yaml:"thresholds" json:"thresholds,omitempty"`
+	// Weights split the operations into scenarios that share the load
+	Weights TrafficWeights `yaml:"weights" json:"weights"`
+	// InsecureSkipTLSVerify defaults to true, as scripts often target test hosts with self-signed certificates
+	InsecureSkipTLSVerify *bool `yaml:"insecureSkipTLSVerify" json:"insecureSkipTLSVerify,omitempty"`
+}
+
+// defaultLoadProfile is the one-VU smoke test used when an environment has no load profile.
+func defaultLoadProfile() LoadProfile {
+	return LoadProfile{
+		Executor: executorRampingVUs,
+		Stages:   []LoadStage{{Duration: "1m", Target: 1}},
+	}
+}
+
+// loadLoadProfile reads load-profile.yaml from an environment folder; found is false when it does not exist.
+func loadLoadProfile(fitnessPath string) (LoadProfile, bool, error) {
+	profilePath := filepath.Join(fitnessPath, loadProfileFileName)
+	if !fileExists(profilePath) {
+		return defaultLoadProfile(), false, nil
+	}
+
+	content, err := readFileContent(profilePath)
+	if err != nil {
+		return LoadProfile{}, true, err
+	}
+
+	var profile LoadProfile
+	if err := yaml.UnmarshalStrict([]byte(content), &profile); err != nil {
+		return LoadProfile{}, true, fmt.Errorf("error parsing %s: %w", loadProfileFileName, err)
+	}
+	if profile.Executor == "" {
+		profile.Executor = executorRampingVUs
+	}
+	return profile, true, nil
+}
+
+// setFields returns the names of the executor-specific fields that have a value.
+func (profile LoadProfile) setFields() map[string]bool {
+	values := map[string]bool{
+		"vus":             profile.VUs != 0,
+		"duration":        profile.Duration != "",
+		"iterations":      profile.Iterations != 0,
+		"maxDuration":     profile.MaxDuration != "",
+		"rate":            profile.Rate != 0,
+		"timeUnit":        profile.TimeUnit != "",
+		"preAllocatedVUs": profile.PreAllocatedVUs != 0,
+		"maxVUs":          profile.MaxVUs != 0,
+		"startVUs":        profile.StartVUs != 0,
+		"startRate":       profile.StartRate != 0,
+		"stages":          len(profile.Stages) > 0,
+	}
+	set := make(map[string]bool)
+	for name, ok := range values {
+		if ok {
+			set[name] = true
+		}
+	}
+	return set
+}
+
+// validateLoadProfile lists the problems that would make k6 reject the scenario built from the profile.
+func validateLoadProfile(profile LoadProfile) []string {
+	var problems []string
+
+	fields, known := executorFields[profile.Executor]
+	if !known {
+		executors := make([]string, 0, len(executorFields))
+		for executor := range executorFields {
+			executors = append(executors, executor)
+		}
+		sort.Strings(executors)
+		return []string{fmt.Sprintf("unknown executor %q (expected one of %s)", profile.Executor, strings.Join(executors, ", "))}
+	}
+
+	set := profile.setFields()
+	accepted := make(map[string]bool)
+	for _, name := range fields.required {
+		accepted[name] = true
+		if !set[name] {
+			problems = append(problems, fmt.Sprintf("%s is required by the %s executor", name, profile.Executor))
+		}
+	}
+	for _, name := range fields.optional {
+		accepted[name] = true
+	}
+	for _, name := range sortedBoolKeys(set) {
+		if !accepted[name] {
+			problems = append(problems, fmt.Sprintf("%s is not used by the %s executor", name, profile.Executor))
+		}
+	}
+
+	for name, value := range map[string]int{"vus": profile.VUs, "iterations": profile.Iterations, "rate": profile.Rate, "preAllocatedVUs": profile.PreAllocatedVUs, "maxVUs": profile.MaxVUs, "startVUs": profile.StartVUs, "startRate": profile.StartRate} {
+		if value < 0 {
+			problems = append(problems, fmt.Sprintf("%s must not be negative", name))
+		}
+	}
+	if profile.MaxVUs != 0 && profile.MaxVUs < profile.PreAllocatedVUs {
+		problems = append(problems, "maxVUs must not be lower than preAllocatedVUs")
+	}
+
+	for name, value := range map[string]string{"duration": profile.Duration, "maxDuration": profile.MaxDuration, "timeUnit": profile.TimeUnit, "gracefulStop": profile.GracefulStop} {
+		if value != "" {
+			if err := validateK6Duration(value); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", name, err))
+			}
+		}
+	}
+	for i, stage := range profile.Stages {
+		if err := validateK6Duration(stage.Duration); err != nil {
+			problems = append(problems, fmt.Sprintf("stages[%d].duration: %v", i, err))
+		}
+		if stage.Target < 0 {
+			problems = append(problems, fmt.Sprintf("stages[%d].target must not be negative", i))
+		}
+	}
+
+	for _, metric := range sortedThresholdMetrics(profile.Thresholds) {
+		if strings.TrimSpace(metric) == "" {
+			problems = append(problems, "threshold with an empty metric name")
+			continue
+		}
+		expressions := profile.Thresholds[metric]
+		if len(expressions) == 0 {
+			problems = append(problems, fmt.Sprintf("threshold %s has no expressions", metric))
+		}
+		for _, expression := range expressions {
+			if !strings.ContainsAny(expression, "<>=") {
+				problems = append(problems, fmt.Sprintf("threshold %s: %q is not a comparison", metric, expression))
+			}
+		}
+	}
+
+	sort.Strings(problems)
+	return problems
+}
+
+// validateK6Duration accepts the duration strings k6 understands, such as 30s, 1m30s or 500ms.
+func validateK6Duration(value string) error {
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q", value)
+	}
+	if parsed < 0 {
+		return fmt.Errorf("duration %q must not be negative", value)
+	}
+	return nil
+}
+
+func sortedBoolKeys(values map[string]bool) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedThresholdMetrics(thresholds map[string][]string) []string {
+	metrics := make([]string, 0, len(thresholds))
+	for metric := range thresholds {
+		metrics = append(metrics, metric)
+	}
+	sort.Strings(metrics)
+	return metrics
+}
+
+// scenarioCode renders the scenario built from a profile as the body of one options.scenarios entry.
+// exec names the function the scenario runs; empty keeps k6's default function.
+func scenarioCode(profile LoadProfile, exec string) string {
+	var lines []string
+	add := func(format string, args ...interface{}) {
+		lines = append(lines, fmt.Sprintf("\t\t\t"+format+",", args...))
+	}
+
+	add("executor: %s", jsemit.String(profile.Executor))
+	if exec != "" {
+		add("exec: %s", jsemit.String(exec))
+	}
+	set := profile.setFields()
+	for _, field := range []struct {
+		name  string
+		value interface{}
+	}{
+		{"startVUs", profile.StartVUs},
+		{"startRate", profile.StartRate},
+		{"vus", profile.VUs},
+		{"rate", profile.Rate},
+		{"timeUnit", profile.TimeUnit},
+		{"iterations", profile.Iterations},
+		{"duration", profile.Duration},
+		{"maxDuration", profile.MaxDuration},
+		{"preAllocatedVUs", profile.PreAllocatedVUs},
+		{"maxVUs", profile.MaxVUs},
+	} {
+		if !set[field.name] {
+			continue
+		}
+		if text, ok := field.value.(string); ok {
+			add("%s: %s", field.name, jsemit.String(text))
+		} else {
+			add("%s: %v", field.name, field.value)
+		}
+	}
+	if profile.GracefulStop != "" {
+		add("gracefulStop: %s", jsemit.String(profile.GracefulStop))
+	}
+	if len(profile.Stages) > 0 {
+		stages := "\t\t\tstages: [\n"
+		for _, stage := range profile.Stages {
+			stages += fmt.Sprintf("\t\t\t\t{ duration: %s, target: %d },\n", jsemit.String(stage.Duration), stage.Target)
+		}
+		lines = append(lines, stages+"\t\t\t],")
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// optionsCode renders export const options with the given scenarios, keyed by scenario name in order,
+// the thresholds of the profile merged with extraThresholds, and the TLS setting of the profile.
+func optionsCode(profile LoadProfile, scenarioNames []string, scenarios map[string]string, extraThresholds map[string][]k6Threshold) string {
+	skipTLSVerify := profile.InsecureSkipTLSVerify == nil || *profile.InsecureSkipTLSVerify
+
+	code := "export const options = {\n"
+	code += fmt.Sprintf("\tinsecureSkipTLSVerify: %t,\n", skipTLSVerify)
+	code += "\tscenarios: {\n"
+	for _, name := range scenarioNames {
+		code += fmt.Sprintf("\t\t%s: {\n%s\t\t},\n", jsonString(name), scenarios[name])
+	}
+	code += "\t},\n"
+
+	thresholds := make(map[string][]k6Threshold)
+	for _, metric := range sortedThresholdMetrics(profile.Thresholds) {
+		for _, expression := range profile.Thresholds[metric] {
+			addThreshold(thresholds, metric, k6Threshold{Expression: expression})
+		}
+	}
+	for _, metric := range sortedK6ThresholdMetrics(extraThresholds) {
+		for _, threshold := range extraThresholds[metric] {
+			addThreshold(thresholds, metric, threshold)
+		}
+	}
+	if len(thresholds) > 0 {
+		code += "\tthresholds: {\n"
+		for _, metric := range sortedK6ThresholdMetrics(thresholds) {
+			codes := make([]string, 0, len(thresholds[metric]))
+			for _, threshold := range thresholds[metric] {
+				codes = append(codes, threshold.code())
+			}
+			code += fmt.Sprintf("\t\t%s: [%s],\n", jsemit.String(metric), strings.Join(codes, ", "))
+		}
+		code += "\t},\n"
+	}
+	return code + "};\n"
+}
+
+// validateLoadProfileFile records an environment's load profile and its problems in the report.
+// Without a profile file the report is left alone and scripts use defaultLoadProfile.
+func validateLoadProfileFile(fitnessPath string, validationReport *ValidationReport) {
+	profile, found, err := loadLoadProfile(fitnessPath)
+	if err != nil {
+		validationReport.ProfileIssues = append(validationReport.ProfileIssues, err.Error())
+		return
+	}
+	if !found {
+		return
+	}
+	fmt.Println("Using load profile:", loadProfileFileName)
+	validationReport.ProfileIssues = append(validationReport.ProfileIssues, validateLoadProfile(profile)...)
+	validationReport.LoadProfile = &profile
+}
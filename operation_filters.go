@@ -0,0 +1,216 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// skipExtension excludes an operation, or every operation of a path item, when set to true.
+const skipExtension = "x-k6-skip"
+
+// OperationFilter matches operations by tag, operationId glob, HTTP method or path prefix. Path
+// prefixes match whole segments, so /admin matches /admin/users but not /administrators.
+type OperationFilter struct {
+	Tags         []string `yaml:"tags"`
+	OperationIDs []string `yaml:"operationIds"`
+	Methods      []string `yaml:"methods"`
+	Paths        []string `yaml:"paths"`
+}
+
+// OperationSelection decides which operations are validated and load tested. An operation must match
+// every kind of criteria of Include that is set, and is dropped when it matches any criterion of
+// Exclude. Environments add their own filters to those of every environment.
+type OperationSelection struct {
+	Include      OperationFilter               `yaml:"include"`
+	Exclude      OperationFilter               `yaml:"exclude"`
+	Environments map[string]OperationSelection `yaml:"environments"`
+}
+
+// SkippedOperation is an operation of the spec left out of the validation and the script, with the reason.
+type SkippedOperation struct {
+	OperationID string `json:"operationId"`
+	Method      string `json:"method"`
+	Path        string `json:"path"`
+	Reason      string `json:"reason"`
+}
+
+// operationFlags holds the --include-* and --exclude-* settings, which override the operations section
+// of the config file.
+var operationFlags OperationSelection
+
+// mergeOperationSelection overlays command-line filters on the config file filters.
+func mergeOperationSelection(fromConfig OperationSelection, fromFlags OperationSelection) OperationSelection {
+	merged := fromConfig
+	overlay := func(filter *OperationFilter, flags OperationFilter) {
+		if len(flags.Tags) > 0 {
+			filter.Tags = flags.Tags
+		}
+		if len(flags.OperationIDs) > 0 {
+			filter.OperationIDs = flags.OperationIDs
+		}
+		if len(flags.Methods) > 0 {
+			filter.Methods = flags.Methods
+		}
+		if len(flags.Paths) > 0 {
+			filter.Paths = flags.Paths
+		}
+	}
+	overlay(&merged.Include, fromFlags.Include)
+	overlay(&merged.Exclude, fromFlags.Exclude)
+	return merged
+}
+
+// validateOperationSelection checks the operationId globs of every filter.
+func validateOperationSelection(selection OperationSelection) error {
+	filters := []OperationFilter{selection.Include, selection.Exclude}
+	for _, environment := range sortedSelectionKeys(selection.Environments) {
+		filters = append(filters, selection.Environments[environment].Include, selection.Environments[environment].Exclude)
+	}
+	for _, filter := range filters {
+		for _, pattern := range filter.OperationIDs {
+			if _, err := filepath.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid operationId glob %q: %v", pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+func sortedSelectionKeys(selections map[string]OperationSelection) []string {
+	present := make(map[string]bool, len(selections))
+	for environment := range selections {
+		present[environment] = true
+	}
+	return sortedBoolKeys(present)
+}
+
+// environmentSelection returns the filters of an environment: the shared ones followed by its own.
+func (selection OperationSelection) environmentSelection(environment string) OperationSelection {
+	own, ok := selection.Environments[environment]
+	if !ok {
+		return selection
+	}
+	combine := func(shared OperationFilter, own OperationFilter) OperationFilter {
+		return OperationFilter{
+			Tags:         append(append([]string{}, shared.Tags...), own.Tags...),
+			OperationIDs: append(append([]string{}, shared.OperationIDs...), own.OperationIDs...),
+			Methods:      append(append([]string{}, shared.Methods...), own.Methods...),
+			Paths:        append(append([]string{}, shared.Paths...), own.Paths...),
+		}
+	}
+	return OperationSelection{
+		Include: combine(selection.Include, own.Include),
+		Exclude: combine(selection.Exclude, own.Exclude),
+	}
+}
+
+// matchingTag returns the first tag of the operation in the list.
+func matchingTag(tags []string, operationTags []string) (string, bool) {
+	for _, tag := range operationTags {
+		if containsString(tags, tag) {
+			return tag, true
+		}
+	}
+	return "", false
+}
+
+// matchingOperationID returns the first glob matching the operationId.
+func matchingOperationID(patterns []string, operationID string) (string, bool) {
+	for _, pattern := range patterns {
+		if matched, err := filepath.Match(pattern, operationID); err == nil && matched {
+			return pattern, true
+		}
+	}
+	return "", false
+}
+
+// matchingMethod returns the method when the list holds it, whatever its case.
+func matchingMethod(methods []string, method string) (string, bool) {
+	for _, candidate := range methods {
+		if strings.EqualFold(candidate, method) {
+			return strings.ToUpper(method), true
+		}
+	}
+	return "", false
+}
+
+// matchingPathPrefix returns the first prefix the path starts with, on a segment boundary.
+func matchingPathPrefix(prefixes []string, path string) (string, bool) {
+	for _, prefix := range prefixes {
+		trimmed := strings.TrimRight(prefix, "/")
+		if trimmed == "" || path == trimmed || strings.HasPrefix(path, trimmed+"/") {
+			return prefix, true
+		}
+	}
+	return "", false
+}
+
+// skipRequested reports whether an operation or its path item sets x-k6-skip.
+func skipRequested(pathItem map[string]interface{}, operation map[string]interface{}) bool {
+	for _, object := range []map[string]interface{}{operation, pathItem} {
+		if skip, ok := object[skipExtension].(bool); ok && skip {
+			return true
+		}
+	}
+	return false
+}
+
+// missingOperation describes an operation named by a fitness file that is not validated: left out by
+// the filters, or absent from the spec.
+func missingOperation(operationID string, skipped []SkippedOperation) string {
+	for _, operation := range skipped {
+		if operation.OperationID == operationID {
+			return fmt.Sprintf("operation %s is skipped (%s)", operationID, operation.Reason)
+		}
+	}
+	return fmt.Sprintf("no operation %s in the spec", operationID)
+}
+
+// operationSkipReason returns why an operation is left out in an environment, or "" when it is kept.
+func operationSkipReason(environment string, path string, method string, pathItem map[string]interface{}, operation map[string]interface{}) string {
+	if skipRequested(pathItem, operation) {
+		return skipExtension + " is set"
+	}
+
+	selection := mergeOperationSelection(generatorConfig.Operations, operationFlags).environmentSelection(environment)
+	operationID, _ := operation["operationId"].(string)
+	tags := operationTags(operation)
+
+	include := selection.Include
+	if len(include.Tags) > 0 {
+		if _, ok := matchingTag(include.Tags, tags); !ok {
+			return "no included tag"
+		}
+	}
+	if len(include.OperationIDs) > 0 {
+		if _, ok := matchingOperationID(include.OperationIDs, operationID); !ok {
+			return "operationId not included"
+		}
+	}
+	if len(include.Methods) > 0 {
+		if _, ok := matchingMethod(include.Methods, method); !ok {
+			return "method not included"
+		}
+	}
+	if len(include.Paths) > 0 {
+		if _, ok := matchingPathPrefix(include.Paths, path); !ok {
+			return "path not included"
+		}
+	}
+
+	exclude := selection.Exclude
+	if tag, ok := matchingTag(exclude.Tags, tags); ok {
+		return "excluded tag " + tag
+	}
+	if pattern, ok := matchingOperationID(exclude.OperationIDs, operationID); ok {
+		return "excluded operationId " + pattern
+	}
+	if matched, ok := matchingMethod(exclude.Methods, method); ok {
+		return "excluded method " + matched
+	}
+	if prefix, ok := matchingPathPrefix(exclude.Paths, path); ok {
+		return "excluded path " + prefix
+	}
+	return ""
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"k6-generator/jsemit"
+)
+
+// operationIDInvalidChars matches the runs of characters a synthesized operationId leaves out.
+var operationIDInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+
+// synthesizedOperationID names an operation without operationId after its method and path, so
+// GET /users/{id}/orders becomes get_users_id_orders and GET / becomes get_root.
+func synthesizedOperationID(method string, path string) string {
+	var parts []string
+	for _, segment := range strings.Split(path, "/") {
+		segment = strings.Trim(operationIDInvalidChars.ReplaceAllString(segment, "_"), "_")
+		if segment != "" {
+			parts = append(parts, segment)
+		}
+	}
+	if len(parts) == 0 {
+		parts = []string{"root"}
+	}
+	return strings.ToLower(method) + "_" + strings.Join(parts, "_")
+}
+
+// synthesizeOperationIDs gives every operation without an operationId its synthesized one, in path and
+// method order and with a numeric suffix when the name is taken. The ids are written into the spec, so
+// they also name the operation's fitness files, and returned as a set.
+func synthesizeOperationIDs(swagger map[string]interface{}) map[string]bool {
+	paths, _ := swagger["paths"].(map[string]interface{})
+
+	taken := make(map[string]bool)
+	for _, path := range sortedKeys(paths) {
+		pathItem, _ := paths[path].(map[string]interface{})
+		for _, method := range sortedMethodKeys(pathItem) {
+			operation, _ := pathItem[method].(map[string]interface{})
+			if operationID, ok := operation["operationId"].(string); ok && operationID != "" {
+				taken[operationID] = true
+			}
+		}
+	}
+
+	synthesized := make(map[string]bool)
+	for _, path := range sortedKeys(paths) {
+		pathItem, _ := paths[path].(map[string]interface{})
+		for _, method := range sortedMethodKeys(pathItem) {
+			operation, ok := pathItem[method].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if operationID, ok := operation["operationId"].(string); ok && operationID != "" {
+				continue
+			}
+
+			base := synthesizedOperationID(method, path)
+			operationID := base
+			for suffix := 2; taken[operationID]; suffix++ {
+				operationID = fmt.Sprintf("%s_%d", base, suffix)
+			}
+			taken[operationID] = true
+			synthesized[operationID] = true
+			operation["operationId"] = operationID
+			fmt.Printf("Synthesized operationId %s for %s %s\n", operationID, strings.ToUpper(method), path)
+		}
+	}
+	return synthesized
+}
+
+// operationIdentifier turns an operationId into the prefix of the script variables, metrics and checks
+// of its operation, so ids such as get-user or users.get still yield valid JavaScript identifiers.
+func operationIdentifier(operationID string) string {
+	return jsemit.Identifier(operationID)
+}
+
+// validateOperationIdentifiers reports operations whose operationIds become the same script identifier.
+func validateOperationIdentifiers(validationReport *ValidationReport) {
+	byIdentifier := make(map[string][]string)
+	for _, operationID := range sortedEndpointIDs(validationReport.Endpoints) {
+		identifier := operationIdentifier(operationID)
+		byIdentifier[identifier] = append(byIdentifier[identifier], operationID)
+	}
+
+	for identifier, operationIDs := range byIdentifier {
+		if len(operationIDs) < 2 {
+			continue
+		}
+		for _, operationID := range operationIDs {
+			details := validationReport.Endpoints[operationID]
+			details.Issues = append(details.Issues, Issue{
+				File:  "operationId",
+				Issue: fmt.Sprintf("operationIds %s share the script identifier %s; rename all but one", strings.Join(operationIDs, ", "), identifier),
+			})
+			validationReport.Endpoints[operationID] = details
+		}
+	}
+}
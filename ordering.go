@@ -0,0 +1,80 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// httpMethodOrder is the order operations of one path appear in generated scripts and reports.
+var httpMethodOrder = []string{"get", "put", "post", "patch", "delete", "head", "options", "trace"}
+
+// methodRank returns the position of an HTTP method in httpMethodOrder; other keys sort after them.
+func methodRank(method string) int {
+	for i, known := range httpMethodOrder {
+		if strings.EqualFold(method, known) {
+			return i
+		}
+	}
+	return len(httpMethodOrder)
+}
+
+// sortedKeys returns the keys of a decoded JSON/YAML object in byte order.
+func sortedKeys(object map[string]interface{}) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sortedStringKeys returns the keys of a string map in byte order.
+func sortedStringKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sortedMethodKeys returns the keys of a path item with HTTP methods first in httpMethodOrder,
+// followed by the remaining keys (parameters, servers, extensions) in byte order.
+func sortedMethodKeys(pathItem map[string]interface{}) []string {
+	keys := sortedKeys(pathItem)
+	sort.SliceStable(keys, func(i, j int) bool {
+		return methodRank(keys[i]) < methodRank(keys[j])
+	})
+	return keys
+}
+
+// sortedEndpointIDs orders the endpoints of a report by path, then method, then operationId.
+func sortedEndpointIDs(endpoints map[string]EndpointDetails) []string {
+	operationIDs := make([]string, 0, len(endpoints))
+	for operationID := range endpoints {
+		operationIDs = append(operationIDs, operationID)
+	}
+	sort.Slice(operationIDs, func(i, j int) bool {
+		left, right := endpoints[operationIDs[i]], endpoints[operationIDs[j]]
+		if left.Path != right.Path {
+			return left.Path < right.Path
+		}
+		if methodRank(left.Method) != methodRank(right.Method) {
+			return methodRank(left.Method) < methodRank(right.Method)
+		}
+		return operationIDs[i] < operationIDs[j]
+	})
+	return operationIDs
+}
+
+// firstNamedExample returns the value of the first entry of an OpenAPI examples map by name.
+func firstNamedExample(examples map[string]interface{}) (interface{}, bool) {
+	for _, name := range sortedKeys(examples) {
+		if exampleMap, ok := examples[name].(map[string]interface{}); ok {
+			if value, ok := exampleMap["value"]; ok {
+				return value, true
+			}
+		}
+	}
+	return nil, false
+}
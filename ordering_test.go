@@ -0,0 +1,164 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the expected scripts and reports under testdata/golden")
+
+// goldenFolder is laid out like a VPE config folder: environments under fitness, scripts under k6,
+// and the printed validation reports under reports.
+const goldenFolder = "testdata/golden"
+
+// TestGoldenScripts generates the script and validation report of every environment under
+// testdata/golden/fitness twice and compares both runs with the expected files byte for byte. Go
+// randomizes map iteration on every range, so output that depends on map order differs between the
+// runs. Run go test -run TestGoldenScripts -update to accept a deliberate change of the output.
+func TestGoldenScripts(t *testing.T) {
+	savedOptions, savedConfig := swaggerOptions, generatorConfig
+	defer func() {
+		swaggerOptions, generatorConfig = savedOptions, savedConfig
+	}()
+	swaggerOptions.FolderPath = goldenFolder
+
+	fitnessPath := filepath.Join(goldenFolder, "fitness")
+	config, err := loadGeneratorConfig(fitnessPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	generatorConfig = config
+
+	entries, err := os.ReadDir(fitnessPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		environment := entry.Name()
+		t.Run(environment, func(t *testing.T) {
+			firstScript, firstReport := generateGolden(t, fitnessPath, environment)
+			secondScript, secondReport := generateGolden(t, fitnessPath, environment)
+			if firstScript != secondScript {
+				t.Fatalf("two generated scripts differ: %s", firstDifference(secondScript, firstScript))
+			}
+			if firstReport != secondReport {
+				t.Fatalf("two validation reports differ: %s", firstDifference(secondReport, firstReport))
+			}
+
+			compareGolden(t, filepath.Join(goldenFolder, "k6", scriptFileName(environment)), firstScript)
+			compareGolden(t, filepath.Join(goldenFolder, "reports", environment+".txt"), firstReport)
+		})
+	}
+}
+
+// compareGolden compares got with the expected file, or rewrites the file with -update.
+func compareGolden(t *testing.T, goldenPath string, got string) {
+	t.Helper()
+	if *updateGolden {
+		if err := os.MkdirAll(filepath.Dir(goldenPath), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(goldenPath, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	expected, err := os.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatalf("%v (run with -update to create it)", err)
+	}
+	if got != string(expected) {
+		t.Errorf("output differs from %s: %s", goldenPath, firstDifference(got, string(expected)))
+	}
+}
+
+// generateGolden runs an environment through the same steps as ValidateSwaggerAndFiles and returns
+// the script and the report printed by GenerateReport.
+func generateGolden(t *testing.T, fitnessPath string, environment string) (string, string) {
+	t.Helper()
+
+	envFitnessPath := filepath.Join(fitnessPath, environment)
+	swaggerFile, err := findSwaggerFile(envFitnessPath)
+	if err != nil || swaggerFile == "" {
+		t.Fatalf("no Swagger/OpenAPI file in %s: %v", envFitnessPath, err)
+	}
+	swaggerPath := filepath.Join(envFitnessPath, swaggerFile)
+	content, err := readFileContent(swaggerPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	swagger, err := parseSpecContent(content)
+	if err != nil {
+		t.Fatalf("parsing %s: %v", swaggerPath, err)
+	}
+	swagger, _, err = resolveSwaggerRefs(swagger, swaggerPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	validationReport := createValidationReport()
+	validationReport.SpecVersion = specVersion(swagger)
+	if isSwagger2Document(swagger) {
+		validationReport.ConversionWarnings = swagger2ConversionWarnings(swagger)
+		swagger = convertSwagger2ToOpenAPI3(swagger)
+	}
+	if err := validateSwagger(swagger, envFitnessPath, &validationReport); err != nil {
+		t.Fatal(err)
+	}
+	report := captureStdout(t, func() { GenerateReport(validationReport) })
+
+	script, _, err := generateK6Script(swagger, validationReport, environment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return script, report
+}
+
+// captureStdout returns what run prints.
+func captureStdout(t *testing.T, run func()) string {
+	t.Helper()
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := os.Stdout
+	os.Stdout = writer
+	printed := make(chan string)
+	go func() {
+		var builder strings.Builder
+		io.Copy(&builder, reader)
+		printed <- builder.String()
+	}()
+
+	run()
+	os.Stdout = saved
+	writer.Close()
+	return <-printed
+}
+
+// firstDifference describes the first line where got and want differ.
+func firstDifference(got string, want string) string {
+	gotLines, wantLines := strings.Split(got, "\n"), strings.Split(want, "\n")
+	for i := 0; i < len(gotLines) || i < len(wantLines); i++ {
+		var gotLine, wantLine string
+		if i < len(gotLines) {
+			gotLine = gotLines[i]
+		}
+		if i < len(wantLines) {
+			wantLine = wantLines[i]
+		}
+		if gotLine != wantLine || i >= len(gotLines) || i >= len(wantLines) {
+			return fmt.Sprintf("line %d\n got: %q\nwant: %q", i+1, gotLine, wantLine)
+		}
+	}
+	return "no difference"
+}
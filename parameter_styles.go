@@ -0,0 +1,297 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// OpenAPI parameter styles
+const (
+	styleForm           = "form"
+	styleSimple         = "simple"
+	styleLabel          = "label"
+	styleMatrix         = "matrix"
+	styleSpaceDelimited = "spaceDelimited"
+	stylePipeDelimited  = "pipeDelimited"
+	styleDeepObject     = "deepObject"
+)
+
+// exampleParameterValue is sent for required parameters that have no value in the fitness files or the spec.
+const exampleParameterValue = "example_value"
+
+// parameterValueString flattens a parameter value read from a fitness file or the spec. Scalars are
+// kept as text and arrays and objects become JSON, which decodeParameterValue turns back into values.
+func parameterValueString(value interface{}) string {
+	switch typed := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return typed
+	case []interface{}, map[string]interface{}, map[interface{}]interface{}:
+		encoded, err := json.Marshal(normalizeYAMLValue(typed))
+		if err == nil {
+			return string(encoded)
+		}
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// operationParameterSpecs returns the merged path-item and operation parameters of an operation.
+func operationParameterSpecs(swagger map[string]interface{}, path string, method string) []map[string]interface{} {
+	paths, _ := swagger["paths"].(map[string]interface{})
+	pathItem, _ := paths[path].(map[string]interface{})
+	operation, _ := pathItem[method].(map[string]interface{})
+	pathItemParameters, _ := pathItem["parameters"].([]interface{})
+	operationParameters, _ := operation["parameters"].([]interface{})
+
+	var specs []map[string]interface{}
+	for _, parameter := range mergeParameters(pathItemParameters, operationParameters) {
+		if spec, ok := parameter.(map[string]interface{}); ok {
+			specs = append(specs, spec)
+		}
+	}
+	return specs
+}
+
+// findParameterSpec returns the parameter with the given name and location, or nil.
+func findParameterSpec(specs []map[string]interface{}, name string, location string) map[string]interface{} {
+	for _, spec := range specs {
+		if spec["name"] == name && spec["in"] == location {
+			return spec
+		}
+	}
+	return nil
+}
+
+// parameterRequired reports whether a parameter must be sent; path parameters always are.
+func parameterRequired(spec map[string]interface{}) bool {
+	required, _ := spec["required"].(bool)
+	return required || spec["in"] == "path"
+}
+
+// parameterStyle returns the style and explode setting of a parameter, applying the OpenAPI defaults:
+// form for query and cookie parameters, simple for path and header ones, and explode only for form.
+func parameterStyle(spec map[string]interface{}) (string, bool) {
+	style, _ := spec["style"].(string)
+	if style == "" {
+		switch spec["in"] {
+		case "query", "cookie":
+			style = styleForm
+		default:
+			style = styleSimple
+		}
+	}
+	explode, ok := spec["explode"].(bool)
+	if !ok {
+		explode = style == styleForm
+	}
+	return style, explode
+}
+
+// decodeParameterValue turns the text of an array or object parameter back into its value. Arrays also
+// accept comma-separated text. Other parameters, and text that does not decode, stay as they are.
+func decodeParameterValue(value string, spec map[string]interface{}) interface{} {
+	schema, _ := spec["schema"].(map[string]interface{})
+	switch schemaType(schema) {
+	case "array":
+		var items []interface{}
+		if err := json.Unmarshal([]byte(value), &items); err == nil {
+			return items
+		}
+		for _, item := range strings.Split(value, ",") {
+			items = append(items, strings.TrimSpace(item))
+		}
+		return items
+	case "object":
+		var object map[string]interface{}
+		if err := json.Unmarshal([]byte(value), &object); err == nil {
+			return object
+		}
+	}
+	return value
+}
+
+// encodeQueryComponent percent-encodes a query name or value, leaving the RFC 3986 reserved characters
+// alone when the parameter allows them.
+func encodeQueryComponent(value string, allowReserved bool) string {
+	encoded := strings.ReplaceAll(url.QueryEscape(value), "+", "%20")
+	if !allowReserved {
+		return encoded
+	}
+	for _, reserved := range []string{":", "/", "?", "#", "[", "]", "@", "!", "$", "&", "'", "(", ")", "*", "+", ",", ";", "="} {
+		encoded = strings.ReplaceAll(encoded, url.QueryEscape(reserved), reserved)
+	}
+	return encoded
+}
+
+// serializeQueryParameter renders a query parameter as encoded name=value pairs for its style.
+func serializeQueryParameter(name string, value interface{}, spec map[string]interface{}) []string {
+	style, explode := parameterStyle(spec)
+	allowReserved, _ := spec["allowReserved"].(bool)
+	encode := func(text string) string { return encodeQueryComponent(text, allowReserved) }
+	encodedName := encode(name)
+
+	delimiter := ","
+	switch style {
+	case styleSpaceDelimited:
+		delimiter = "%20"
+	case stylePipeDelimited:
+		delimiter = "|"
+	}
+
+	switch typed := value.(type) {
+	case []interface{}:
+		if explode {
+			pairs := make([]string, 0, len(typed))
+			for _, item := range typed {
+				pairs = append(pairs, encodedName+"="+encode(parameterValueString(item)))
+			}
+			return pairs
+		}
+		items := make([]string, 0, len(typed))
+		for _, item := range typed {
+			items = append(items, encode(parameterValueString(item)))
+		}
+		return []string{encodedName + "=" + strings.Join(items, delimiter)}
+
+	case map[string]interface{}:
+		keys := sortedKeys(typed)
+		if style == styleDeepObject {
+			pairs := make([]string, 0, len(keys))
+			for _, key := range keys {
+				pairs = append(pairs, fmt.Sprintf("%s[%s]=%s", encodedName, encode(key), encode(parameterValueString(typed[key]))))
+			}
+			return pairs
+		}
+		if explode && style == styleForm {
+			pairs := make([]string, 0, len(keys))
+			for _, key := range keys {
+				pairs = append(pairs, encode(key)+"="+encode(parameterValueString(typed[key])))
+			}
+			return pairs
+		}
+		items := make([]string, 0, 2*len(keys))
+		for _, key := range keys {
+			items = append(items, encode(key), encode(parameterValueString(typed[key])))
+		}
+		return []string{encodedName + "=" + strings.Join(items, delimiter)}
+	}
+
+	return []string{encodedName + "=" + encode(parameterValueString(value))}
+}
+
+// serializePathParameter renders a path parameter for the simple, label or matrix style,
+// percent-encoding each value so only the style's delimiters stay literal.
+func serializePathParameter(name string, value interface{}, spec map[string]interface{}) string {
+	style, explode := parameterStyle(spec)
+
+	prefix, separator := "", ","
+	switch style {
+	case styleLabel:
+		prefix = "."
+		if explode {
+			separator = "."
+		}
+	case styleMatrix:
+		prefix = ";" + url.PathEscape(name) + "="
+		if explode {
+			separator = ";" + url.PathEscape(name) + "="
+		}
+	}
+
+	switch typed := value.(type) {
+	case []interface{}:
+		items := make([]string, 0, len(typed))
+		for _, item := range typed {
+			items = append(items, url.PathEscape(parameterValueString(item)))
+		}
+		return prefix + strings.Join(items, separator)
+
+	case map[string]interface{}:
+		keys := sortedKeys(typed)
+		items := make([]string, 0, len(keys))
+		for _, key := range keys {
+			if explode {
+				items = append(items, url.PathEscape(key)+"="+url.PathEscape(parameterValueString(typed[key])))
+			} else {
+				items = append(items, url.PathEscape(key), url.PathEscape(parameterValueString(typed[key])))
+			}
+		}
+		if !explode {
+			return prefix + strings.Join(items, ",")
+		}
+		switch style {
+		case styleMatrix:
+			return ";" + strings.Join(items, ";")
+		case styleLabel:
+			return "." + strings.Join(items, ".")
+		}
+		return strings.Join(items, ",")
+	}
+
+	return prefix + url.PathEscape(parameterValueString(value))
+}
+
+// serializeHeaderValue renders a header parameter in the simple style; header values are not percent-encoded.
+func serializeHeaderValue(value interface{}, spec map[string]interface{}) string {
+	_, explode := parameterStyle(spec)
+	switch typed := value.(type) {
+	case []interface{}:
+		items := make([]string, 0, len(typed))
+		for _, item := range typed {
+			items = append(items, parameterValueString(item))
+		}
+		return strings.Join(items, ",")
+	case map[string]interface{}:
+		var items []string
+		for _, key := range sortedKeys(typed) {
+			if explode {
+				items = append(items, key+"="+parameterValueString(typed[key]))
+			} else {
+				items = append(items, key, parameterValueString(typed[key]))
+			}
+		}
+		return strings.Join(items, ",")
+	}
+	return parameterValueString(value)
+}
+
+// serializeCookieValue renders a cookie parameter in the form style without explode; a cookie carries one value,
+// percent-encoded since cookie values cannot hold spaces, commas or semicolons.
+func serializeCookieValue(value interface{}) string {
+	switch typed := value.(type) {
+	case []interface{}:
+		items := make([]string, 0, len(typed))
+		for _, item := range typed {
+			items = append(items, encodeQueryComponent(parameterValueString(item), false))
+		}
+		return strings.Join(items, ",")
+	case map[string]interface{}:
+		var items []string
+		for _, key := range sortedKeys(typed) {
+			items = append(items, encodeQueryComponent(key, false), encodeQueryComponent(parameterValueString(typed[key]), false))
+		}
+		return strings.Join(items, ",")
+	}
+	return encodeQueryComponent(parameterValueString(value), false)
+}
+
+// encodedPathParams serializes every resolved path parameter for its style.
+func encodedPathParams(pathParams map[string]string, specs []map[string]interface{}) map[string]string {
+	encoded := make(map[string]string, len(pathParams))
+	for name, value := range pathParams {
+		spec := findParameterSpec(specs, name, "path")
+		encoded[name] = serializePathParameter(name, decodeParameterValue(value, spec), spec)
+	}
+	return encoded
+}
+
+// generateQueryParamsString joins encoded name=value pairs into a query string, or nothing when there are none.
+func generateQueryParamsString(pairs []string) string {
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "?" + strings.Join(pairs, "&")
+}
@@ -0,0 +1,441 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Report formats accepted by --report-format
+const (
+	reportFormatJSON  = "json"
+	reportFormatJUnit = "junit"
+	reportFormatSARIF = "sarif"
+)
+
+// reportFormat and reportOut are set from the --report-format and --report-out flags.
+var reportFormat string
+var reportOut string
+
+// EnvironmentResult is the outcome of validating one environment folder and generating its script.
+type EnvironmentResult struct {
+	Environment   string            `json:"environment"`
+	Status        string            `json:"status"`
+	FailureReason string            `json:"failureReason,omitempty"`
+	SwaggerFile   string            `json:"swaggerFile,omitempty"`
+	ScriptFile    string            `json:"scriptFile,omitempty"`
+	Report        *ValidationReport `json:"report,omitempty"`
+}
+
+// RunReport is the machine-readable report written for a whole fitness folder.
+type RunReport struct {
+	GeneratedAt          string               `json:"generatedAt"`
+	FitnessPath          string               `json:"fitnessPath"`
+	Mode                 string               `json:"mode"`
+	EnvironmentSelection string               `json:"environmentSelection,omitempty"`
+	SkippedEnvironments  []SkippedEnvironment `json:"skippedEnvironments,omitempty"`
+	Environments         []EnvironmentResult  `json:"environments"`
+}
+
+// reportFinding is a single problem found in an environment, shared by the JUnit and SARIF writers.
+type reportFinding struct {
+	RuleID      string
+	Message     string
+	File        string
+	OperationID string
+}
+
+var reportRuleDescriptions = map[string]string{
+	"missing-server-url":  "The Swagger/OpenAPI document does not declare a server URL",
+	"missing-file":        "A fitness file expected for an operation is missing",
+	"empty-value":         "A fitness file is empty or could not be parsed",
+	"endpoint-issue":      "A fitness file does not satisfy the operation's parameters or request schema",
+	"load-profile":        "The environment's load profile is invalid",
+	"slo":                 "The environment's service level objectives are invalid",
+	"dataset":             "The environment's datasets are invalid or do not match the operations",
+	"flow":                "The environment's flow is invalid or does not match the operations",
+	"environment-failure": "The k6 script could not be generated for the environment",
+}
+
+func isSupportedReportFormat(format string) bool {
+	switch format {
+	case reportFormatJSON, reportFormatJUnit, reportFormatSARIF:
+		return true
+	}
+	return false
+}
+
+func defaultReportFileName(format string) string {
+	switch format {
+	case reportFormatJUnit:
+		return "validation-report.xml"
+	case reportFormatSARIF:
+		return "validation-report.sarif"
+	default:
+		return "validation-report.json"
+	}
+}
+
+// buildRunReport collects the per-environment results in detection order. Succeeded lists the
+// environments that passed: validated in validate and report mode, with a script in generate mode.
+func buildRunReport(fitnessPath string, mode string, environments []string, results map[string]*EnvironmentResult, failureReasons map[string]string, succeeded []string) RunReport {
+	runReport := RunReport{
+		GeneratedAt:  time.Now().UTC().Format(time.RFC3339),
+		FitnessPath:  fitnessPath,
+		Mode:         mode,
+		Environments: []EnvironmentResult{},
+	}
+
+	for _, environment := range environments {
+		result := EnvironmentResult{Environment: environment}
+		if existing, ok := results[environment]; ok {
+			result = *existing
+		}
+
+		if reason, failed := failureReasons[environment]; failed {
+			result.Status = "failed"
+			result.FailureReason = reason
+		} else if containsString(succeeded, environment) {
+			result.Status = "success"
+		} else {
+			result.Status = "skipped"
+		}
+		runReport.Environments = append(runReport.Environments, result)
+	}
+	return runReport
+}
+
+// writeRunReport writes the run report in the requested format, creating parent folders as needed.
+func writeRunReport(runReport RunReport, format string, reportPath string) error {
+	var data []byte
+	var err error
+
+	switch format {
+	case reportFormatJSON:
+		data, err = json.MarshalIndent(runReport, "", "  ")
+	case reportFormatJUnit:
+		data, err = renderJUnitReport(runReport)
+	case reportFormatSARIF:
+		data, err = renderSARIFReport(runReport)
+	default:
+		return fmt.Errorf("unsupported report format %q", format)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(reportPath), os.ModePerm); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(reportPath, data, 0644)
+}
+
+// collectFindings flattens everything that blocks script generation for an environment.
+func collectFindings(result EnvironmentResult) []reportFinding {
+	var findings []reportFinding
+
+	if result.Report == nil {
+		if result.FailureReason != "" {
+			findings = append(findings, reportFinding{RuleID: "environment-failure", Message: result.FailureReason, File: result.SwaggerFile})
+		}
+		return findings
+	}
+
+	report := result.Report
+	if report.MissingServerURL {
+		message := "Server URL is missing in the Swagger file"
+		if report.ServerIssue != "" {
+			message += ": " + report.ServerIssue
+		}
+		findings = append(findings, reportFinding{RuleID: "missing-server-url", Message: message, File: result.SwaggerFile})
+	}
+
+	for _, issue := range report.ProfileIssues {
+		findings = append(findings, reportFinding{RuleID: "load-profile", Message: "Load profile: " + issue, File: loadProfileFileName})
+	}
+
+	for _, issue := range report.SLOIssues {
+		findings = append(findings, reportFinding{RuleID: "slo", Message: "Service level objectives: " + issue, File: sloFileName})
+	}
+
+	for _, issue := range report.DatasetIssues {
+		findings = append(findings, reportFinding{RuleID: "dataset", Message: "Datasets: " + issue, File: datasetsFileName})
+	}
+
+	for _, issue := range report.FlowIssues {
+		findings = append(findings, reportFinding{RuleID: "flow", Message: "Flow: " + issue, File: flowFileName})
+	}
+
+	for _, item := range report.MissingFiles {
+		findings = append(findings, reportFinding{
+			RuleID:      "missing-file",
+			Message:     fmt.Sprintf("Missing %s file %s for operation %s", item.Type, item.File, item.OperationID),
+			File:        item.File,
+			OperationID: item.OperationID,
+		})
+	}
+
+	for _, item := range report.EmptyValues {
+		message := fmt.Sprintf("Empty or problematic %s file %s for operation %s", item.Type, item.File, item.OperationID)
+		if item.Issue != "" {
+			message += ": " + item.Issue
+		}
+		findings = append(findings, reportFinding{RuleID: "empty-value", Message: message, File: item.File, OperationID: item.OperationID})
+	}
+
+	for _, operationID := range sortedEndpointIDs(report.Endpoints) {
+		for _, issue := range report.Endpoints[operationID].Issues {
+			var parts []string
+			if len(issue.MissingParameters) > 0 {
+				parts = append(parts, "missing parameters: "+strings.Join(issue.MissingParameters, ", "))
+			}
+			if len(issue.EmptyParameters) > 0 {
+				parts = append(parts, "empty parameters: "+strings.Join(issue.EmptyParameters, ", "))
+			}
+			if len(issue.MissingRequiredProperties) > 0 {
+				parts = append(parts, "missing required properties: "+strings.Join(issue.MissingRequiredProperties, ", "))
+			}
+			if len(issue.TypeValidationErrors) > 0 {
+				parts = append(parts, "type validation errors: "+strings.Join(issue.TypeValidationErrors, ", "))
+			}
+			if issue.Issue != "" {
+				parts = append(parts, issue.Issue)
+			}
+			findings = append(findings, reportFinding{
+				RuleID:      "endpoint-issue",
+				Message:     fmt.Sprintf("Operation %s: %s", operationID, strings.Join(parts, "; ")),
+				File:        issue.File,
+				OperationID: operationID,
+			})
+		}
+	}
+
+	if result.Status == "failed" && len(findings) == 0 {
+		findings = append(findings, reportFinding{RuleID: "environment-failure", Message: result.FailureReason, File: result.SwaggerFile})
+	}
+	return findings
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr,omitempty"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr,omitempty"`
+	Timestamp string          `xml:"timestamp,attr,omitempty"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// renderJUnitReport writes one test suite per environment with a test case per operation, skipped
+// operations included as skipped cases, plus a k6-script case for the generation outcome. Validate
+// and report runs generate no script, so they get an environment case for the environment-level
+// findings instead.
+func renderJUnitReport(runReport RunReport) ([]byte, error) {
+	suites := junitTestSuites{Name: "k6-swagger-validation"}
+
+	for _, result := range runReport.Environments {
+		suite := junitTestSuite{Name: result.Environment, Timestamp: runReport.GeneratedAt}
+		findingsByOperation := make(map[string][]reportFinding)
+		for _, finding := range collectFindings(result) {
+			findingsByOperation[finding.OperationID] = append(findingsByOperation[finding.OperationID], finding)
+		}
+
+		if result.Report != nil {
+			for _, operationID := range sortedEndpointIDs(result.Report.Endpoints) {
+				details := result.Report.Endpoints[operationID]
+				testCase := junitTestCase{
+					Name:      fmt.Sprintf("%s (%s %s)", operationID, strings.ToUpper(details.Method), details.Path),
+					ClassName: result.Environment,
+				}
+				testCase.Failure = junitFailureFor(findingsByOperation[operationID])
+				suite.Cases = append(suite.Cases, testCase)
+			}
+			for _, skipped := range result.Report.SkippedOperations {
+				suite.Cases = append(suite.Cases, junitTestCase{
+					Name:      fmt.Sprintf("%s (%s %s)", skipped.OperationID, strings.ToUpper(skipped.Method), skipped.Path),
+					ClassName: result.Environment,
+					Skipped:   &junitSkipped{Message: skipped.Reason},
+				})
+			}
+		}
+
+		scriptCase := junitTestCase{Name: "k6-script", ClassName: result.Environment}
+		if runReport.Mode != swaggerModeGenerate {
+			scriptCase.Name = "environment"
+			scriptCase.Failure = junitFailureFor(findingsByOperation[""])
+		} else if result.Status != "success" {
+			scriptCase.Failure = junitFailureFor(findingsByOperation[""])
+			if scriptCase.Failure == nil {
+				scriptCase.Failure = &junitFailure{Message: result.FailureReason, Type: "environment-failure", Text: result.FailureReason}
+			}
+		}
+		suite.Cases = append(suite.Cases, scriptCase)
+
+		for _, testCase := range suite.Cases {
+			suite.Tests++
+			if testCase.Failure != nil {
+				suite.Failures++
+			}
+			if testCase.Skipped != nil {
+				suite.Skipped++
+			}
+		}
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Skipped += suite.Skipped
+		suites.Suites = append(suites.Suites, suite)
+	}
+
+	data, err := xml.MarshalIndent(suites, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+func junitFailureFor(findings []reportFinding) *junitFailure {
+	if len(findings) == 0 {
+		return nil
+	}
+
+	messages := make([]string, 0, len(findings))
+	for _, finding := range findings {
+		messages = append(messages, finding.Message)
+	}
+	return &junitFailure{
+		Message: findings[0].Message,
+		Type:    findings[0].RuleID,
+		Text:    strings.Join(messages, "\n"),
+	}
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID     string                 `json:"ruleId"`
+	Level      string                 `json:"level"`
+	Message    sarifMessage           `json:"message"`
+	Locations  []sarifLocation        `json:"locations,omitempty"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// renderSARIFReport emits a SARIF 2.1.0 log whose locations point at fitness/<env>/<file>.
+func renderSARIFReport(runReport RunReport) ([]byte, error) {
+	ruleIDs := make([]string, 0, len(reportRuleDescriptions))
+	for ruleID := range reportRuleDescriptions {
+		ruleIDs = append(ruleIDs, ruleID)
+	}
+	sort.Strings(ruleIDs)
+
+	driver := sarifDriver{Name: "k6-swagger-generator"}
+	for _, ruleID := range ruleIDs {
+		driver.Rules = append(driver.Rules, sarifRule{ID: ruleID, ShortDescription: sarifMessage{Text: reportRuleDescriptions[ruleID]}})
+	}
+
+	run := sarifRun{Tool: sarifTool{Driver: driver}, Results: []sarifResult{}}
+	for _, result := range runReport.Environments {
+		for _, finding := range collectFindings(result) {
+			sarifEntry := sarifResult{
+				RuleID:  finding.RuleID,
+				Level:   "error",
+				Message: sarifMessage{Text: fmt.Sprintf("[%s] %s", result.Environment, finding.Message)},
+				Properties: map[string]interface{}{
+					"environment": result.Environment,
+				},
+			}
+			if finding.OperationID != "" {
+				sarifEntry.Properties["operationId"] = finding.OperationID
+			}
+
+			file := finding.File
+			if file == "" {
+				file = result.SwaggerFile
+			}
+			if file != "" {
+				sarifEntry.Locations = []sarifLocation{{
+					PhysicalLocation: sarifPhysicalLocation{
+						ArtifactLocation: sarifArtifactLocation{URI: path.Join("fitness", result.Environment, file)},
+					},
+				}}
+			}
+			run.Results = append(run.Results, sarifEntry)
+		}
+	}
+
+	return json.MarshalIndent(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	}, "", "  ")
+}
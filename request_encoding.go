@@ -0,0 +1,419 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"k6-generator/jsemit"
+)
+
+// How a request body is encoded in the generated script, decided by its media type
+const (
+	encodingJSON      = "json"
+	encodingForm      = "form"
+	encodingMultipart = "multipart"
+	encodingXML       = "xml"
+	encodingText      = "text"
+	encodingBinary    = "binary"
+)
+
+// mediaTypePreference is the order request media types are picked in when an operation offers several.
+var mediaTypePreference = []string{encodingJSON, encodingForm, encodingMultipart, encodingXML, encodingText, encodingBinary}
+
+// rawBodyExtensions are the fitness file extensions holding bodies sent as they are; binary bodies
+// accept any extension other than the YAML and JSON used for structured bodies.
+var rawBodyExtensions = map[string][]string{
+	encodingXML:  {"xml"},
+	encodingText: {"txt"},
+}
+
+// bodyEncoding classifies a media type, including structured suffixes such as application/problem+json.
+func bodyEncoding(mediaType string) string {
+	base := strings.ToLower(strings.TrimSpace(strings.SplitN(mediaType, ";", 2)[0]))
+	switch {
+	case base == "application/json" || strings.HasSuffix(base, "+json"):
+		return encodingJSON
+	case base == "application/x-www-form-urlencoded":
+		return encodingForm
+	case base == "multipart/form-data":
+		return encodingMultipart
+	case base == "application/xml" || base == "text/xml" || strings.HasSuffix(base, "+xml"):
+		return encodingXML
+	case strings.HasPrefix(base, "text/"):
+		return encodingText
+	case base == "application/octet-stream" || strings.HasPrefix(base, "image/") || strings.HasPrefix(base, "audio/") || strings.HasPrefix(base, "video/") || base == "application/pdf" || base == "application/zip":
+		return encodingBinary
+	}
+	return ""
+}
+
+// selectRequestMediaType picks the request media type the script sends: the first supported
+// encoding in mediaTypePreference, and within one encoding the media type sorting first.
+func selectRequestMediaType(content map[string]interface{}) (string, map[string]interface{}) {
+	for _, encoding := range mediaTypePreference {
+		for _, mediaType := range sortedKeys(content) {
+			if bodyEncoding(mediaType) != encoding {
+				continue
+			}
+			if media, ok := content[mediaType].(map[string]interface{}); ok {
+				return mediaType, media
+			}
+		}
+	}
+	return "", nil
+}
+
+// requestBodyMedia returns the chosen media type and media object of an operation's request body.
+func requestBodyMedia(swagger map[string]interface{}, path string, method string) (string, map[string]interface{}) {
+	operation := findOperation(swagger, path, method)
+	requestBody, _ := operation["requestBody"].(map[string]interface{})
+	content, _ := requestBody["content"].(map[string]interface{})
+	return selectRequestMediaType(content)
+}
+
+// findRawBodyFile returns the name of the first non-empty <operationId>[_<last path part>]_body file
+// holding a raw body of the given encoding, or an empty name when there is none.
+func findRawBodyFile(operationID string, path string, fitnessPath string, encoding string) string {
+	prefixes := []string{
+		fmt.Sprintf("%s_%s_body.", operationID, filepath.Base(path)),
+		fmt.Sprintf("%s_body.", operationID),
+	}
+	for _, prefix := range prefixes {
+		var candidates []string
+		if encoding == encodingBinary {
+			matches, _ := filepath.Glob(filepath.Join(fitnessPath, globEscape(prefix)+"*"))
+			sort.Strings(matches)
+			for _, match := range matches {
+				extension := strings.ToLower(filepath.Ext(match))
+				if extension != ".yaml" && extension != ".yml" && extension != ".json" {
+					candidates = append(candidates, filepath.Base(match))
+				}
+			}
+		} else {
+			for _, extension := range rawBodyExtensions[encoding] {
+				candidates = append(candidates, prefix+extension)
+			}
+		}
+		for _, candidate := range candidates {
+			if info, err := os.Stat(filepath.Join(fitnessPath, candidate)); err == nil && !info.IsDir() && info.Size() > 0 {
+				return candidate
+			}
+		}
+	}
+	return ""
+}
+
+// globEscape quotes the glob metacharacters of a literal file name prefix.
+func globEscape(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`).Replace(value)
+}
+
+// lookupRawBodyData returns an XML or text body from its fitness file, an example or the schema sample.
+func lookupRawBodyData(operationID string, path string, fitnessPath string, encoding string, media map[string]interface{}) (string, string, string) {
+	if bodyFileName := findRawBodyFile(operationID, path, fitnessPath, encoding); bodyFileName != "" {
+		content, err := readFileContent(filepath.Join(fitnessPath, bodyFileName))
+		if err == nil {
+			return content, bodySourceFile, bodyFileName
+		}
+	}
+
+	examples, _ := media["examples"].(map[string]interface{})
+	schema, _ := media["schema"].(map[string]interface{})
+	if value, ok := firstNamedExample(examples); ok {
+		return rawBodyText(value, encoding, schema), bodySourceExample, ""
+	}
+	if example, ok := media["example"]; ok {
+		return rawBodyText(example, encoding, schema), bodySourceExample, ""
+	}
+	if example, ok := schema["example"]; ok {
+		return rawBodyText(example, encoding, schema), bodySourceExample, ""
+	}
+
+	fmt.Printf("Warning: Body file not found for operationID: %s (tried multiple patterns)\n", operationID)
+	if schema == nil {
+		return "", "", ""
+	}
+	fmt.Printf("Synthesized request body from schema for operationID: %s\n", operationID)
+	return rawBodyText(synthesizeSchemaSample(schema), encoding, schema), bodySourceSynthesized, ""
+}
+
+// rawBodyText renders an example or sample as the text of an XML or plain text body. Strings are
+// taken as written; structured values become XML elements or JSON text.
+func rawBodyText(value interface{}, encoding string, schema map[string]interface{}) string {
+	if text, ok := value.(string); ok {
+		return text
+	}
+	if encoding == encodingXML {
+		return xmlElement(xmlRootName(schema), value)
+	}
+	if value == nil {
+		return ""
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(encoded)
+}
+
+// xmlRootName is the root element of an XML body: the schema's xml.name, its component name, or "root".
+func xmlRootName(schema map[string]interface{}) string {
+	if xmlObject, ok := schema["xml"].(map[string]interface{}); ok {
+		if name, ok := xmlObject["name"].(string); ok && name != "" {
+			return name
+		}
+	}
+	if name, ok := schemaRefName(schema); ok {
+		return name
+	}
+	return "root"
+}
+
+// xmlElement renders a decoded value as XML, repeating the element for array items.
+func xmlElement(name string, value interface{}) string {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		var builder strings.Builder
+		builder.WriteString("<" + name + ">")
+		for _, key := range sortedKeys(typed) {
+			builder.WriteString(xmlElement(key, typed[key]))
+		}
+		builder.WriteString("</" + name + ">")
+		return builder.String()
+	case []interface{}:
+		var builder strings.Builder
+		for _, item := range typed {
+			builder.WriteString(xmlElement(name, item))
+		}
+		return builder.String()
+	case nil:
+		return "<" + name + "/>"
+	}
+	var escaped strings.Builder
+	xml.EscapeText(&escaped, []byte(fmt.Sprintf("%v", value)))
+	return "<" + name + ">" + escaped.String() + "</" + name + ">"
+}
+
+// isBinarySchema reports whether a multipart property is a file upload.
+func isBinarySchema(schema map[string]interface{}) bool {
+	format, _ := schema["format"].(string)
+	if format == "binary" || format == "base64" {
+		return true
+	}
+	_, hasMediaType := schema["contentMediaType"]
+	_, hasEncoding := schema["contentEncoding"]
+	return hasMediaType || hasEncoding
+}
+
+// multipartFileParts returns the file upload properties of a multipart schema in name order.
+func multipartFileParts(schema map[string]interface{}) []string {
+	properties, _ := schema["properties"].(map[string]interface{})
+	var parts []string
+	for _, name := range sortedKeys(properties) {
+		if property, ok := properties[name].(map[string]interface{}); ok && isBinarySchema(property) {
+			parts = append(parts, name)
+		}
+	}
+	return parts
+}
+
+// multipartPartFile resolves the fitness file uploaded for a multipart file part: the file named by
+// the body data when it exists, otherwise <operationId>_<part>.<any extension>, otherwise the
+// <part>.<any extension> file earlier versions required.
+func multipartPartFile(operationID string, part string, value interface{}, fitnessPath string) string {
+	if name, ok := value.(string); ok && name != "" && !filepath.IsAbs(name) && fileExists(filepath.Join(fitnessPath, name)) {
+		return name
+	}
+	for _, prefix := range []string{operationID + "_" + part + ".", part + "."} {
+		matches, _ := filepath.Glob(filepath.Join(fitnessPath, globEscape(prefix)+"*"))
+		sort.Strings(matches)
+		for _, match := range matches {
+			if info, err := os.Stat(match); err == nil && !info.IsDir() {
+				return filepath.Base(match)
+			}
+		}
+	}
+	return ""
+}
+
+// multipartPartContentType is the content type of an uploaded part: the media object's encoding
+// entry, else the one implied by the file extension, else application/octet-stream.
+func multipartPartContentType(media map[string]interface{}, part string, fileName string) string {
+	if encodings, ok := media["encoding"].(map[string]interface{}); ok {
+		if encoding, ok := encodings[part].(map[string]interface{}); ok {
+			if contentType, ok := encoding["contentType"].(string); ok && contentType != "" {
+				return strings.TrimSpace(strings.SplitN(contentType, ",", 2)[0])
+			}
+		}
+	}
+	if contentType := mime.TypeByExtension(filepath.Ext(fileName)); contentType != "" {
+		return strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0])
+	}
+	return "application/octet-stream"
+}
+
+// validateMultipartBody checks the fields of a multipart body and that every required file part has
+// a file in the fitness folder. Optional file parts without a file are left out of the request.
+func validateMultipartBody(media map[string]interface{}, operationID string, endpoint string, method string, fitnessPath string, validationReport *ValidationReport, swagger map[string]interface{}) error {
+	schema, ok := media["schema"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	if err := validateBodyFilesAgainstSchema(schema, operationID, endpoint, fitnessPath, validationReport); err != nil {
+		return err
+	}
+
+	body := multipartBodyValues(operationID, endpoint, method, fitnessPath, swagger)
+	required := make(map[string]bool)
+	if requiredList, ok := schema["required"].([]interface{}); ok {
+		for _, name := range requiredList {
+			if text, ok := name.(string); ok {
+				required[text] = true
+			}
+		}
+	}
+
+	for _, part := range multipartFileParts(schema) {
+		if !required[part] || multipartPartFile(operationID, part, body[part], fitnessPath) != "" {
+			continue
+		}
+		fmt.Printf("Warning: No file found for multipart part %s of operation: %s\n", part, operationID)
+		validationReport.MissingFiles = append(validationReport.MissingFiles, MissingFile{
+			File:        operationID + "_" + part + ".*",
+			Type:        "multipart file",
+			OperationID: operationID,
+		})
+	}
+	return nil
+}
+
+// multipartBodyValues decodes the structured body used for a multipart or form request.
+func multipartBodyValues(operationID string, path string, method string, fitnessPath string, swagger map[string]interface{}) map[string]interface{} {
+	var values map[string]interface{}
+	content, _, _ := lookupBodyData(operationID, path, method, fitnessPath, swagger)
+	if err := json.Unmarshal([]byte(content), &values); err != nil {
+		return map[string]interface{}{}
+	}
+	return values
+}
+
+// validateBinaryBody requires the fitness file sent as an octet-stream style body.
+func validateBinaryBody(operationID string, endpoint string, fitnessPath string, validationReport *ValidationReport) {
+	if findRawBodyFile(operationID, endpoint, fitnessPath, encodingBinary) != "" {
+		return
+	}
+	fmt.Printf("Warning: Binary body file not found for operationID: %s\n", operationID)
+	validationReport.MissingFiles = append(validationReport.MissingFiles, MissingFile{
+		File:        operationID + "_body.*",
+		Type:        "body",
+		OperationID: operationID,
+	})
+}
+
+// scriptRelativePath is the path k6 open() needs to read a fitness file from the generated script.
+func scriptRelativePath(fitnessPath string, fileName string) string {
+	target, err := filepath.Abs(filepath.Join(fitnessPath, fileName))
+	if err != nil {
+		target = filepath.Join(fitnessPath, fileName)
+	}
+	outputDir, err := filepath.Abs(swaggerOutputDir())
+	if err != nil {
+		return filepath.ToSlash(target)
+	}
+	relative, err := filepath.Rel(outputDir, target)
+	if err != nil {
+		return filepath.ToSlash(target)
+	}
+	return filepath.ToSlash(relative)
+}
+
+// formValue renders one form or multipart field; structured values are sent as JSON text.
+func formValue(value interface{}) string {
+	if text, ok := value.(string); ok {
+		return jsemit.String(text)
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return jsemit.String(fmt.Sprintf("%v", value))
+	}
+	return jsemit.String(string(encoded))
+}
+
+// requestBodyExpression returns the JavaScript expression of an operation's request body for its media
+// type, and the init-stage code that opens the files it uploads. JSON bodies keep JSON.stringify.
+func requestBodyExpression(operationID string, endpointDetails EndpointDetails, fitnessPath string, swagger map[string]interface{}) (string, string, error) {
+	path, method := endpointDetails.Path, endpointDetails.Method
+	mediaType, media := requestBodyMedia(swagger, path, method)
+
+	switch bodyEncoding(mediaType) {
+	case encodingForm:
+		values := multipartBodyValues(operationID, path, method, fitnessPath, swagger)
+		var entries []string
+		for _, name := range sortedKeys(values) {
+			entries = append(entries, fmt.Sprintf("%s: %s", jsonString(name), formValue(values[name])))
+		}
+		return "{ " + strings.Join(entries, ", ") + " }", "", nil
+
+	case encodingMultipart:
+		values := multipartBodyValues(operationID, path, method, fitnessPath, swagger)
+		schema, _ := media["schema"].(map[string]interface{})
+		fileParts := make(map[string]string)
+		for _, part := range multipartFileParts(schema) {
+			fileName := multipartPartFile(operationID, part, values[part], fitnessPath)
+			if fileName == "" {
+				delete(values, part)
+				continue
+			}
+			fileParts[part] = fileName
+			values[part] = fileName
+		}
+
+		var entries []string
+		initCode := ""
+		for _, name := range sortedKeys(values) {
+			fileName, isFile := fileParts[name]
+			if !isFile {
+				entries = append(entries, fmt.Sprintf("%s: %s", jsonString(name), formValue(values[name])))
+				continue
+			}
+			fileVariable := fmt.Sprintf("%s_%s_file", operationIdentifier(operationID), scenarioName(name))
+			initCode += fmt.Sprintf("const %s = open(%s, 'b');\n", fileVariable, jsemit.String(scriptRelativePath(fitnessPath, fileName)))
+			entries = append(entries, fmt.Sprintf("%s: http.file(%s, %s, %s)", jsonString(name), fileVariable, jsemit.String(filepath.Base(fileName)), jsemit.String(multipartPartContentType(media, name, fileName))))
+		}
+		return "{ " + strings.Join(entries, ", ") + " }", initCode, nil
+
+	case encodingXML, encodingText:
+		content, _, _ := lookupRawBodyData(operationID, path, fitnessPath, bodyEncoding(mediaType), media)
+		return jsemit.String(content), "", nil
+
+	case encodingBinary:
+		fileName := findRawBodyFile(operationID, path, fitnessPath, encodingBinary)
+		if fileName == "" {
+			return "", "", fmt.Errorf("no binary body file for operation %s", operationID)
+		}
+		fileVariable := fmt.Sprintf("%s_body_file", operationIdentifier(operationID))
+		return fileVariable, fmt.Sprintf("const %s = open(%s, 'b');\n", fileVariable, jsemit.String(scriptRelativePath(fitnessPath, fileName))), nil
+	}
+
+	return fmt.Sprintf("JSON.stringify(%s)", getBodyData(operationID, path, method, fitnessPath, swagger)), "", nil
+}
+
+// setContentTypeHeader adds the request media type to the headers unless a header file already sets
+// it. Multipart bodies are left to k6, which adds the boundary.
+func setContentTypeHeader(headers map[string]string, contentType string) {
+	if contentType == "" || bodyEncoding(contentType) == encodingMultipart {
+		return
+	}
+	for name := range headers {
+		if strings.EqualFold(name, "Content-Type") {
+			return
+		}
+	}
+	headers["Content-Type"] = contentType
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// RequestMappings names where the values of an operation's request come from, for its path, query,
+// header and cookie parameters and for JSON body fields given as dotted paths such as items.0.id.
+// Datasets map them to columns and flows to extracted variables.
+type RequestMappings struct {
+	Path    map[string]string `yaml:"path" json:"path,omitempty"`
+	Query   map[string]string `yaml:"query" json:"query,omitempty"`
+	Headers map[string]string `yaml:"headers" json:"headers,omitempty"`
+	Cookies map[string]string `yaml:"cookies" json:"cookies,omitempty"`
+	Body    map[string]string `yaml:"body" json:"body,omitempty"`
+}
+
+func (mappings RequestMappings) mapped() bool {
+	return len(mappings.Path) > 0 || len(mappings.Query) > 0 || len(mappings.Headers) > 0 || len(mappings.Cookies) > 0 || len(mappings.Body) > 0
+}
+
+// expressions turns every mapped source into the JavaScript expression reading it.
+func (mappings RequestMappings) expressions(expression func(source string) string) RequestMappings {
+	convert := func(values map[string]string) map[string]string {
+		converted := make(map[string]string, len(values))
+		for name, source := range values {
+			converted[name] = expression(source)
+		}
+		return converted
+	}
+	return RequestMappings{
+		Path:    convert(mappings.Path),
+		Query:   convert(mappings.Query),
+		Headers: convert(mappings.Headers),
+		Cookies: convert(mappings.Cookies),
+		Body:    convert(mappings.Body),
+	}
+}
+
+// merge returns the mappings with those of other added, other winning for the same name.
+func (mappings RequestMappings) merge(other RequestMappings) RequestMappings {
+	combine := func(values map[string]string, overrides map[string]string) map[string]string {
+		combined := make(map[string]string, len(values)+len(overrides))
+		for name, value := range values {
+			combined[name] = value
+		}
+		for name, value := range overrides {
+			combined[name] = value
+		}
+		return combined
+	}
+	return RequestMappings{
+		Path:    combine(mappings.Path, other.Path),
+		Query:   combine(mappings.Query, other.Query),
+		Headers: combine(mappings.Headers, other.Headers),
+		Cookies: combine(mappings.Cookies, other.Cookies),
+		Body:    combine(mappings.Body, other.Body),
+	}
+}
+
+// problems checks that the mapped parameters belong to the operation and that body fields are only
+// mapped for JSON bodies. unknownSource describes a source that cannot be read, or returns "".
+func (mappings RequestMappings) problems(prefix string, details EndpointDetails, unknownSource func(source string) string) []string {
+	var problems []string
+	for _, mapping := range []struct {
+		name       string
+		values     map[string]string
+		parameters []string
+	}{
+		{"path", mappings.Path, pathPlaceholderNames(details.Path)},
+		{"query", mappings.Query, details.QueryParams},
+		{"headers", mappings.Headers, details.HeaderParams},
+		{"cookies", mappings.Cookies, details.CookieParams},
+	} {
+		for _, parameter := range sortedStringKeys(mapping.values) {
+			if !containsString(mapping.parameters, parameter) {
+				problems = append(problems, fmt.Sprintf("%s.%s: operation has no %s parameter %s", prefix, mapping.name, mapping.name, parameter))
+			}
+			if problem := unknownSource(mapping.values[parameter]); problem != "" {
+				problems = append(problems, fmt.Sprintf("%s.%s.%s: %s", prefix, mapping.name, parameter, problem))
+			}
+		}
+	}
+	if len(mappings.Body) > 0 && (details.ContentType == "" || bodyEncoding(details.ContentType) != encodingJSON) {
+		problems = append(problems, fmt.Sprintf("%s.body: operation has no JSON request body", prefix))
+	}
+	for _, field := range sortedStringKeys(mappings.Body) {
+		if problem := unknownSource(mappings.Body[field]); problem != "" {
+			problems = append(problems, fmt.Sprintf("%s.body.%s: %s", prefix, field, problem))
+		}
+	}
+	return problems
+}
+
+// suppliedParameters returns the request values of an operation read from its dataset or flow step.
+func suppliedParameters(validationReport *ValidationReport, operationID string, details EndpointDetails, fitnessPath string) RequestMappings {
+	var mappings RequestMappings
+	if dataset, ok := operationDataset(validationReport.Datasets, operationID, details, fitnessPath); ok {
+		mappings = dataset.RequestMappings
+	}
+	if step, ok := validationReport.Flow.step(operationID); ok {
+		mappings = mappings.merge(step.RequestMappings)
+	}
+	return mappings
+}
+
+// parameterNames returns the names of parameter objects in order.
+func parameterNames(params []map[string]interface{}) []string {
+	names := make([]string, 0, len(params))
+	for _, param := range params {
+		if name, ok := param["name"].(string); ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// unsuppliedParameters returns the parameters whose values are not supplied by a mapping.
+func unsuppliedParameters(params []map[string]interface{}, supplied map[string]string) []map[string]interface{} {
+	var remaining []map[string]interface{}
+	for _, param := range params {
+		name, _ := param["name"].(string)
+		if _, ok := supplied[name]; !ok {
+			remaining = append(remaining, param)
+		}
+	}
+	return remaining
+}
+
+// pathPlaceholderNames returns the {name} placeholders of a templated path in order.
+func pathPlaceholderNames(path string) []string {
+	var names []string
+	for _, match := range pathPlaceholderPattern.FindAllStringSubmatch(path, -1) {
+		names = append(names, match[1])
+	}
+	return names
+}
+
+// bodyFieldsExpression sets fields of a JSON body to JavaScript expressions, keyed by dotted path.
+func bodyFieldsExpression(operationID string, body string, fields map[string]string) (string, error) {
+	var value interface{}
+	if err := json.Unmarshal([]byte(body), &value); err != nil {
+		return "", fmt.Errorf("body of operation %s is not JSON: %v", operationID, err)
+	}
+
+	// Fields are first set to unique markers, which are swapped for the expressions once encoded
+	markers := make(map[string]string, len(fields))
+	for index, field := range sortedStringKeys(fields) {
+		marker := fmt.Sprintf("__request_field_%d__", index)
+		updated, err := setJSONPath(value, strings.Split(field, "."), marker)
+		if err != nil {
+			return "", fmt.Errorf("body field %s of operation %s: %v", field, operationID, err)
+		}
+		value = updated
+		markers[jsonString(marker)] = fields[field]
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	expression := string(encoded)
+	for marker, replacement := range markers {
+		expression = strings.Replace(expression, marker, replacement, 1)
+	}
+	return fmt.Sprintf("JSON.stringify(%s)", expression), nil
+}
+
+// setJSONPath sets the value at a dotted path of decoded JSON, creating the missing objects.
+func setJSONPath(value interface{}, path []string, field interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return field, nil
+	}
+
+	switch typed := value.(type) {
+	case []interface{}:
+		index, err := strconv.Atoi(path[0])
+		if err != nil || index < 0 || index >= len(typed) {
+			return nil, fmt.Errorf("no array element %s", path[0])
+		}
+		updated, err := setJSONPath(typed[index], path[1:], field)
+		if err != nil {
+			return nil, err
+		}
+		typed[index] = updated
+		return typed, nil
+
+	case map[string]interface{}:
+		updated, err := setJSONPath(typed[path[0]], path[1:], field)
+		if err != nil {
+			return nil, err
+		}
+		typed[path[0]] = updated
+		return typed, nil
+
+	case nil:
+		updated, err := setJSONPath(nil, path[1:], field)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{path[0]: updated}, nil
+	}
+	return nil, fmt.Errorf("%s is not an object", path[0])
+}
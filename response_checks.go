@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxResponseCheckDepth limits how deep nested response properties are compiled into checks.
+const maxResponseCheckDepth = 3
+
+// expectedStatusCodes derives the status codes a successful call may return from the operation's
+// responses: declared 2xx/3xx codes (or 2XX-style ranges) when present, otherwise every declared
+// code, falling back to 200 when responses are absent.
+func expectedStatusCodes(operation map[string]interface{}) []string {
+	responses, ok := operation["responses"].(map[string]interface{})
+	if !ok || len(responses) == 0 {
+		return []string{"200"}
+	}
+
+	var success, declared []string
+	for code := range responses {
+		normalized := strings.ToUpper(code)
+		if normalized == "DEFAULT" {
+			continue
+		}
+		declared = append(declared, normalized)
+		if strings.HasPrefix(normalized, "2") || strings.HasPrefix(normalized, "3") {
+			success = append(success, normalized)
+		}
+	}
+
+	codes := success
+	if len(codes) == 0 {
+		codes = declared
+	}
+	if len(codes) == 0 {
+		return []string{"200"}
+	}
+
+	sort.Strings(codes)
+	return codes
+}
+
+// statusCheckExpression builds the JavaScript condition for the expected status codes of a response r.
+func statusCheckExpression(codes []string) string {
+	var exact []string
+	var conditions []string
+	for _, code := range codes {
+		if strings.HasSuffix(code, "XX") && len(code) == 3 {
+			if base, err := strconv.Atoi(code[:1]); err == nil {
+				conditions = append(conditions, fmt.Sprintf("(r.status >= %d && r.status < %d)", base*100, base*100+100))
+			}
+			continue
+		}
+		if _, err := strconv.Atoi(code); err == nil {
+			exact = append(exact, code)
+		}
+	}
+
+	if len(exact) == 1 {
+		conditions = append([]string{fmt.Sprintf("r.status == %s", exact[0])}, conditions...)
+	} else if len(exact) > 1 {
+		conditions = append([]string{fmt.Sprintf("[%s].includes(r.status)", strings.Join(exact, ", "))}, conditions...)
+	}
+	if len(conditions) == 0 {
+		return "r.status == 200"
+	}
+	return strings.Join(conditions, " || ")
+}
+
+// responseSchemaFor returns the JSON schema of the first expected response that declares one.
+func responseSchemaFor(operation map[string]interface{}, codes []string) map[string]interface{} {
+	responses, ok := operation["responses"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	for _, code := range codes {
+		response, ok := responses[code].(map[string]interface{})
+		if !ok {
+			response, ok = responses[strings.ToLower(code)].(map[string]interface{})
+		}
+		if !ok {
+			continue
+		}
+
+		content, ok := response["content"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		mediaTypes := make([]string, 0, len(content))
+		for mediaType := range content {
+			mediaTypes = append(mediaTypes, mediaType)
+		}
+		sort.Strings(mediaTypes)
+
+		for _, mediaType := range mediaTypes {
+			if mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json") {
+				continue
+			}
+			if mediaTypeObject, ok := content[mediaType].(map[string]interface{}); ok {
+				if schema, ok := mediaTypeObject["schema"].(map[string]interface{}); ok {
+					return schema
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// compileResponseSchemaCheck turns the required fields and property types of a response schema into a
+// JavaScript boolean expression over the variable named by expr.
+func compileResponseSchemaCheck(schema map[string]interface{}, expr string, depth int) string {
+	if schema == nil || depth > maxResponseCheckDepth {
+		return "true"
+	}
+	if _, unresolved := schema["$ref"]; unresolved {
+		return "true"
+	}
+	if allOf, ok := schema["allOf"].([]interface{}); ok && len(allOf) > 0 {
+		schema = mergeAllOf(schema, allOf)
+	}
+
+	var conditions []string
+	switch schemaType(schema) {
+	case "object":
+		conditions = append(conditions, fmt.Sprintf("typeof %s === 'object' && %s !== null && !Array.isArray(%s)", expr, expr, expr))
+
+		properties, _ := schema["properties"].(map[string]interface{})
+		for _, required := range requiredList(schema) {
+			name, ok := required.(string)
+			if !ok {
+				continue
+			}
+			// A writeOnly property is never returned, even when required
+			if property, _ := properties[name].(map[string]interface{}); omittedProperty(property, schemaResponse) {
+				continue
+			}
+			conditions = append(conditions, fmt.Sprintf("%s !== undefined", propertyAccess(expr, name)))
+		}
+
+		names := make([]string, 0, len(properties))
+		for name := range properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			property, ok := properties[name].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			access := propertyAccess(expr, name)
+			propertyCheck := compileResponseSchemaCheck(property, access, depth+1)
+			if propertyCheck == "true" {
+				continue
+			}
+			conditions = append(conditions, fmt.Sprintf("(%s === undefined || %s === null || (%s))", access, access, propertyCheck))
+		}
+	case "array":
+		conditions = append(conditions, fmt.Sprintf("Array.isArray(%s)", expr))
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			item := fmt.Sprintf("item%d", depth)
+			if itemCheck := compileResponseSchemaCheck(items, item, depth+1); itemCheck != "true" {
+				conditions = append(conditions, fmt.Sprintf("%s.every((%s) => %s)", expr, item, itemCheck))
+			}
+		}
+	case "string":
+		conditions = append(conditions, fmt.Sprintf("typeof %s === 'string'", expr))
+	case "integer":
+		conditions = append(conditions, fmt.Sprintf("Number.isInteger(%s)", expr))
+	case "number":
+		conditions = append(conditions, fmt.Sprintf("typeof %s === 'number'", expr))
+	case "boolean":
+		conditions = append(conditions, fmt.Sprintf("typeof %s === 'boolean'", expr))
+	}
+
+	if len(conditions) == 0 {
+		return "true"
+	}
+	return strings.Join(conditions, " && ")
+}
+
+// propertyAccess builds a bracket property access with a safely quoted property name.
+func propertyAccess(expr string, name string) string {
+	quoted, err := json.Marshal(name)
+	if err != nil {
+		quoted = []byte(`""`)
+	}
+	return fmt.Sprintf("%s[%s]", expr, quoted)
+}
+
+// findOperation returns the operation object for a path and method in the Swagger document.
+func findOperation(swagger map[string]interface{}, path string, method string) map[string]interface{} {
+	paths, ok := swagger["paths"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	pathItem, ok := paths[path].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	operation, _ := pathItem[method].(map[string]interface{})
+	return operation
+}
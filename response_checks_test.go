@@ -0,0 +1,25 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResponseSchemaCheckSkipsWriteOnlyRequired(t *testing.T) {
+	schema := map[string]interface{}{
+		"type":     "object",
+		"required": []interface{}{"id", "password"},
+		"properties": map[string]interface{}{
+			"id":       map[string]interface{}{"type": "string"},
+			"password": map[string]interface{}{"type": "string", "writeOnly": true},
+		},
+	}
+
+	check := compileResponseSchemaCheck(schema, "body", 0)
+	if !strings.Contains(check, propertyAccess("body", "id")+" !== undefined") {
+		t.Errorf("check does not require id: %s", check)
+	}
+	if strings.Contains(check, propertyAccess("body", "password")+" !== undefined") {
+		t.Errorf("check requires the writeOnly password: %s", check)
+	}
+}
@@ -0,0 +1,446 @@
+package main
+
+import (
+	"math"
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"strings"
+)
+
+// maxSampleDepth bounds nested object/array generation, which also stops recursive schemas.
+const maxSampleDepth = 8
+
+// stringFormatSamples are used for string schemas with a well-known format.
+var stringFormatSamples = map[string]string{
+	"date-time": "2024-01-01T00:00:00Z",
+	"date":      "2024-01-01",
+	"time":      "00:00:00",
+	"email":     "user@example.com",
+	"uuid":      "3fa85f64-5717-4562-b3fc-2c963f66afa6",
+	"uri":       "https://example.com",
+	"url":       "https://example.com",
+	"hostname":  "example.com",
+	"ipv4":      "192.0.2.1",
+	"ipv6":      "2001:db8::1",
+	"byte":      "ZXhhbXBsZQ==",
+	"binary":    "",
+	"password":  "P@ssw0rd1",
+}
+
+// synthesizeSchemaSample builds a sample value that satisfies a resolved JSON Schema:
+// explicit example/default/const/enum values win, otherwise the sample is derived from
+// type, format, ranges, length limits, pattern and composition keywords.
+func synthesizeSchemaSample(schema map[string]interface{}) interface{} {
+	return sampleSchema(schema, 0)
+}
+
+func sampleSchema(schema map[string]interface{}, depth int) interface{} {
+	if schema == nil || depth > maxSampleDepth {
+		return nil
+	}
+
+	for _, key := range []string{"example", "default", "const"} {
+		if value, ok := schema[key]; ok && value != nil {
+			return value
+		}
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok && len(enum) > 0 {
+		return enum[0]
+	}
+
+	if allOf, ok := schema["allOf"].([]interface{}); ok && len(allOf) > 0 {
+		return sampleSchema(mergeAllOf(schema, allOf), depth)
+	}
+	for _, key := range []string{"oneOf", "anyOf"} {
+		if variants, ok := schema[key].([]interface{}); ok && len(variants) > 0 {
+			if variant, ok := variants[0].(map[string]interface{}); ok {
+				return sampleSchema(variant, depth+1)
+			}
+		}
+	}
+
+	switch schemaType(schema) {
+	case "object":
+		return sampleObject(schema, depth)
+	case "array":
+		return sampleArray(schema, depth)
+	case "integer":
+		return int64(sampleNumber(schema, true))
+	case "number":
+		return sampleNumber(schema, false)
+	case "boolean":
+		return true
+	case "null":
+		return nil
+	default:
+		return sampleString(schema)
+	}
+}
+
+// schemaType returns the declared type, inferring object/array from properties/items when absent.
+// OpenAPI 3.1 type arrays use the first non-null entry.
+func schemaType(schema map[string]interface{}) string {
+	switch t := schema["type"].(type) {
+	case string:
+		return t
+	case []interface{}:
+		for _, entry := range t {
+			if name, ok := entry.(string); ok && name != "null" {
+				return name
+			}
+		}
+	}
+
+	if _, ok := schema["properties"]; ok {
+		return "object"
+	}
+	if _, ok := schema["additionalProperties"]; ok {
+		return "object"
+	}
+	if _, ok := schema["items"]; ok {
+		return "array"
+	}
+	return ""
+}
+
+// mergeAllOf folds allOf subschemas into a single object schema.
+func mergeAllOf(schema map[string]interface{}, allOf []interface{}) map[string]interface{} {
+	merged := make(map[string]interface{})
+	for key, value := range schema {
+		if key != "allOf" {
+			merged[key] = value
+		}
+	}
+
+	properties := make(map[string]interface{})
+	if own, ok := schema["properties"].(map[string]interface{}); ok {
+		for name, property := range own {
+			properties[name] = property
+		}
+	}
+	required := append([]interface{}{}, requiredList(schema)...)
+
+	for _, part := range allOf {
+		partMap, ok := part.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if nested, ok := partMap["allOf"].([]interface{}); ok {
+			partMap = mergeAllOf(partMap, nested)
+		}
+		for key, value := range partMap {
+			switch key {
+			case "properties":
+				if partProperties, ok := value.(map[string]interface{}); ok {
+					for name, property := range partProperties {
+						properties[name] = property
+					}
+				}
+			case "required":
+				required = append(required, requiredList(partMap)...)
+			default:
+				if _, exists := merged[key]; !exists {
+					merged[key] = value
+				}
+			}
+		}
+	}
+
+	if len(properties) > 0 {
+		merged["properties"] = properties
+		if _, ok := merged["type"]; !ok {
+			merged["type"] = "object"
+		}
+	}
+	if len(required) > 0 {
+		merged["required"] = required
+	}
+	return merged
+}
+
+func requiredList(schema map[string]interface{}) []interface{} {
+	required, _ := schema["required"].([]interface{})
+	return required
+}
+
+func sampleObject(schema map[string]interface{}, depth int) interface{} {
+	result := make(map[string]interface{})
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		property, ok := properties[name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		// Request payloads must not carry server-populated fields
+		if readOnly, ok := property["readOnly"].(bool); ok && readOnly {
+			continue
+		}
+		if _, unresolved := property["$ref"]; unresolved {
+			// Circular reference left in place by the resolver
+			if isRequiredProperty(schema, name) {
+				result[name] = map[string]interface{}{}
+			}
+			continue
+		}
+		if depth >= maxSampleDepth && !isRequiredProperty(schema, name) {
+			continue
+		}
+		result[name] = sampleSchema(property, depth+1)
+	}
+
+	if len(result) == 0 {
+		if additional, ok := schema["additionalProperties"].(map[string]interface{}); ok {
+			result["key"] = sampleSchema(additional, depth+1)
+		}
+	}
+	return result
+}
+
+func isRequiredProperty(schema map[string]interface{}, name string) bool {
+	for _, required := range requiredList(schema) {
+		if required == name {
+			return true
+		}
+	}
+	return false
+}
+
+func sampleArray(schema map[string]interface{}, depth int) interface{} {
+	count := 1
+	if minItems, ok := numericKeyword(schema, "minItems"); ok && int(minItems) > count {
+		count = int(minItems)
+	}
+	if maxItems, ok := numericKeyword(schema, "maxItems"); ok && int(maxItems) < count {
+		count = int(maxItems)
+	}
+
+	items, _ := schema["items"].(map[string]interface{})
+	result := make([]interface{}, 0, count)
+	if items == nil || depth >= maxSampleDepth {
+		return result
+	}
+	if _, unresolved := items["$ref"]; unresolved {
+		return result
+	}
+
+	for i := 0; i < count; i++ {
+		item := sampleSchema(items, depth+1)
+		// Vary primitive items so uniqueItems constraints still hold
+		if i > 0 {
+			item = sampleVariation(items, item, i)
+		}
+		result = append(result, item)
+	}
+	return result
+}
+
+// sampleVariation returns the i-th variation of a primitive item sample, or the sample itself when
+// varying it would break the item schema's enum, format, pattern, length or range.
+func sampleVariation(items map[string]interface{}, item interface{}, i int) interface{} {
+	if _, hasEnum := items["enum"]; hasEnum {
+		return item
+	}
+
+	switch v := item.(type) {
+	case int64:
+		if varied, ok := variedNumber(items, float64(v), i); ok {
+			return int64(varied)
+		}
+	case float64:
+		if varied, ok := variedNumber(items, v, i); ok {
+			return varied
+		}
+	case string:
+		if _, hasPattern := items["pattern"]; hasPattern {
+			return item
+		}
+		if _, hasFormat := items["format"]; hasFormat {
+			return item
+		}
+		varied := v + strings.Repeat("x", i)
+		if maxLength, ok := numericKeyword(items, "maxLength"); ok && float64(len(varied)) > maxLength {
+			return item
+		}
+		return varied
+	}
+	return item
+}
+
+// variedNumber adds i whole steps of multipleOf, or i when there is none, unless that passes the maximum.
+func variedNumber(items map[string]interface{}, number float64, i int) (float64, bool) {
+	step := 1.0
+	if multipleOf, ok := numericKeyword(items, "multipleOf"); ok && multipleOf > 0 {
+		step = math.Ceil(1/multipleOf) * multipleOf
+	}
+	varied := number + step*float64(i)
+	if maximum, ok := numericKeyword(items, "maximum"); ok {
+		if exclusive, _ := items["exclusiveMaximum"].(bool); varied > maximum || (exclusive && varied >= maximum) {
+			return 0, false
+		}
+	}
+	if exclusiveMaximum, ok := numericKeyword(items, "exclusiveMaximum"); ok && varied >= exclusiveMaximum {
+		return 0, false
+	}
+	return varied, true
+}
+
+func sampleNumber(schema map[string]interface{}, integer bool) float64 {
+	value := 1.0
+	if !integer {
+		value = 1.5
+	}
+
+	minimum, hasMinimum := numericKeyword(schema, "minimum")
+	maximum, hasMaximum := numericKeyword(schema, "maximum")
+	step := 1.0
+	if !integer {
+		step = 0.5
+	}
+
+	// OpenAPI 3.0 uses boolean exclusive flags, OpenAPI 3.1 uses numeric bounds
+	if exclusive, ok := schema["exclusiveMinimum"].(bool); ok && exclusive && hasMinimum {
+		minimum += step
+	} else if exclusiveMinimum, ok := numericKeyword(schema, "exclusiveMinimum"); ok {
+		minimum, hasMinimum = exclusiveMinimum+step, true
+	}
+	if exclusive, ok := schema["exclusiveMaximum"].(bool); ok && exclusive && hasMaximum {
+		maximum -= step
+	} else if exclusiveMaximum, ok := numericKeyword(schema, "exclusiveMaximum"); ok {
+		maximum, hasMaximum = exclusiveMaximum-step, true
+	}
+
+	if hasMinimum && value < minimum {
+		value = minimum
+	}
+	if hasMaximum && value > maximum {
+		value = maximum
+	}
+
+	if multipleOf, ok := numericKeyword(schema, "multipleOf"); ok && multipleOf > 0 {
+		value = math.Ceil(value/multipleOf) * multipleOf
+		if hasMaximum && value > maximum {
+			value = math.Floor(maximum/multipleOf) * multipleOf
+		}
+	}
+
+	if integer {
+		if hasMinimum {
+			return math.Ceil(value)
+		}
+		return math.Floor(value)
+	}
+	return value
+}
+
+func sampleString(schema map[string]interface{}) string {
+	value := "string"
+	format, _ := schema["format"].(string)
+	if formatSample, ok := stringFormatSamples[format]; ok {
+		value = formatSample
+	}
+
+	if pattern, ok := schema["pattern"].(string); ok && pattern != "" {
+		if patternSample, ok := samplePattern(pattern); ok {
+			return patternSample
+		}
+	}
+
+	if minLength, ok := numericKeyword(schema, "minLength"); ok && len(value) < int(minLength) {
+		value += strings.Repeat("x", int(minLength)-len(value))
+	}
+	if maxLength, ok := numericKeyword(schema, "maxLength"); ok && len(value) > int(maxLength) {
+		value = value[:int(maxLength)]
+	}
+	return value
+}
+
+// samplePattern produces the shortest string the regular expression accepts by walking its syntax tree.
+func samplePattern(pattern string) (string, bool) {
+	parsed, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return "", false
+	}
+
+	var builder strings.Builder
+	if !writePatternSample(&builder, parsed.Simplify()) {
+		return "", false
+	}
+
+	sample := builder.String()
+	compiled, err := regexp.Compile(pattern)
+	if err != nil || !compiled.MatchString(sample) {
+		return "", false
+	}
+	return sample, true
+}
+
+func writePatternSample(builder *strings.Builder, re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpLiteral:
+		builder.WriteString(string(re.Rune))
+	case syntax.OpCharClass:
+		if len(re.Rune) == 0 {
+			return false
+		}
+		builder.WriteRune(preferredClassRune(re.Rune))
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		builder.WriteRune('a')
+	case syntax.OpCapture:
+		return writePatternSample(builder, re.Sub[0])
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			if !writePatternSample(builder, sub) {
+				return false
+			}
+		}
+	case syntax.OpAlternate:
+		return writePatternSample(builder, re.Sub[0])
+	case syntax.OpPlus:
+		return writePatternSample(builder, re.Sub[0])
+	case syntax.OpRepeat:
+		for i := 0; i < re.Min; i++ {
+			if !writePatternSample(builder, re.Sub[0]) {
+				return false
+			}
+		}
+	case syntax.OpStar, syntax.OpQuest, syntax.OpEmptyMatch,
+		syntax.OpBeginLine, syntax.OpEndLine, syntax.OpBeginText, syntax.OpEndText,
+		syntax.OpWordBoundary, syntax.OpNoWordBoundary:
+		// Zero-width or optional parts add nothing to the shortest sample
+	default:
+		return false
+	}
+	return true
+}
+
+// preferredClassRune picks a readable rune from a character class, favouring letters and digits.
+func preferredClassRune(ranges []rune) rune {
+	for _, preferred := range []rune{'a', 'A', '1', '0'} {
+		for i := 0; i+1 < len(ranges); i += 2 {
+			if preferred >= ranges[i] && preferred <= ranges[i+1] {
+				return preferred
+			}
+		}
+	}
+	return ranges[0]
+}
+
+func numericKeyword(schema map[string]interface{}, key string) (float64, bool) {
+	switch v := schema[key].(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
@@ -0,0 +1,50 @@
+package main
+
+import "testing"
+
+func TestSampleArrayItemsStayInsideItemSchema(t *testing.T) {
+	cases := []struct {
+		name  string
+		items map[string]interface{}
+		valid func(item interface{}) bool
+	}{
+		{
+			"maxLength",
+			map[string]interface{}{"type": "string", "maxLength": 6.0},
+			func(item interface{}) bool { return len(item.(string)) <= 6 },
+		},
+		{
+			"format",
+			map[string]interface{}{"type": "string", "format": "uuid"},
+			func(item interface{}) bool { return item == stringFormatSamples["uuid"] },
+		},
+		{
+			"enum",
+			map[string]interface{}{"type": "string", "enum": []interface{}{"a"}},
+			func(item interface{}) bool { return item == "a" },
+		},
+		{
+			"maximum",
+			map[string]interface{}{"type": "integer", "minimum": 1.0, "maximum": 2.0},
+			func(item interface{}) bool { return item.(int64) >= 1 && item.(int64) <= 2 },
+		},
+		{
+			"multipleOf",
+			map[string]interface{}{"type": "integer", "multipleOf": 5.0},
+			func(item interface{}) bool { return item.(int64)%5 == 0 },
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sample := sampleArray(map[string]interface{}{"type": "array", "minItems": 4.0, "items": c.items}, 0).([]interface{})
+			if len(sample) != 4 {
+				t.Fatalf("got %d items, want 4", len(sample))
+			}
+			for _, item := range sample {
+				if !c.valid(item) {
+					t.Errorf("item %#v breaks the item schema %v", item, c.items)
+				}
+			}
+		})
+	}
+}
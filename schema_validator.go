@@ -0,0 +1,408 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	emailFormatPattern = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+	uuidFormatPattern  = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
+// schemaValidationResult collects violations found while checking a value against a JSON Schema.
+type schemaValidationResult struct {
+	MissingRequiredProperties []string
+	TypeValidationErrors      []string
+}
+
+func (r *schemaValidationResult) valid() bool {
+	return len(r.MissingRequiredProperties) == 0 && len(r.TypeValidationErrors) == 0
+}
+
+func (r *schemaValidationResult) addError(path string, format string, args ...interface{}) {
+	r.TypeValidationErrors = append(r.TypeValidationErrors, fmt.Sprintf("%s: %s", path, fmt.Sprintf(format, args...)))
+}
+
+// schemaDirection tells whether a body is sent in a request or received in a response.
+type schemaDirection int
+
+const (
+	schemaRequest schemaDirection = iota
+	schemaResponse
+)
+
+// validateBodyAgainstSchema checks a decoded body against a resolved schema: required properties,
+// types, enums, formats, additionalProperties, string length, patterns, numeric ranges, array sizes
+// and allOf/oneOf/anyOf composition. Required readOnly properties are not expected in a request
+// body, nor required writeOnly ones in a response.
+func validateBodyAgainstSchema(value interface{}, schema map[string]interface{}, direction schemaDirection) schemaValidationResult {
+	var result schemaValidationResult
+	validateSchemaValue(value, schema, "$", direction, &result)
+	return result
+}
+
+func validateSchemaValue(value interface{}, schema map[string]interface{}, path string, direction schemaDirection, result *schemaValidationResult) {
+	if schema == nil {
+		return
+	}
+	if _, unresolved := schema["$ref"]; unresolved {
+		// Circular reference left in place by the resolver; nothing more to check at this depth
+		return
+	}
+
+	if value == nil {
+		if nullable, ok := schema["nullable"].(bool); ok && nullable {
+			return
+		}
+		if schemaAllowsType(schema, "null") {
+			return
+		}
+		if schemaType(schema) != "" {
+			result.addError(path, "expected %s but got null", schemaType(schema))
+		}
+		return
+	}
+
+	if allOf, ok := schema["allOf"].([]interface{}); ok {
+		for _, part := range allOf {
+			if partMap, ok := part.(map[string]interface{}); ok {
+				validateSchemaValue(value, partMap, path, direction, result)
+			}
+		}
+	}
+	for _, key := range []string{"oneOf", "anyOf"} {
+		variants, ok := schema[key].([]interface{})
+		if !ok || len(variants) == 0 {
+			continue
+		}
+		matched := 0
+		for _, variant := range variants {
+			variantMap, ok := variant.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			var variantResult schemaValidationResult
+			validateSchemaValue(value, variantMap, path, direction, &variantResult)
+			if variantResult.valid() {
+				matched++
+			}
+		}
+		if matched == 0 {
+			result.addError(path, "does not match any %s schema", key)
+		} else if key == "oneOf" && matched > 1 {
+			result.addError(path, "matches %d oneOf schemas, expected exactly one", matched)
+		}
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok && len(enum) > 0 {
+		found := false
+		for _, allowed := range enum {
+			if jsonEqual(allowed, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			result.addError(path, "value %v is not one of %v", formatSchemaValue(value), enum)
+		}
+	}
+
+	expectedType := schemaType(schema)
+	if expectedType == "" {
+		return
+	}
+	if !valueMatchesType(value, expectedType) && !schemaAllowsType(schema, jsonTypeName(value)) {
+		result.addError(path, "expected %s but got %s", expectedType, jsonTypeName(value))
+		return
+	}
+
+	// A 3.1 type list lets the value be of another type than the first one listed, so the
+	// keywords applied follow the value's own type
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		validateSchemaObject(typed, schema, path, direction, result)
+	case []interface{}:
+		validateSchemaArray(typed, schema, path, direction, result)
+	case string:
+		validateSchemaString(typed, schema, path, result)
+	default:
+		if number, ok := numericValue(value); ok {
+			validateSchemaNumber(number, schema, path, result)
+		}
+	}
+}
+
+func validateSchemaObject(object map[string]interface{}, schema map[string]interface{}, path string, direction schemaDirection, result *schemaValidationResult) {
+	properties, _ := schema["properties"].(map[string]interface{})
+	for _, required := range requiredList(schema) {
+		name, ok := required.(string)
+		if !ok {
+			continue
+		}
+		if property, _ := properties[name].(map[string]interface{}); omittedProperty(property, direction) {
+			continue
+		}
+		if _, exists := object[name]; !exists {
+			result.MissingRequiredProperties = append(result.MissingRequiredProperties, joinSchemaPath(path, name))
+		}
+	}
+
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		propertyPath := joinSchemaPath(path, name)
+		if property, ok := properties[name].(map[string]interface{}); ok {
+			validateSchemaValue(object[name], property, propertyPath, direction, result)
+			continue
+		}
+
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				result.addError(propertyPath, "additional property is not allowed")
+			}
+		case map[string]interface{}:
+			validateSchemaValue(object[name], additional, propertyPath, direction, result)
+		}
+	}
+}
+
+// omittedProperty reports whether a property is left out of bodies going in the direction: readOnly
+// properties of requests and writeOnly properties of responses.
+func omittedProperty(property map[string]interface{}, direction schemaDirection) bool {
+	keyword := "readOnly"
+	if direction == schemaResponse {
+		keyword = "writeOnly"
+	}
+	omitted, _ := property[keyword].(bool)
+	return omitted
+}
+
+func validateSchemaArray(items []interface{}, schema map[string]interface{}, path string, direction schemaDirection, result *schemaValidationResult) {
+	if minItems, ok := numericKeyword(schema, "minItems"); ok && float64(len(items)) < minItems {
+		result.addError(path, "expected at least %v items but got %d", minItems, len(items))
+	}
+	if maxItems, ok := numericKeyword(schema, "maxItems"); ok && float64(len(items)) > maxItems {
+		result.addError(path, "expected at most %v items but got %d", maxItems, len(items))
+	}
+	if unique, ok := schema["uniqueItems"].(bool); ok && unique {
+		for i := range items {
+			for j := i + 1; j < len(items); j++ {
+				if jsonEqual(items[i], items[j]) {
+					result.addError(path, "items %d and %d are not unique", i, j)
+				}
+			}
+		}
+	}
+
+	itemSchema, ok := schema["items"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	for i, item := range items {
+		validateSchemaValue(item, itemSchema, fmt.Sprintf("%s[%d]", path, i), direction, result)
+	}
+}
+
+func validateSchemaString(value string, schema map[string]interface{}, path string, result *schemaValidationResult) {
+	length := float64(utf8.RuneCountInString(value))
+	if minLength, ok := numericKeyword(schema, "minLength"); ok && length < minLength {
+		result.addError(path, "length %v is shorter than minLength %v", length, minLength)
+	}
+	if maxLength, ok := numericKeyword(schema, "maxLength"); ok && length > maxLength {
+		result.addError(path, "length %v is longer than maxLength %v", length, maxLength)
+	}
+
+	if pattern, ok := schema["pattern"].(string); ok && pattern != "" {
+		if compiled, err := regexp.Compile(pattern); err == nil && !compiled.MatchString(value) {
+			result.addError(path, "value %q does not match pattern %s", value, pattern)
+		}
+	}
+
+	if format, ok := schema["format"].(string); ok && !stringMatchesFormat(value, format) {
+		result.addError(path, "value %q is not a valid %s", value, format)
+	}
+}
+
+func validateSchemaNumber(value float64, schema map[string]interface{}, path string, result *schemaValidationResult) {
+	if minimum, ok := numericKeyword(schema, "minimum"); ok {
+		if exclusive, _ := schema["exclusiveMinimum"].(bool); exclusive && value <= minimum {
+			result.addError(path, "value %v must be greater than %v", value, minimum)
+		} else if value < minimum {
+			result.addError(path, "value %v is less than minimum %v", value, minimum)
+		}
+	}
+	if exclusiveMinimum, ok := numericKeyword(schema, "exclusiveMinimum"); ok && value <= exclusiveMinimum {
+		result.addError(path, "value %v must be greater than %v", value, exclusiveMinimum)
+	}
+
+	if maximum, ok := numericKeyword(schema, "maximum"); ok {
+		if exclusive, _ := schema["exclusiveMaximum"].(bool); exclusive && value >= maximum {
+			result.addError(path, "value %v must be less than %v", value, maximum)
+		} else if value > maximum {
+			result.addError(path, "value %v is greater than maximum %v", value, maximum)
+		}
+	}
+	if exclusiveMaximum, ok := numericKeyword(schema, "exclusiveMaximum"); ok && value >= exclusiveMaximum {
+		result.addError(path, "value %v must be less than %v", value, exclusiveMaximum)
+	}
+
+	if multipleOf, ok := numericKeyword(schema, "multipleOf"); ok && multipleOf > 0 {
+		quotient := value / multipleOf
+		if math.Abs(quotient-math.Round(quotient)) > 1e-9 {
+			result.addError(path, "value %v is not a multiple of %v", value, multipleOf)
+		}
+	}
+}
+
+func stringMatchesFormat(value string, format string) bool {
+	switch format {
+	case "email":
+		return emailFormatPattern.MatchString(value)
+	case "uuid":
+		return uuidFormatPattern.MatchString(value)
+	case "date":
+		_, err := time.Parse("2006-01-02", value)
+		return err == nil
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, value)
+		return err == nil
+	case "ipv4":
+		ip := net.ParseIP(value)
+		return ip != nil && ip.To4() != nil && strings.Contains(value, ".")
+	case "ipv6":
+		ip := net.ParseIP(value)
+		return ip != nil && strings.Contains(value, ":")
+	case "uri", "url":
+		parsed, err := url.Parse(value)
+		return err == nil && parsed.Scheme != ""
+	}
+	// Unknown formats are annotations only
+	return true
+}
+
+func valueMatchesType(value interface{}, expectedType string) bool {
+	switch expectedType {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "integer":
+		number, ok := numericValue(value)
+		return ok && number == math.Trunc(number)
+	case "number":
+		_, ok := numericValue(value)
+		return ok
+	case "null":
+		return value == nil
+	}
+	return true
+}
+
+// schemaAllowsType supports OpenAPI 3.1 type arrays such as ["string", "null"].
+func schemaAllowsType(schema map[string]interface{}, typeName string) bool {
+	types, ok := schema["type"].([]interface{})
+	if !ok {
+		return false
+	}
+	for _, entry := range types {
+		if entry == typeName || (entry == "number" && typeName == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonTypeName(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	default:
+		if number, ok := numericValue(v); ok {
+			if number == math.Trunc(number) {
+				return "integer"
+			}
+			return "number"
+		}
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func numericValue(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// jsonEqual compares decoded values the way JSON would, so 1 and 1.0 are equal.
+func jsonEqual(a, b interface{}) bool {
+	left, errLeft := json.Marshal(a)
+	right, errRight := json.Marshal(b)
+	return errLeft == nil && errRight == nil && string(left) == string(right)
+}
+
+func formatSchemaValue(value interface{}) string {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(encoded)
+}
+
+func joinSchemaPath(path string, name string) string {
+	return path + "." + name
+}
+
+// parseBodyFileContent decodes a JSON or YAML body file.
+func parseBodyFileContent(content string) (interface{}, error) {
+	var value interface{}
+	if err := json.Unmarshal([]byte(content), &value); err == nil {
+		return value, nil
+	}
+
+	if err := yaml.Unmarshal([]byte(content), &value); err != nil {
+		return nil, err
+	}
+	return normalizeYAMLValue(value), nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestValidateBodyAgainstSchemaTypeList(t *testing.T) {
+	schema := map[string]interface{}{
+		"type":      []interface{}{"object", "string"},
+		"minLength": 5.0,
+		"required":  []interface{}{"id"},
+	}
+
+	cases := []struct {
+		name   string
+		value  interface{}
+		errors int
+	}{
+		{"object", map[string]interface{}{"id": "1"}, 0},
+		{"object missing required", map[string]interface{}{}, 1},
+		{"string", "abcdef", 0},
+		{"short string", "abc", 1},
+		{"other type", 3.0, 1},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			result := validateBodyAgainstSchema(c.value, schema, schemaRequest)
+			if got := len(result.MissingRequiredProperties) + len(result.TypeValidationErrors); got != c.errors {
+				t.Fatalf("got %d violations, want %d: %+v", got, c.errors, result)
+			}
+		})
+	}
+}
+
+func TestValidateBodyAgainstSchemaOmittedProperties(t *testing.T) {
+	schema := map[string]interface{}{
+		"type":     "object",
+		"required": []interface{}{"id", "password", "name"},
+		"properties": map[string]interface{}{
+			"id":       map[string]interface{}{"type": "string", "readOnly": true},
+			"password": map[string]interface{}{"type": "string", "writeOnly": true},
+			"name":     map[string]interface{}{"type": "string"},
+		},
+	}
+
+	cases := []struct {
+		direction schemaDirection
+		body      map[string]interface{}
+		missing   []string
+	}{
+		{schemaRequest, map[string]interface{}{"password": "p", "name": "n"}, nil},
+		{schemaRequest, map[string]interface{}{"name": "n"}, []string{"$.password"}},
+		{schemaResponse, map[string]interface{}{"id": "1", "name": "n"}, nil},
+		{schemaResponse, map[string]interface{}{"name": "n"}, []string{"$.id"}},
+	}
+	for _, c := range cases {
+		result := validateBodyAgainstSchema(c.body, schema, c.direction)
+		if fmt.Sprint(result.MissingRequiredProperties) != fmt.Sprint(c.missing) {
+			t.Errorf("direction %d, body %v: missing %v, want %v", c.direction, c.body, result.MissingRequiredProperties, c.missing)
+		}
+	}
+}
@@ -0,0 +1,338 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"k6-generator/envrefs"
+	"k6-generator/jsemit"
+
+	"gopkg.in/yaml.v2"
+)
+
+// securityCredentialsFileName holds the credentials of each security scheme in an environment folder:
+//
+//	bearerAuth:
+//	  token: eyJ...
+//	basicAuth:
+//	  username: tester
+//	  password: secret
+//	apiKeyAuth:
+//	  value: 0123
+//	oauth:
+//	  clientId: k6
+//	  clientSecret: secret
+//	  scope: read write
+const securityCredentialsFileName = "security.yaml"
+
+// securityAuthObject is the object returned by setup() that requests read tokens and keys from.
+const securityAuthObject = "data.auth"
+
+// securitySchemesOf returns components.securitySchemes of the document.
+func securitySchemesOf(swagger map[string]interface{}) map[string]interface{} {
+	components, _ := swagger["components"].(map[string]interface{})
+	schemes, _ := components["securitySchemes"].(map[string]interface{})
+	return schemes
+}
+
+// operationSecurity returns the security requirements of an operation, falling back to the document's.
+// An explicit empty list on the operation turns security off.
+func operationSecurity(swagger map[string]interface{}, operation map[string]interface{}) []interface{} {
+	if requirements, ok := operation["security"].([]interface{}); ok {
+		return requirements
+	}
+	requirements, _ := swagger["security"].([]interface{})
+	return requirements
+}
+
+// loadSecurityCredentials reads security.yaml from an environment folder; found is false when it does not exist.
+func loadSecurityCredentials(fitnessPath string) (map[string]map[string]string, bool, error) {
+	credentialsPath := filepath.Join(fitnessPath, securityCredentialsFileName)
+	if !fileExists(credentialsPath) {
+		return nil, false, nil
+	}
+
+	content, err := readFileContent(credentialsPath)
+	if err != nil {
+		return nil, true, err
+	}
+
+	var raw map[string]map[string]interface{}
+	if err := yaml.UnmarshalStrict([]byte(content), &raw); err != nil {
+		return nil, true, fmt.Errorf("error parsing %s: %w", securityCredentialsFileName, err)
+	}
+
+	credentials := make(map[string]map[string]string, len(raw))
+	for scheme, fields := range raw {
+		credentials[scheme] = make(map[string]string, len(fields))
+		for field, value := range fields {
+			if value != nil {
+				credentials[scheme][field] = fmt.Sprintf("%v", value)
+			}
+		}
+	}
+	return credentials, true, nil
+}
+
+// oauth2Flow picks the supported flow of an OAuth2 scheme: password when the credentials carry a
+// username and password and the scheme offers it, otherwise client credentials, otherwise password.
+func oauth2Flow(scheme map[string]interface{}, credentials map[string]string) (string, map[string]interface{}) {
+	flows, _ := scheme["flows"].(map[string]interface{})
+	password, hasPassword := flows["password"].(map[string]interface{})
+	clientCredentials, hasClientCredentials := flows["clientCredentials"].(map[string]interface{})
+
+	if hasPassword && credentials["username"] != "" && credentials["password"] != "" {
+		return "password", password
+	}
+	if hasClientCredentials {
+		return "clientCredentials", clientCredentials
+	}
+	if hasPassword {
+		return "password", password
+	}
+	return "", nil
+}
+
+// requiredCredentialFields lists the security.yaml fields a scheme needs, or an error when the
+// scheme cannot be automated.
+func requiredCredentialFields(scheme map[string]interface{}, credentials map[string]string) ([]string, error) {
+	switch scheme["type"] {
+	case "http":
+		switch strings.ToLower(fmt.Sprintf("%v", scheme["scheme"])) {
+		case "bearer":
+			return []string{"token"}, nil
+		case "basic":
+			return []string{"username", "password"}, nil
+		}
+		return nil, fmt.Errorf("unsupported http authentication scheme %v", scheme["scheme"])
+	case "apiKey":
+		switch scheme["in"] {
+		case "header", "query", "cookie":
+			return []string{"value"}, nil
+		}
+		return nil, fmt.Errorf("unsupported apiKey location %v", scheme["in"])
+	case "oauth2":
+		switch flow, _ := oauth2Flow(scheme, credentials); flow {
+		case "clientCredentials":
+			return []string{"clientId", "clientSecret"}, nil
+		case "password":
+			return []string{"username", "password"}, nil
+		}
+		return nil, fmt.Errorf("only the clientCredentials and password OAuth2 flows are supported")
+	}
+	return nil, fmt.Errorf("unsupported security scheme type %v", scheme["type"])
+}
+
+// validateOperationSecurity picks the first security requirement of an operation whose schemes are
+// all supported and have credentials, and records it on the endpoint.
+func validateOperationSecurity(requirements []interface{}, operationID string, fitnessPath string, swagger map[string]interface{}, validationReport *ValidationReport) {
+	credentials, found, err := loadSecurityCredentials(fitnessPath)
+	endpointDetails := validationReport.Endpoints[operationID]
+	defer func() { validationReport.Endpoints[operationID] = endpointDetails }()
+
+	if err != nil {
+		endpointDetails.Issues = append(endpointDetails.Issues, Issue{File: securityCredentialsFileName, Issue: err.Error()})
+		return
+	}
+
+	schemes := securitySchemesOf(swagger)
+	var problems []string
+	var missing []string
+
+	for _, requirement := range requirements {
+		requirementMap, ok := requirement.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		// An empty requirement allows anonymous access
+		if len(requirementMap) == 0 {
+			endpointDetails.Security = nil
+			return
+		}
+
+		names := sortedKeys(requirementMap)
+		usable := true
+		for _, name := range names {
+			scheme, ok := schemes[name].(map[string]interface{})
+			if !ok {
+				problems = append(problems, fmt.Sprintf("security scheme %s is not defined", name))
+				usable = false
+				continue
+			}
+			fields, err := requiredCredentialFields(scheme, credentials[name])
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", name, err))
+				usable = false
+				continue
+			}
+			for _, field := range fields {
+				if strings.TrimSpace(credentials[name][field]) == "" {
+					missing = append(missing, name+"."+field)
+					usable = false
+				}
+			}
+		}
+
+		if usable && found {
+			endpointDetails.Security = names
+			return
+		}
+	}
+
+	if !found && len(problems) == 0 {
+		fmt.Printf("Warning: %s not found for secured operation %s\n", securityCredentialsFileName, operationID)
+		validationReport.MissingFiles = append(validationReport.MissingFiles, MissingFile{
+			File:        securityCredentialsFileName,
+			Type:        "security",
+			OperationID: operationID,
+		})
+		return
+	}
+
+	issue := Issue{File: securityCredentialsFileName, MissingParameters: missing}
+	if len(problems) > 0 {
+		issue.Issue = "No usable security requirement: " + strings.Join(problems, "; ")
+	}
+	endpointDetails.Issues = append(endpointDetails.Issues, issue)
+}
+
+// usedSecuritySchemes lists the schemes chosen for any endpoint, sorted by name.
+func usedSecuritySchemes(endpoints map[string]EndpointDetails) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, details := range endpoints {
+		for _, name := range details.Security {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// credentialExpression renders a credential as a literal, or as an __ENV lookup when env refs are enabled.
+func credentialExpression(envRegistry *envrefs.Registry, schemeName string, field string, value string) string {
+	if envRegistry != nil {
+		return envRegistry.Ref(schemeName+"_"+field, value)
+	}
+	return jsemit.String(value)
+}
+
+// securitySetupCode emits the k6 setup() stage that builds the credentials of every used scheme once,
+// fetching OAuth2 tokens from the token endpoint, and returns them to the VUs as data.auth.
+func securitySetupCode(schemeNames []string, swagger map[string]interface{}, fitnessPath string, serverURL string, envRegistry *envrefs.Registry) (string, error) {
+	credentials, _, err := loadSecurityCredentials(fitnessPath)
+	if err != nil {
+		return "", err
+	}
+	schemes := securitySchemesOf(swagger)
+
+	code := "\n// Obtain credentials once for all VUs\nexport function setup() {\n\tconst auth = {};\n"
+	for _, name := range schemeNames {
+		scheme, _ := schemes[name].(map[string]interface{})
+		values := credentials[name]
+		target := propertyAccess("auth", name)
+
+		switch scheme["type"] {
+		case "http":
+			if strings.EqualFold(fmt.Sprintf("%v", scheme["scheme"]), "basic") {
+				code += fmt.Sprintf("\t%s = encoding.b64encode(%s + ':' + %s);\n", target,
+					credentialExpression(envRegistry, name, "username", values["username"]),
+					credentialExpression(envRegistry, name, "password", values["password"]))
+			} else {
+				code += fmt.Sprintf("\t%s = %s;\n", target, credentialExpression(envRegistry, name, "token", values["token"]))
+			}
+		case "apiKey":
+			code += fmt.Sprintf("\t%s = %s;\n", target, credentialExpression(envRegistry, name, "value", values["value"]))
+		case "oauth2":
+			flow, flowObject := oauth2Flow(scheme, values)
+			tokenURL := values["tokenUrl"]
+			if tokenURL == "" {
+				tokenURL, _ = flowObject["tokenUrl"].(string)
+			}
+			if tokenURL == "" {
+				return "", fmt.Errorf("no tokenUrl for OAuth2 scheme %s", name)
+			}
+			if strings.HasPrefix(tokenURL, "/") {
+				tokenURL = serverURL + tokenURL
+			}
+
+			form := []string{}
+			if flow == "password" {
+				form = append(form, "grant_type: 'password'",
+					"username: "+credentialExpression(envRegistry, name, "username", values["username"]),
+					"password: "+credentialExpression(envRegistry, name, "password", values["password"]))
+			} else {
+				form = append(form, "grant_type: 'client_credentials'")
+			}
+			for _, field := range [][2]string{{"clientId", "client_id"}, {"clientSecret", "client_secret"}} {
+				if values[field[0]] != "" {
+					form = append(form, field[1]+": "+credentialExpression(envRegistry, name, field[0], values[field[0]]))
+				}
+			}
+			if values["scope"] != "" {
+				form = append(form, "scope: "+jsemit.String(values["scope"]))
+			}
+
+			responseVariable := "tokenRes_" + envrefs.VariableName(name)
+			code += fmt.Sprintf("\tconst %s = http.post(%s, { %s });\n", responseVariable, jsemit.String(tokenURL), strings.Join(form, ", "))
+			code += fmt.Sprintf("\tcheck(%s, { %s: (r) => r.status == 200 });\n", responseVariable, jsemit.String(name+"_token_check"))
+			code += fmt.Sprintf("\tif (%s.status !== 200 || !%s.json('access_token')) {\n\t\tthrow new Error(%s + %s.status);\n\t}\n", responseVariable, responseVariable, jsemit.String("could not obtain an access token for "+name+": status "), responseVariable)
+			code += fmt.Sprintf("\t%s = %s.json('access_token');\n", target, responseVariable)
+		}
+	}
+	code += "\treturn { auth: auth };\n}\n"
+	return code, nil
+}
+
+// securityRequestParts returns the header, query and cookie expressions that apply the chosen
+// schemes to one request.
+func securityRequestParts(schemeNames []string, swagger map[string]interface{}) (map[string]string, map[string]string, map[string]string) {
+	headers := make(map[string]string)
+	query := make(map[string]string)
+	cookies := make(map[string]string)
+	schemes := securitySchemesOf(swagger)
+
+	for _, name := range schemeNames {
+		scheme, _ := schemes[name].(map[string]interface{})
+		value := propertyAccess(securityAuthObject, name)
+
+		switch scheme["type"] {
+		case "http":
+			if strings.EqualFold(fmt.Sprintf("%v", scheme["scheme"]), "basic") {
+				headers["Authorization"] = "'Basic ' + " + value
+			} else {
+				headers["Authorization"] = "'Bearer ' + " + value
+			}
+		case "oauth2":
+			headers["Authorization"] = "'Bearer ' + " + value
+		case "apiKey":
+			keyName, _ := scheme["name"].(string)
+			switch scheme["in"] {
+			case "header":
+				headers[keyName] = value
+			case "query":
+				query[keyName] = "${encodeURIComponent(" + value + ")}"
+			case "cookie":
+				cookies[keyName] = value
+			}
+		}
+	}
+	return headers, query, cookies
+}
+
+// usesBasicAuth reports whether any used scheme needs k6/encoding for basic credentials.
+func usesBasicAuth(schemeNames []string, swagger map[string]interface{}) bool {
+	schemes := securitySchemesOf(swagger)
+	for _, name := range schemeNames {
+		scheme, _ := schemes[name].(map[string]interface{})
+		if scheme["type"] == "http" && strings.EqualFold(fmt.Sprintf("%v", scheme["scheme"]), "basic") {
+			return true
+		}
+	}
+	return false
+}
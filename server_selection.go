@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ServerOverride pins the server used for one environment folder, set under servers in k6-generator.yaml:
+//
+//	servers:
+//	  dev:
+//	    description: Development
+//	    variables:
+//	      region: eu
+//	  uat:
+//	    url: https://uat.example.com/api
+type ServerOverride struct {
+	// URL replaces the servers of the spec for this environment
+	URL string `yaml:"url"`
+	// Description selects the server whose description matches, ignoring case
+	Description string `yaml:"description"`
+	// Variables override the defaults of the server variables
+	Variables map[string]string `yaml:"variables"`
+}
+
+// selectServerURL picks the document server for an environment and expands its variables.
+// The config override URL wins over the servers listed in the spec.
+func selectServerURL(servers []interface{}, environment string) (string, error) {
+	override := generatorConfig.Servers[environment]
+	if override.URL != "" {
+		return expandServerVariables(override.URL, nil, override.Variables)
+	}
+	return pickServerURL(servers, environment, override, true)
+}
+
+// pickServerURL chooses from a servers list by the configured description, then by a description
+// naming the environment, and finally takes the first server. With requireDescription a configured
+// description that matches no server is an error instead of falling through.
+func pickServerURL(servers []interface{}, environment string, override ServerOverride, requireDescription bool) (string, error) {
+	var candidates []map[string]interface{}
+	for _, server := range servers {
+		serverMap, ok := server.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if serverURL, ok := serverMap["url"].(string); ok && serverURL != "" {
+			candidates = append(candidates, serverMap)
+		}
+	}
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("no server URL in the servers list")
+	}
+
+	var chosen map[string]interface{}
+	if override.Description != "" {
+		for _, candidate := range candidates {
+			if description, _ := candidate["description"].(string); strings.EqualFold(strings.TrimSpace(description), override.Description) {
+				chosen = candidate
+				break
+			}
+		}
+		if chosen == nil && requireDescription {
+			return "", fmt.Errorf("no server with description %q for environment %s", override.Description, environment)
+		}
+	}
+	if chosen == nil {
+		chosen = serverForEnvironment(candidates, environment)
+	}
+
+	serverURL, _ := chosen["url"].(string)
+	variables, _ := chosen["variables"].(map[string]interface{})
+	return expandServerVariables(serverURL, variables, override.Variables)
+}
+
+// serverForEnvironment prefers a server described exactly as the environment, then one whose
+// description mentions it, falling back to the first server.
+func serverForEnvironment(candidates []map[string]interface{}, environment string) map[string]interface{} {
+	name := strings.ToLower(environment)
+	for _, candidate := range candidates {
+		if description, _ := candidate["description"].(string); strings.EqualFold(strings.TrimSpace(description), name) {
+			return candidate
+		}
+	}
+	for _, candidate := range candidates {
+		if description, _ := candidate["description"].(string); strings.Contains(strings.ToLower(description), name) {
+			return candidate
+		}
+	}
+	return candidates[0]
+}
+
+// expandServerVariables substitutes {name} in a server URL with the configured value or the variable's
+// default, checking the value against the variable's enum. The trailing slash is dropped so paths can be appended.
+func expandServerVariables(serverURL string, variables map[string]interface{}, values map[string]string) (string, error) {
+	var expandErr error
+	expanded := pathPlaceholderPattern.ReplaceAllStringFunc(serverURL, func(placeholder string) string {
+		name := strings.Trim(placeholder, "{}")
+		variable, _ := variables[name].(map[string]interface{})
+
+		value, ok := values[name]
+		if !ok {
+			if defaultValue, exists := variable["default"]; exists && defaultValue != nil {
+				value, ok = fmt.Sprintf("%v", defaultValue), true
+			}
+		}
+		if !ok {
+			if expandErr == nil {
+				expandErr = fmt.Errorf("server variable %s in %s has no default or configured value", name, serverURL)
+			}
+			return placeholder
+		}
+
+		if enum, hasEnum := variable["enum"].([]interface{}); hasEnum && len(enum) > 0 {
+			allowed := false
+			for _, entry := range enum {
+				if fmt.Sprintf("%v", entry) == value {
+					allowed = true
+					break
+				}
+			}
+			if !allowed && expandErr == nil {
+				expandErr = fmt.Errorf("value %q for server variable %s is not one of %v", value, name, enum)
+			}
+		}
+		return value
+	})
+	if expandErr != nil {
+		return "", expandErr
+	}
+	return strings.TrimRight(expanded, "/"), nil
+}
+
+// operationServerURL resolves an operation-level servers list, or failing that a path-level one.
+// It reports false when neither overrides the document servers. The environment's override URL
+// only replaces the document servers, so these lists keep their own hosts, and a configured
+// description they do not use falls back to matching the environment.
+func operationServerURL(pathItem map[string]interface{}, operation map[string]interface{}, environment string) (string, bool, error) {
+	for _, owner := range []map[string]interface{}{operation, pathItem} {
+		servers, ok := owner["servers"].([]interface{})
+		if !ok || len(servers) == 0 {
+			continue
+		}
+		serverURL, err := pickServerURL(servers, environment, generatorConfig.Servers[environment], false)
+		return serverURL, true, err
+	}
+	return "", false, nil
+}
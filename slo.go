@@ -0,0 +1,250 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"k6-generator/jsemit"
+
+	"gopkg.in/yaml.v2"
+)
+
+// sloFileName declares the service level objectives of an environment, turned into k6 thresholds:
+//
+//	p95: 500ms
+//	p99: 1s
+//	errorRate: 0.01
+//	checksRate: 0.99
+//	abortOnFail: true
+//	delayAbortEval: 30s
+//	operations:
+//	  getUser:
+//	    p95: 200ms
+//	    errorRate: 0.001
+const sloFileName = "slo.yaml"
+
+// operationTagName tags the requests and checks of operations with their own SLOs; the tag value is
+// the operation's script identifier.
+const operationTagName = "operation"
+
+// SLO holds latency percentiles, the tolerated error rate and the required checks pass rate.
+// Latencies are k6 durations or plain milliseconds, measured over the whole request like
+// http_req_duration.
+type SLO struct {
+	P95            string   `yaml:"p95" json:"p95,omitempty"`
+	P99            string   `yaml:"p99" json:"p99,omitempty"`
+	ErrorRate      *float64 `yaml:"errorRate" json:"errorRate,omitempty"`
+	ChecksRate     *float64 `yaml:"checksRate" json:"checksRate,omitempty"`
+	AbortOnFail    *bool    `yaml:"abortOnFail" json:"abortOnFail,omitempty"`
+	DelayAbortEval string   `yaml:"delayAbortEval" json:"delayAbortEval,omitempty"`
+}
+
+// SLOConfig is the content of slo.yaml: global objectives plus overrides per operationId.
+// An operation inherits abortOnFail and delayAbortEval from the global objectives.
+type SLOConfig struct {
+	SLO        `yaml:",inline"`
+	Operations map[string]SLO `yaml:"operations" json:"operations,omitempty"`
+}
+
+// loadSLOConfig reads slo.yaml from an environment folder; found is false when it does not exist.
+func loadSLOConfig(fitnessPath string) (SLOConfig, bool, error) {
+	var config SLOConfig
+
+	sloPath := filepath.Join(fitnessPath, sloFileName)
+	if !fileExists(sloPath) {
+		return config, false, nil
+	}
+
+	content, err := readFileContent(sloPath)
+	if err != nil {
+		return config, true, err
+	}
+	if err := yaml.UnmarshalStrict([]byte(content), &config); err != nil {
+		return config, true, fmt.Errorf("error parsing %s: %w", sloFileName, err)
+	}
+	return config, true, nil
+}
+
+// latencyMilliseconds converts an SLO latency to milliseconds.
+func latencyMilliseconds(value string) (float64, error) {
+	if milliseconds, err := strconv.ParseFloat(value, 64); err == nil {
+		if milliseconds <= 0 {
+			return 0, fmt.Errorf("latency %q must be positive", value)
+		}
+		return milliseconds, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		return 0, fmt.Errorf("invalid latency %q", value)
+	}
+	return float64(duration) / float64(time.Millisecond), nil
+}
+
+// validateSLO lists the problems of one set of objectives, prefixing them with where they were declared.
+func validateSLO(slo SLO, prefix string) []string {
+	var problems []string
+	for name, value := range map[string]string{"p95": slo.P95, "p99": slo.P99} {
+		if value == "" {
+			continue
+		}
+		if _, err := latencyMilliseconds(value); err != nil {
+			problems = append(problems, fmt.Sprintf("%s%s: %v", prefix, name, err))
+		}
+	}
+	for name, value := range map[string]*float64{"errorRate": slo.ErrorRate, "checksRate": slo.ChecksRate} {
+		if value != nil && (*value < 0 || *value > 1) {
+			problems = append(problems, fmt.Sprintf("%s%s must be between 0 and 1", prefix, name))
+		}
+	}
+	if slo.DelayAbortEval != "" {
+		if err := validateK6Duration(slo.DelayAbortEval); err != nil {
+			problems = append(problems, fmt.Sprintf("%sdelayAbortEval: %v", prefix, err))
+		}
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+// validateSLOConfig checks every objective and that each operation override names an operation of the spec.
+func validateSLOConfig(config SLOConfig, endpoints map[string]EndpointDetails, skipped []SkippedOperation) []string {
+	problems := validateSLO(config.SLO, "")
+	for _, operationID := range sortedSLOOperations(config.Operations) {
+		if _, ok := endpoints[operationID]; !ok {
+			problems = append(problems, "operations: "+missingOperation(operationID, skipped))
+		}
+		problems = append(problems, validateSLO(config.Operations[operationID], "operations."+operationID+".")...)
+	}
+	return problems
+}
+
+func sortedSLOOperations(operations map[string]SLO) []string {
+	operationIDs := make([]string, 0, len(operations))
+	for operationID := range operations {
+		operationIDs = append(operationIDs, operationID)
+	}
+	sort.Strings(operationIDs)
+	return operationIDs
+}
+
+// k6Threshold is one threshold expression of a metric, aborting the run when it fails if AbortOnFail is set.
+type k6Threshold struct {
+	Expression     string
+	AbortOnFail    bool
+	DelayAbortEval string
+}
+
+// code renders the threshold, in the object form when the run should abort on failure.
+func (threshold k6Threshold) code() string {
+	if !threshold.AbortOnFail {
+		return jsemit.String(threshold.Expression)
+	}
+	if threshold.DelayAbortEval == "" {
+		return fmt.Sprintf("{ threshold: %s, abortOnFail: true }", jsemit.String(threshold.Expression))
+	}
+	return fmt.Sprintf("{ threshold: %s, abortOnFail: true, delayAbortEval: %s }", jsemit.String(threshold.Expression), jsemit.String(threshold.DelayAbortEval))
+}
+
+// addThreshold adds a threshold to a metric. An expression the metric already has is kept once, and
+// aborts the run when either declaration does.
+func addThreshold(thresholds map[string][]k6Threshold, metric string, threshold k6Threshold) {
+	for i, existing := range thresholds[metric] {
+		if existing.Expression != threshold.Expression {
+			continue
+		}
+		if threshold.AbortOnFail && !existing.AbortOnFail {
+			thresholds[metric][i] = threshold
+		}
+		return
+	}
+	thresholds[metric] = append(thresholds[metric], threshold)
+}
+
+func sortedK6ThresholdMetrics(thresholds map[string][]k6Threshold) []string {
+	metrics := make([]string, 0, len(thresholds))
+	for metric := range thresholds {
+		metrics = append(metrics, metric)
+	}
+	sort.Strings(metrics)
+	return metrics
+}
+
+// sloThresholds adds the thresholds of one set of objectives: latencies on each latency metric,
+// the error rate on errorMetric and the pass rate on checksMetric.
+func sloThresholds(thresholds map[string][]k6Threshold, slo SLO, abortOnFail bool, delayAbortEval string, latencyMetrics []string, errorMetric string, checksMetric string) {
+	threshold := func(expression string) k6Threshold {
+		return k6Threshold{Expression: expression, AbortOnFail: abortOnFail, DelayAbortEval: delayAbortEval}
+	}
+	for _, percentile := range []struct{ name, value string }{{"p(95)", slo.P95}, {"p(99)", slo.P99}} {
+		if percentile.value == "" {
+			continue
+		}
+		milliseconds, _ := latencyMilliseconds(percentile.value)
+		for _, metric := range latencyMetrics {
+			addThreshold(thresholds, metric, threshold(fmt.Sprintf("%s<%s", percentile.name, strconv.FormatFloat(milliseconds, 'f', -1, 64))))
+		}
+	}
+	if slo.ErrorRate != nil {
+		addThreshold(thresholds, errorMetric, threshold("rate<"+strconv.FormatFloat(*slo.ErrorRate, 'f', -1, 64)))
+	}
+	if slo.ChecksRate != nil {
+		addThreshold(thresholds, checksMetric, threshold("rate>"+strconv.FormatFloat(*slo.ChecksRate, 'f', -1, 64)))
+	}
+}
+
+// sloThresholdExpressions turns the objectives into k6 thresholds keyed by metric. Global objectives
+// apply to the untagged http_req metrics and checks; an operation's objectives apply to its Trend and
+// to the metrics tagged with it, both named after the operation's script identifier. Unlike the raw
+// operationId, the identifier cannot hold the : , { } that end a tag selector.
+func sloThresholdExpressions(config SLOConfig) map[string][]k6Threshold {
+	thresholds := make(map[string][]k6Threshold)
+	abortOnFail := config.AbortOnFail != nil && *config.AbortOnFail
+
+	sloThresholds(thresholds, config.SLO, abortOnFail, config.DelayAbortEval, []string{"http_req_duration"}, "http_req_failed", "checks")
+
+	for _, operationID := range sortedSLOOperations(config.Operations) {
+		slo := config.Operations[operationID]
+		operationAbort, delay := abortOnFail, config.DelayAbortEval
+		if slo.AbortOnFail != nil {
+			operationAbort = *slo.AbortOnFail
+		}
+		if slo.DelayAbortEval != "" {
+			delay = slo.DelayAbortEval
+		}
+		identifier := operationIdentifier(operationID)
+		tag := fmt.Sprintf("{%s:%s}", operationTagName, identifier)
+		sloThresholds(thresholds, slo, operationAbort, delay, []string{identifier, "http_req_duration" + tag}, "http_req_failed"+tag, "checks"+tag)
+	}
+	return thresholds
+}
+
+// hasOperationSLO reports whether an operation's requests and checks need the operation tag.
+func hasOperationSLO(config *SLOConfig, operationID string) bool {
+	if config == nil {
+		return false
+	}
+	_, ok := config.Operations[operationID]
+	return ok
+}
+
+// validateSLOFile records an environment's objectives and their problems in the report.
+func validateSLOFile(fitnessPath string, validationReport *ValidationReport) {
+	config, found, err := loadSLOConfig(fitnessPath)
+	if err != nil {
+		validationReport.SLOIssues = append(validationReport.SLOIssues, err.Error())
+		return
+	}
+	if !found {
+		return
+	}
+	fmt.Println("Using service level objectives:", sloFileName)
+	validationReport.SLO = &config
+	validationReport.SLOIssues = append(validationReport.SLOIssues, validateSLOConfig(config, validationReport.Endpoints, validationReport.SkippedOperations)...)
+}
+
+// operationTagExpression is the tags object added to the requests and checks of an operation.
+func operationTagExpression(operationID string) string {
+	return fmt.Sprintf("{ %s: %s }", operationTagName, jsemit.String(operationIdentifier(operationID)))
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSLOThresholdsMergeWithProfile(t *testing.T) {
+	abortOnFail := true
+	errorRate := 0.01
+	config := SLOConfig{SLO: SLO{ErrorRate: &errorRate, AbortOnFail: &abortOnFail}}
+	profile := LoadProfile{Thresholds: map[string][]string{"http_req_failed": {"rate<0.01", "rate<0.01"}}}
+
+	code := optionsCode(profile, nil, nil, sloThresholdExpressions(config))
+	want := "'http_req_failed': [{ threshold: 'rate<0.01', abortOnFail: true }],"
+	if !strings.Contains(code, want) {
+		t.Fatalf("options do not hold %s:\n%s", want, code)
+	}
+}
+
+func TestSLOOperationTagSelector(t *testing.T) {
+	p95 := "200"
+	operationID := "get:user{a,b}"
+	config := SLOConfig{Operations: map[string]SLO{operationID: {P95: p95}}}
+
+	thresholds := sloThresholdExpressions(config)
+	metric := "http_req_duration{operation:" + operationIdentifier(operationID) + "}"
+	if _, ok := thresholds[metric]; !ok {
+		t.Fatalf("no threshold on %s in %v", metric, thresholds)
+	}
+	for metric := range thresholds {
+		if selector := strings.TrimPrefix(metric, "http_req_duration{"); selector != metric && strings.ContainsAny(strings.TrimSuffix(selector, "}"), ",{}") {
+			t.Errorf("tag selector %s cannot be parsed by k6", metric)
+		}
+	}
+	if tags := operationTagExpression(operationID); !strings.Contains(tags, "'"+operationIdentifier(operationID)+"'") {
+		t.Errorf("request tags %s do not match the threshold selector", tags)
+	}
+}
@@ -0,0 +1,459 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// swagger2SchemaKeys are the Swagger 2.0 non-body parameter fields that move under "schema" in OpenAPI 3.
+var swagger2SchemaKeys = []string{
+	"type", "format", "items", "collectionFormat", "default", "enum",
+	"maximum", "exclusiveMaximum", "minimum", "exclusiveMinimum",
+	"maxLength", "minLength", "pattern", "maxItems", "minItems", "uniqueItems", "multipleOf",
+}
+
+var swagger2Methods = []string{"get", "put", "post", "delete", "options", "head", "patch"}
+
+// isSwagger2Document reports whether the document declares "swagger": "2.x".
+func isSwagger2Document(swagger map[string]interface{}) bool {
+	version, ok := swagger["swagger"].(string)
+	return ok && strings.HasPrefix(strings.TrimSpace(version), "2")
+}
+
+// convertSwagger2ToOpenAPI3 rewrites a $ref-resolved Swagger 2.0 document into the OpenAPI 3 shape
+// the validator and k6 generator understand: servers from schemes/host/basePath, in: body and
+// in: formData parameters as requestBody, response schemas as content and definitions as components.
+func convertSwagger2ToOpenAPI3(swagger map[string]interface{}) map[string]interface{} {
+	converted := make(map[string]interface{}, len(swagger)+2)
+	for key, value := range swagger {
+		converted[key] = value
+	}
+
+	if servers := swagger2Servers(swagger); len(servers) > 0 {
+		converted["servers"] = servers
+	}
+
+	globalConsumes := stringList(swagger["consumes"])
+	globalProduces := stringList(swagger["produces"])
+
+	components, _ := converted["components"].(map[string]interface{})
+	if components == nil {
+		components = make(map[string]interface{})
+	}
+	if definitions, ok := swagger["definitions"].(map[string]interface{}); ok {
+		components["schemas"] = definitions
+	}
+	if parameters, ok := swagger["parameters"].(map[string]interface{}); ok {
+		convertedParameters := make(map[string]interface{}, len(parameters))
+		for name, parameter := range parameters {
+			if parameterMap, ok := parameter.(map[string]interface{}); ok {
+				convertedParameters[name] = convertSwagger2Parameter(parameterMap)
+			}
+		}
+		components["parameters"] = convertedParameters
+	}
+	if responses, ok := swagger["responses"].(map[string]interface{}); ok {
+		components["responses"] = convertSwagger2Responses(responses, globalProduces)
+	}
+	if securityDefinitions, ok := swagger["securityDefinitions"].(map[string]interface{}); ok {
+		components["securitySchemes"] = convertSwagger2SecurityDefinitions(securityDefinitions)
+	}
+	converted["components"] = components
+
+	paths, ok := swagger["paths"].(map[string]interface{})
+	if !ok {
+		return converted
+	}
+
+	convertedPaths := make(map[string]interface{}, len(paths))
+	for endpoint, pathItem := range paths {
+		pathItemMap, ok := pathItem.(map[string]interface{})
+		if !ok {
+			convertedPaths[endpoint] = pathItem
+			continue
+		}
+
+		pathParameters, _ := pathItemMap["parameters"].([]interface{})
+		convertedPathItem := make(map[string]interface{}, len(pathItemMap))
+		for key, value := range pathItemMap {
+			convertedPathItem[key] = value
+		}
+
+		for _, method := range swagger2Methods {
+			operationMap, ok := pathItemMap[method].(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			consumes := globalConsumes
+			if operationConsumes := stringList(operationMap["consumes"]); len(operationConsumes) > 0 {
+				consumes = operationConsumes
+			}
+			produces := globalProduces
+			if operationProduces := stringList(operationMap["produces"]); len(operationProduces) > 0 {
+				produces = operationProduces
+			}
+
+			operationParameters, _ := operationMap["parameters"].([]interface{})
+			parameters, requestBody := convertSwagger2Parameters(mergeParameters(pathParameters, operationParameters), consumes)
+
+			convertedOperation := make(map[string]interface{}, len(operationMap)+1)
+			for key, value := range operationMap {
+				convertedOperation[key] = value
+			}
+			convertedOperation["parameters"] = parameters
+			if requestBody != nil {
+				convertedOperation["requestBody"] = requestBody
+			}
+			if responses, ok := operationMap["responses"].(map[string]interface{}); ok {
+				convertedOperation["responses"] = convertSwagger2Responses(responses, produces)
+			}
+			convertedPathItem[method] = convertedOperation
+		}
+
+		// Path-level parameters were merged into every operation above
+		delete(convertedPathItem, "parameters")
+		convertedPaths[endpoint] = convertedPathItem
+	}
+	converted["paths"] = convertedPaths
+
+	return converted
+}
+
+// swagger2Servers builds server entries from schemes, host and basePath. Without a host there is no
+// usable base URL, so no servers are returned and validation reports the missing server URL.
+func swagger2Servers(swagger map[string]interface{}) []interface{} {
+	host, _ := swagger["host"].(string)
+	if strings.TrimSpace(host) == "" {
+		return nil
+	}
+
+	basePath, _ := swagger["basePath"].(string)
+	basePath = strings.TrimSuffix(basePath, "/")
+
+	schemes := stringList(swagger["schemes"])
+	if len(schemes) == 0 {
+		schemes = []string{"https"}
+	}
+
+	// Prefer https when the spec offers several schemes
+	var servers []interface{}
+	for _, preferred := range []bool{true, false} {
+		for _, scheme := range schemes {
+			if (strings.ToLower(scheme) == "https") != preferred {
+				continue
+			}
+			servers = append(servers, map[string]interface{}{
+				"url": fmt.Sprintf("%s://%s%s", strings.ToLower(scheme), host, basePath),
+			})
+		}
+	}
+	return servers
+}
+
+// convertSwagger2Parameters splits Swagger 2.0 parameters into OpenAPI 3 parameters and a requestBody
+// built from the in: body parameter or the in: formData parameters.
+func convertSwagger2Parameters(parameters []interface{}, consumes []string) ([]interface{}, map[string]interface{}) {
+	var converted []interface{}
+	var requestBody map[string]interface{}
+
+	formProperties := make(map[string]interface{})
+	var formRequired []interface{}
+	hasFileField := false
+
+	for _, parameter := range parameters {
+		parameterMap, ok := parameter.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		switch parameterMap["in"] {
+		case "body":
+			mediaTypes := consumes
+			if len(mediaTypes) == 0 {
+				mediaTypes = []string{"application/json"}
+			}
+
+			schema, _ := parameterMap["schema"].(map[string]interface{})
+			content := make(map[string]interface{}, len(mediaTypes))
+			for _, mediaType := range mediaTypes {
+				mediaTypeObject := map[string]interface{}{}
+				if schema != nil {
+					mediaTypeObject["schema"] = schema
+				}
+				if example, ok := parameterMap["x-example"]; ok {
+					mediaTypeObject["example"] = example
+				}
+				content[mediaType] = mediaTypeObject
+			}
+
+			requestBody = map[string]interface{}{"content": content}
+			if required, ok := parameterMap["required"].(bool); ok {
+				requestBody["required"] = required
+			}
+			if description, ok := parameterMap["description"]; ok {
+				requestBody["description"] = description
+			}
+		case "formData":
+			name, _ := parameterMap["name"].(string)
+			if name == "" {
+				continue
+			}
+
+			property := swagger2ParameterSchema(parameterMap)
+			if property["type"] == "file" {
+				hasFileField = true
+				property["type"] = "string"
+				property["format"] = "binary"
+			}
+			formProperties[name] = property
+			if required, ok := parameterMap["required"].(bool); ok && required {
+				formRequired = append(formRequired, name)
+			}
+		default:
+			converted = append(converted, convertSwagger2Parameter(parameterMap))
+		}
+	}
+
+	if requestBody == nil && len(formProperties) > 0 {
+		mediaType := "application/x-www-form-urlencoded"
+		if hasFileField || containsString(consumes, "multipart/form-data") {
+			mediaType = "multipart/form-data"
+		}
+
+		schema := map[string]interface{}{
+			"type":       "object",
+			"properties": formProperties,
+		}
+		if len(formRequired) > 0 {
+			schema["required"] = formRequired
+		}
+		requestBody = map[string]interface{}{
+			"content": map[string]interface{}{
+				mediaType: map[string]interface{}{"schema": schema},
+			},
+		}
+	}
+
+	return converted, requestBody
+}
+
+// convertSwagger2Parameter moves type information of a non-body parameter under "schema" and
+// maps the x-example vendor extension to "example".
+func convertSwagger2Parameter(parameter map[string]interface{}) map[string]interface{} {
+	if in, _ := parameter["in"].(string); in == "body" || in == "formData" {
+		return parameter
+	}
+
+	converted := make(map[string]interface{}, len(parameter))
+	for key, value := range parameter {
+		converted[key] = value
+	}
+	for _, key := range swagger2SchemaKeys {
+		delete(converted, key)
+	}
+
+	if _, hasSchema := converted["schema"]; !hasSchema {
+		if schema := swagger2ParameterSchema(parameter); len(schema) > 0 {
+			converted["schema"] = schema
+		}
+	}
+	if _, hasExample := converted["example"]; !hasExample {
+		if example, ok := parameter["x-example"]; ok {
+			converted["example"] = example
+		}
+	}
+
+	switch parameter["collectionFormat"] {
+	case "multi":
+		converted["style"] = "form"
+		converted["explode"] = true
+	case "ssv":
+		converted["style"] = "spaceDelimited"
+		converted["explode"] = false
+	case "pipes":
+		converted["style"] = "pipeDelimited"
+		converted["explode"] = false
+	case "csv", "tsv":
+		// OpenAPI 3 has no tab separated style; swagger2ConversionWarnings reports it
+		converted["explode"] = false
+	}
+
+	return converted
+}
+
+// swagger2ConversionWarnings lists the parameters whose collectionFormat has no OpenAPI 3 style. Their
+// values are sent comma separated, the OpenAPI 3 default.
+func swagger2ConversionWarnings(swagger map[string]interface{}) []string {
+	var warnings []string
+	paths, _ := swagger["paths"].(map[string]interface{})
+	for endpoint, pathItem := range paths {
+		pathItemMap, ok := pathItem.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		pathParameters, _ := pathItemMap["parameters"].([]interface{})
+		for _, method := range swagger2Methods {
+			operationMap, ok := pathItemMap[method].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			operationParameters, _ := operationMap["parameters"].([]interface{})
+			for _, parameter := range mergeParameters(pathParameters, operationParameters) {
+				parameterMap, _ := parameter.(map[string]interface{})
+				format, _ := parameterMap["collectionFormat"].(string)
+				switch format {
+				case "", "csv", "ssv", "pipes", "multi":
+					continue
+				}
+				warnings = append(warnings, fmt.Sprintf("%s %s parameter %v: collectionFormat %s is not supported, its values are sent comma separated", strings.ToUpper(method), endpoint, parameterMap["name"], format))
+			}
+		}
+	}
+	sort.Strings(warnings)
+	return warnings
+}
+
+func swagger2ParameterSchema(parameter map[string]interface{}) map[string]interface{} {
+	schema := make(map[string]interface{})
+	for _, key := range swagger2SchemaKeys {
+		if key == "collectionFormat" {
+			continue
+		}
+		if value, ok := parameter[key]; ok {
+			schema[key] = value
+		}
+	}
+	if example, ok := parameter["x-example"]; ok {
+		schema["example"] = example
+	}
+	return schema
+}
+
+// convertSwagger2Responses wraps response schemas and examples in an OpenAPI 3 content map.
+func convertSwagger2Responses(responses map[string]interface{}, produces []string) map[string]interface{} {
+	mediaTypes := produces
+	if len(mediaTypes) == 0 {
+		mediaTypes = []string{"application/json"}
+	}
+
+	converted := make(map[string]interface{}, len(responses))
+	for code, response := range responses {
+		responseMap, ok := response.(map[string]interface{})
+		if !ok {
+			converted[code] = response
+			continue
+		}
+
+		convertedResponse := make(map[string]interface{}, len(responseMap))
+		for key, value := range responseMap {
+			convertedResponse[key] = value
+		}
+		delete(convertedResponse, "schema")
+		delete(convertedResponse, "examples")
+
+		schema, hasSchema := responseMap["schema"].(map[string]interface{})
+		examples, _ := responseMap["examples"].(map[string]interface{})
+		if hasSchema || len(examples) > 0 {
+			content := make(map[string]interface{}, len(mediaTypes))
+			for _, mediaType := range mediaTypes {
+				mediaTypeObject := map[string]interface{}{}
+				if hasSchema {
+					mediaTypeObject["schema"] = schema
+				}
+				if example, ok := examples[mediaType]; ok {
+					mediaTypeObject["example"] = example
+				}
+				content[mediaType] = mediaTypeObject
+			}
+			convertedResponse["content"] = content
+		}
+
+		if headers, ok := responseMap["headers"].(map[string]interface{}); ok {
+			convertedHeaders := make(map[string]interface{}, len(headers))
+			for name, header := range headers {
+				if headerMap, ok := header.(map[string]interface{}); ok {
+					convertedHeaders[name] = map[string]interface{}{"schema": swagger2ParameterSchema(headerMap)}
+				}
+			}
+			convertedResponse["headers"] = convertedHeaders
+		}
+
+		converted[code] = convertedResponse
+	}
+	return converted
+}
+
+// convertSwagger2SecurityDefinitions maps securityDefinitions onto OpenAPI 3 securitySchemes.
+func convertSwagger2SecurityDefinitions(definitions map[string]interface{}) map[string]interface{} {
+	schemes := make(map[string]interface{}, len(definitions))
+	for name, definition := range definitions {
+		definitionMap, ok := definition.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		switch definitionMap["type"] {
+		case "basic":
+			schemes[name] = map[string]interface{}{"type": "http", "scheme": "basic"}
+		case "apiKey":
+			schemes[name] = map[string]interface{}{
+				"type": "apiKey",
+				"name": definitionMap["name"],
+				"in":   definitionMap["in"],
+			}
+		case "oauth2":
+			flow := map[string]interface{}{}
+			for _, key := range []string{"authorizationUrl", "tokenUrl", "scopes"} {
+				if value, ok := definitionMap[key]; ok {
+					flow[key] = value
+				}
+			}
+
+			flowName := ""
+			switch definitionMap["flow"] {
+			case "application":
+				flowName = "clientCredentials"
+			case "password":
+				flowName = "password"
+			case "accessCode":
+				flowName = "authorizationCode"
+			case "implicit":
+				flowName = "implicit"
+			}
+			if flowName != "" {
+				schemes[name] = map[string]interface{}{
+					"type":  "oauth2",
+					"flows": map[string]interface{}{flowName: flow},
+				}
+			}
+		}
+	}
+	return schemes
+}
+
+// stringList converts a decoded JSON/YAML array into a string slice, skipping non-string values.
+func stringList(value interface{}) []string {
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSwagger2TSVCollectionFormat(t *testing.T) {
+	parameter := map[string]interface{}{
+		"name": "ids", "in": "query", "type": "array", "collectionFormat": "tsv",
+		"items": map[string]interface{}{"type": "string"},
+	}
+	swagger := map[string]interface{}{
+		"swagger": "2.0",
+		"paths": map[string]interface{}{
+			"/items": map[string]interface{}{
+				"get": map[string]interface{}{"parameters": []interface{}{parameter}},
+			},
+		},
+	}
+
+	warnings := swagger2ConversionWarnings(swagger)
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "GET /items parameter ids: collectionFormat tsv") {
+		t.Fatalf("warnings = %v", warnings)
+	}
+	if converted := convertSwagger2Parameter(parameter); converted["explode"] != false {
+		t.Fatalf("tsv parameter converted to %v, want the comma separated form", converted)
+	}
+}
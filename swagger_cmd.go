@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"k6-generator/Vpe"
+	"k6-generator/constants"
+
+	"github.com/spf13/cobra"
+)
+
+// defaultScriptNameTemplate is the generated script name; {env} is replaced by the environment folder.
+const defaultScriptNameTemplate = "vpe-default-k6-swagger_{env}.js"
+
+// Run modes for SwaggerGeneratorOptions.Mode
+const (
+	swaggerModeValidate = "validate"
+	swaggerModeGenerate = "generate"
+	swaggerModeReport   = "report"
+)
+
+// SwaggerGeneratorOptions holds the command-line settings of the Swagger k6 generator.
+type SwaggerGeneratorOptions struct {
+	// Mode is validate, generate or report
+	Mode string
+	// FolderPath contains the fitness folder; defaults to the VPE config path
+	FolderPath string
+	// OutputDir receives the scripts, env_vars and reports; defaults to <FolderPath>/k6
+	OutputDir string
+	// ScriptNameTemplate names each script, with {env} replaced by the environment
+	ScriptNameTemplate string
+	// BaseURLOverride replaces the servers entry of the Swagger file when set
+	BaseURLOverride string
+	// DryRun prints the generated scripts instead of writing any files
+	DryRun bool
+	// EnvRefs emits base URLs and secrets as __ENV lookups, as envRefs.enabled does in the config file
+	EnvRefs bool
+}
+
+// swaggerOptions is filled from the command line before ValidateSwaggerAndFiles runs.
+var swaggerOptions = SwaggerGeneratorOptions{
+	Mode:               swaggerModeGenerate,
+	ScriptNameTemplate: defaultScriptNameTemplate,
+}
+
+// k6Cmd is the root of the k6 binary, with the swagger commands and the vpeconfig generator.
+var k6Cmd = &cobra.Command{
+	Use:           "k6",
+	Short:         "Generate k6 load test scripts",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+// K6SwaggerCmd groups the Swagger/OpenAPI generator subcommands.
+var K6SwaggerCmd = &cobra.Command{
+	Use:   "swagger",
+	Short: "Validate Swagger/OpenAPI fitness folders and generate k6 scripts",
+}
+
+var swaggerValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Validate the Swagger file and fitness files of each environment without writing scripts",
+	Args:  cobra.NoArgs,
+	RunE:  runSwaggerCommand(swaggerModeValidate),
+}
+
+var swaggerGenerateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Validate each environment and generate a k6 script for those without issues",
+	Args:  cobra.NoArgs,
+	RunE:  runSwaggerCommand(swaggerModeGenerate),
+}
+
+var swaggerReportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Validate each environment and write a machine-readable validation report",
+	Args:  cobra.NoArgs,
+	RunE:  runSwaggerCommand(swaggerModeReport),
+}
+
+func init() {
+	flags := K6SwaggerCmd.PersistentFlags()
+	flags.StringVarP(&swaggerOptions.FolderPath, "path", "p", "", "folder containing the fitness folder (default the VPE config path)")
+	flags.StringVarP(&swaggerOptions.OutputDir, "out", "o", "", "output folder for scripts, env_vars and reports (default <path>/k6)")
+	flags.StringSliceVarP(&environmentFlags.List, "env", "e", nil, "environment folders to process, comma separated or repeated (overrides discovery)")
+	flags.StringSliceVar(&environmentFlags.Include, "env-include", nil, "glob of environment folders to include, comma separated or repeated")
+	flags.StringSliceVar(&environmentFlags.Exclude, "env-exclude", nil, "glob of environment folders to exclude, comma separated or repeated")
+	flags.Bool("all-envs", false, "process every fitness subfolder that contains a Swagger/OpenAPI file")
+	flags.StringVar(&reportFormat, "report-format", "", "also write the validation report as json, junit or sarif")
+	flags.StringVar(&reportOut, "report-out", "", "path of the validation report (default <out>/validation-report.<ext>)")
+	flags.StringVar(&swaggerOptions.BaseURLOverride, "base-url", "", "base URL to use instead of the servers entry of the Swagger file")
+	flags.StringSliceVar(&operationFlags.Include.Tags, "include-tag", nil, "only keep operations with one of these tags")
+	flags.StringSliceVar(&operationFlags.Exclude.Tags, "exclude-tag", nil, "leave out operations with one of these tags")
+	flags.StringSliceVar(&operationFlags.Include.OperationIDs, "include-operation", nil, "only keep operations whose operationId matches one of these globs")
+	flags.StringSliceVar(&operationFlags.Exclude.OperationIDs, "exclude-operation", nil, "leave out operations whose operationId matches one of these globs")
+	flags.StringSliceVar(&operationFlags.Include.Methods, "include-method", nil, "only keep operations with one of these HTTP methods")
+	flags.StringSliceVar(&operationFlags.Exclude.Methods, "exclude-method", nil, "leave out operations with one of these HTTP methods")
+	flags.StringSliceVar(&operationFlags.Include.Paths, "include-path", nil, "only keep operations under one of these path prefixes")
+	flags.StringSliceVar(&operationFlags.Exclude.Paths, "exclude-path", nil, "leave out operations under one of these path prefixes")
+
+	swaggerGenerateCmd.Flags().StringVar(&swaggerOptions.ScriptNameTemplate, "script-name", defaultScriptNameTemplate, "script file name template; {env} is replaced by the environment")
+	swaggerGenerateCmd.Flags().BoolVar(&swaggerOptions.DryRun, "dry-run", false, "print the generated scripts instead of writing files")
+	swaggerGenerateCmd.Flags().BoolVar(&swaggerOptions.EnvRefs, "env-refs", false, "read base URLs and sensitive values from __ENV and write them to a companion .env file")
+
+	K6SwaggerCmd.AddCommand(swaggerValidateCmd, swaggerGenerateCmd, swaggerReportCmd)
+	k6Cmd.AddCommand(K6SwaggerCmd, vpe.K6VpeconfigCmd)
+}
+
+// runSwaggerCommand applies the flags shared by the swagger subcommands and runs the generator in the given mode.
+func runSwaggerCommand(mode string) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		swaggerOptions.Mode = mode
+
+		if allEnvironments, _ := cmd.Flags().GetBool("all-envs"); allEnvironments {
+			environmentFlags.Mode = environmentModeAll
+		}
+
+		if mode == swaggerModeReport && reportFormat == "" {
+			reportFormat = "json"
+		}
+		if reportFormat != "" && !isSupportedReportFormat(reportFormat) {
+			return fmt.Errorf("unsupported report format %q (expected json, junit or sarif)", reportFormat)
+		}
+
+		if !strings.Contains(swaggerOptions.ScriptNameTemplate, "{env}") {
+			return fmt.Errorf("--script-name %q must contain {env} so each environment gets its own script", swaggerOptions.ScriptNameTemplate)
+		}
+
+		return ValidateSwaggerAndFiles()
+	}
+}
+
+// swaggerFolderPath returns the folder that holds the fitness folder.
+func swaggerFolderPath() string {
+	if swaggerOptions.FolderPath != "" {
+		return swaggerOptions.FolderPath
+	}
+	return constants.PathConstantsInstance.VPEConfigPath
+}
+
+// envFitnessPath returns the fitness folder of an environment.
+func envFitnessPath(environment string) string {
+	return filepath.Join(swaggerFolderPath(), "fitness", environment)
+}
+
+// swaggerOutputDir returns the folder that receives the generated files.
+func swaggerOutputDir() string {
+	if swaggerOptions.OutputDir != "" {
+		return swaggerOptions.OutputDir
+	}
+	return filepath.Join(swaggerFolderPath(), "k6")
+}
+
+// scriptFileName applies the script name template to an environment.
+func scriptFileName(environment string) string {
+	return strings.ReplaceAll(swaggerOptions.ScriptNameTemplate, "{env}", environment)
+}
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// resolvedRefKey is set on every node that was inlined from a $ref so callers can still
+// find the original reference (e.g. to derive <SchemaName>.json fitness file names).
+const resolvedRefKey = "x-resolved-ref"
+
+// refResolver dereferences local, relative-file and nested $ref pointers in a Swagger/OpenAPI document.
+type refResolver struct {
+	documents map[string]map[string]interface{} // parsed documents keyed by absolute file path
+	// resolved holds the resolution of every ref that cut no cycle short, keyed by absolute pointer,
+	// so a component shared by many operations is resolved once
+	resolved     map[string]interface{}
+	circularRefs []string
+	cycleCuts    int
+}
+
+// resolveSwaggerRefs returns a copy of the document with every resolvable $ref inlined.
+// References that point back into their own resolution chain are left in place and
+// returned as circular refs, so recursive schemas do not expand forever.
+func resolveSwaggerRefs(swagger map[string]interface{}, specFilePath string) (map[string]interface{}, []string, error) {
+	absSpecPath, err := filepath.Abs(specFilePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error resolving spec path %s: %w", specFilePath, err)
+	}
+
+	resolver := &refResolver{
+		documents: map[string]map[string]interface{}{absSpecPath: swagger},
+		resolved:  make(map[string]interface{}),
+	}
+
+	resolved, err := resolver.resolve(swagger, absSpecPath, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resolvedMap, ok := resolved.(map[string]interface{})
+	if !ok {
+		return nil, nil, fmt.Errorf("resolved document is not an object")
+	}
+	return resolvedMap, resolver.circularRefs, nil
+}
+
+func (r *refResolver) resolve(node interface{}, docPath string, stack []string) (interface{}, error) {
+	switch value := node.(type) {
+	case map[string]interface{}:
+		if ref, ok := value["$ref"].(string); ok {
+			return r.resolveRef(ref, value, docPath, stack)
+		}
+
+		result := make(map[string]interface{}, len(value))
+		// Walk keys in order so circular references are found and reported in the same order every run
+		for _, key := range sortedKeys(value) {
+			resolvedChild, err := r.resolve(value[key], docPath, stack)
+			if err != nil {
+				return nil, err
+			}
+			result[key] = resolvedChild
+		}
+		return result, nil
+	case map[interface{}]interface{}:
+		return r.resolve(normalizeYAMLValue(value), docPath, stack)
+	case []interface{}:
+		result := make([]interface{}, len(value))
+		for i, child := range value {
+			resolvedChild, err := r.resolve(child, docPath, stack)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = resolvedChild
+		}
+		return result, nil
+	default:
+		return node, nil
+	}
+}
+
+func (r *refResolver) resolveRef(ref string, node map[string]interface{}, docPath string, stack []string) (interface{}, error) {
+	targetDocPath, pointer, err := splitRef(ref, docPath)
+	if err != nil {
+		return nil, err
+	}
+
+	refKey := targetDocPath + "#" + pointer
+	for _, seen := range stack {
+		if seen == refKey {
+			r.recordCircularRef(ref)
+			r.cycleCuts++
+			return node, nil
+		}
+	}
+
+	resolved, cached := r.resolved[refKey]
+	if !cached {
+		document, err := r.loadDocument(targetDocPath)
+		if err != nil {
+			return nil, err
+		}
+
+		target, err := resolveJSONPointer(document, pointer)
+		if err != nil {
+			return nil, fmt.Errorf("error resolving $ref %s: %w", ref, err)
+		}
+
+		cycleCuts := r.cycleCuts
+		resolved, err = r.resolve(target, targetDocPath, append(stack, refKey))
+		if err != nil {
+			return nil, err
+		}
+		// A resolution that left a ref of its chain in place depends on that chain, so only complete ones are reused
+		if r.cycleCuts == cycleCuts {
+			r.resolved[refKey] = resolved
+		}
+	}
+
+	resolvedMap, ok := resolved.(map[string]interface{})
+	if !ok {
+		return resolved, nil
+	}
+
+	// Copy so that each inlined use of a shared component can be annotated independently
+	result := make(map[string]interface{}, len(resolvedMap)+1)
+	for key, child := range resolvedMap {
+		result[key] = child
+	}
+	for key, child := range node {
+		if key == "$ref" {
+			continue
+		}
+		resolvedChild, err := r.resolve(child, docPath, stack)
+		if err != nil {
+			return nil, err
+		}
+		result[key] = resolvedChild
+	}
+	if _, exists := result[resolvedRefKey]; !exists {
+		result[resolvedRefKey] = ref
+	}
+	return result, nil
+}
+
+func (r *refResolver) recordCircularRef(ref string) {
+	for _, existing := range r.circularRefs {
+		if existing == ref {
+			return
+		}
+	}
+	fmt.Printf("Warning: Circular $ref detected: %s\n", ref)
+	r.circularRefs = append(r.circularRefs, ref)
+}
+
+func (r *refResolver) loadDocument(docPath string) (map[string]interface{}, error) {
+	if document, ok := r.documents[docPath]; ok {
+		return document, nil
+	}
+
+	content, err := readFileContent(docPath)
+	if err != nil {
+		return nil, err
+	}
+
+	document, err := parseSpecContent(content)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing referenced file %s: %w", docPath, err)
+	}
+
+	r.documents[docPath] = document
+	return document, nil
+}
+
+// splitRef turns a $ref into the absolute path of the document it points to and its JSON pointer.
+func splitRef(ref string, docPath string) (string, string, error) {
+	filePart, pointer := ref, ""
+	if index := strings.Index(ref, "#"); index >= 0 {
+		filePart, pointer = ref[:index], ref[index+1:]
+	}
+
+	if filePart == "" {
+		return docPath, pointer, nil
+	}
+
+	if strings.HasPrefix(filePart, "http://") || strings.HasPrefix(filePart, "https://") {
+		return "", "", fmt.Errorf("remote $ref %s is not supported", ref)
+	}
+
+	if unescaped, err := url.PathUnescape(filePart); err == nil {
+		filePart = unescaped
+	}
+	if !filepath.IsAbs(filePart) {
+		filePart = filepath.Join(filepath.Dir(docPath), filePart)
+	}
+	return filepath.Clean(filePart), pointer, nil
+}
+
+// resolveJSONPointer walks an RFC 6901 pointer such as /components/schemas/User. Only the empty
+// pointer is the whole document; / names the key "".
+func resolveJSONPointer(document interface{}, pointer string) (interface{}, error) {
+	if pointer == "" {
+		return document, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%s is not a JSON pointer", pointer)
+	}
+
+	current := document
+	for _, token := range strings.Split(pointer[1:], "/") {
+		if unescaped, err := url.PathUnescape(token); err == nil {
+			token = unescaped
+		}
+		token = strings.Replace(token, "~1", "/", -1)
+		token = strings.Replace(token, "~0", "~", -1)
+
+		switch value := current.(type) {
+		case map[string]interface{}:
+			next, ok := value[token]
+			if !ok {
+				return nil, fmt.Errorf("%s not found", pointer)
+			}
+			current = next
+		case map[interface{}]interface{}:
+			next, ok := value[token]
+			if !ok {
+				return nil, fmt.Errorf("%s not found", pointer)
+			}
+			current = next
+		case []interface{}:
+			index, err := strconv.Atoi(token)
+			if err != nil || index < 0 || index >= len(value) {
+				return nil, fmt.Errorf("%s not found", pointer)
+			}
+			current = value[index]
+		default:
+			return nil, fmt.Errorf("%s not found", pointer)
+		}
+	}
+	return current, nil
+}
+
+// schemaRefName returns the component name a schema was referenced by, whether it is
+// still a $ref or was inlined by the resolver.
+func schemaRefName(schema map[string]interface{}) (string, bool) {
+	if ref, ok := schema["$ref"].(string); ok {
+		return filepath.Base(ref), true
+	}
+	if ref, ok := schema[resolvedRefKey].(string); ok {
+		return filepath.Base(ref), true
+	}
+	return "", false
+}
+
+// parseSpecContent parses a JSON or YAML document into string keyed maps.
+func parseSpecContent(content string) (map[string]interface{}, error) {
+	var document map[string]interface{}
+	if err := json.Unmarshal([]byte(content), &document); err == nil {
+		return document, nil
+	}
+
+	var yamlDocument map[interface{}]interface{}
+	if err := yaml.Unmarshal([]byte(content), &yamlDocument); err != nil {
+		return nil, err
+	}
+
+	document, ok := normalizeYAMLValue(yamlDocument).(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("document is not an object")
+	}
+	return document, nil
+}
+
+// normalizeYAMLValue converts the map[interface{}]interface{} values produced by yaml.v2
+// into map[string]interface{} so YAML specs can be walked like JSON ones.
+func normalizeYAMLValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			result[fmt.Sprintf("%v", key)] = normalizeYAMLValue(child)
+		}
+		return result
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			result[key] = normalizeYAMLValue(child)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, child := range v {
+			result[i] = normalizeYAMLValue(child)
+		}
+		return result
+	default:
+		return value
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveJSONPointer(t *testing.T) {
+	document := map[string]interface{}{
+		"":    "empty key",
+		"a/b": map[string]interface{}{"~": []interface{}{"first", "second"}},
+	}
+
+	cases := []struct {
+		pointer string
+		want    interface{}
+	}{
+		{"/", "empty key"},
+		{"/a~1b/~0/1", "second"},
+	}
+	for _, c := range cases {
+		got, err := resolveJSONPointer(document, c.pointer)
+		if err != nil || got != c.want {
+			t.Errorf("resolveJSONPointer(%q) = %v, %v; want %v", c.pointer, got, err, c.want)
+		}
+	}
+
+	if got, err := resolveJSONPointer(document, ""); err != nil || fmt.Sprint(got) != fmt.Sprint(document) {
+		t.Errorf("the empty pointer resolved to %v, %v; want the document", got, err)
+	}
+	for _, pointer := range []string{"components", "/missing"} {
+		if _, err := resolveJSONPointer(document, pointer); err == nil {
+			t.Errorf("resolveJSONPointer(%q) succeeded", pointer)
+		}
+	}
+}
+
+// TestResolveSharedRefsOnce builds schemas that each reference the next one twice; resolving every
+// use anew would take 2^depth steps.
+func TestResolveSharedRefsOnce(t *testing.T) {
+	const depth = 40
+	schemas := make(map[string]interface{}, depth+1)
+	for i := 0; i < depth; i++ {
+		next := map[string]interface{}{"$ref": fmt.Sprintf("#/components/schemas/S%d", i+1)}
+		schemas[fmt.Sprintf("S%d", i)] = map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"left": next, "right": next},
+		}
+	}
+	schemas[fmt.Sprintf("S%d", depth)] = map[string]interface{}{"type": "string"}
+	swagger := map[string]interface{}{"components": map[string]interface{}{"schemas": schemas}}
+
+	resolved, circular, err := resolveSwaggerRefs(swagger, filepath.Join(t.TempDir(), "openapi.json"))
+	if err != nil || len(circular) > 0 {
+		t.Fatalf("resolveSwaggerRefs: %v, circular %v", err, circular)
+	}
+
+	schema := resolved["components"].(map[string]interface{})["schemas"].(map[string]interface{})["S0"]
+	for i := 0; i < depth; i++ {
+		schema = schema.(map[string]interface{})["properties"].(map[string]interface{})["right"]
+	}
+	if schemaType(schema.(map[string]interface{})) != "string" {
+		t.Fatalf("innermost schema is %v", schema)
+	}
+}
@@ -3,19 +3,24 @@ package main
 import (
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 
+	"k6-generator/jsemit"
+
 	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	K6 *struct {
-		VUs               *int                `yaml:"vus"`
-		Iterations        *int                `yaml:"iterations"`
-		LoopCount         *int                `yaml:"loop_count"`
-		SleepBetweenTests *int                `yaml:"sleep_between_tests"`
-		Thresholds        map[string][]string `yaml:"thresholds"`
-	} `yaml:"k6"`
+	K6 *K6Config `yaml:"k6"`
+}
+
+type K6Config struct {
+	VUs               *int                `yaml:"vus"`
+	Iterations        *int                `yaml:"iterations"`
+	LoopCount         *int                `yaml:"loop_count"`
+	SleepBetweenTests *int                `yaml:"sleep_between_tests"`
+	Thresholds        map[string][]string `yaml:"thresholds"`
 }
 
 type TestFile struct {
@@ -52,10 +57,23 @@ func main() {
 	yaml.Unmarshal(testBytes, &tests)
 	yaml.Unmarshal(cfgBytes, &cfg)
 
-	vus := resolve(cfg.K6, func(k *int) int { return *k }, 1)
-	iters := resolve(cfg.K6, func(k *int) int { return *k }, 1)
-	loops := resolve(cfg.K6, func(k *int) int { return *k }, 1)
-	sleep := resolve(cfg.K6, func(k *int) int { return *k }, 1)
+	script, err := generateScript(tests, cfg)
+	if err != nil {
+		fmt.Println("❌", err)
+		os.Exit(1)
+	}
+
+	os.MkdirAll("output", 0755)
+	os.WriteFile("output/script.js", []byte(script), 0644)
+	fmt.Println("✅ k6 script generated: output/script.js")
+}
+
+// generateScript builds the k6 script running every test of tests with the k6 settings of cfg.
+func generateScript(tests TestFile, cfg Config) (string, error) {
+	vus := resolve(cfg.K6, func(k *K6Config) *int { return k.VUs }, 1)
+	iters := resolve(cfg.K6, func(k *K6Config) *int { return k.Iterations }, 1)
+	loops := resolve(cfg.K6, func(k *K6Config) *int { return k.LoopCount }, 1)
+	sleep := resolve(cfg.K6, func(k *K6Config) *int { return k.SleepBetweenTests }, 1)
 
 	var sb strings.Builder
 
@@ -70,15 +88,38 @@ export let options = {
 	if cfg.K6 != nil && len(cfg.K6.Thresholds) > 0 {
 		sb.WriteString("  thresholds: {\n")
 		for k, v := range cfg.K6.Thresholds {
-			sb.WriteString(fmt.Sprintf("    %s: %v,\n", k, v))
+			sb.WriteString(fmt.Sprintf("    %s: %s,\n", jsemit.Property(k), jsemit.StringArray(v)))
 		}
 		sb.WriteString("  },\n")
 	}
 
 	sb.WriteString("};\n\nexport default function () {\n")
 
-	for k, v := range tests.Variables {
-		sb.WriteString(fmt.Sprintf("  let %s = '%s';\n", k, v))
+	// Variables and extracted values are declared once, so later tests can use them in their paths
+	declared := map[string]string{}
+	declare := func(name string, value string) error {
+		identifier := jsemit.Identifier(name)
+		if other, ok := declared[identifier]; ok {
+			if other == name {
+				return nil
+			}
+			return fmt.Errorf("%s and %s both become the variable %s", other, name, identifier)
+		}
+		declared[identifier] = name
+		sb.WriteString(fmt.Sprintf("  let %s%s;\n", identifier, value))
+		return nil
+	}
+	for _, k := range sortedKeys(tests.Variables) {
+		if err := declare(k, " = "+jsemit.String(tests.Variables[k])); err != nil {
+			return "", err
+		}
+	}
+	for _, t := range tests.Tests {
+		for _, v := range sortedKeys(t.Extract) {
+			if err := declare(v, ""); err != nil {
+				return "", err
+			}
+		}
 	}
 
 	sb.WriteString(fmt.Sprintf("\n  for (let i = 0; i < %d; i++) {\n", loops))
@@ -89,39 +130,41 @@ export let options = {
 	}
 
 	sb.WriteString("  }\n}\n")
-
-	os.MkdirAll("output", 0755)
-	os.WriteFile("output/script.js", []byte(sb.String()), 0644)
-	fmt.Println("✅ k6 script generated: output/script.js")
+	return sb.String(), nil
 }
 
-func resolve(k6 *struct {
-	VUs               *int
-	Iterations        *int
-	LoopCount         *int
-	SleepBetweenTests *int
-	Thresholds        map[string][]string
-}, getter func(*int) int, def int) int {
-	if k6 == nil {
+// resolve returns the setting field picks from k6, or def when it is not configured.
+func resolve(k6 *K6Config, field func(*K6Config) *int, def int) int {
+	if k6 == nil || field(k6) == nil {
 		return def
 	}
-	return def
+	return *field(k6)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
 }
 
 func generateTest(t TestCase, baseURL string) string {
 	var sb strings.Builder
 
-	url := baseURL + t.Request.Endpoint
+	url := jsemit.TemplateText(baseURL + t.Request.Endpoint)
 	for k := range t.Request.PathParams {
-		url = strings.ReplaceAll(url, "{"+k+"}", "${"+k+"}")
+		url = strings.ReplaceAll(url, jsemit.TemplateText("{"+k+"}"), "${"+jsemit.Identifier(k)+"}")
 	}
 
-	sb.WriteString(fmt.Sprintf("\n    // %s\n", t.ID))
+	// Each test runs in its own block, so its url, params and res do not clash with the next test's
+	sb.WriteString(fmt.Sprintf("\n    // %s\n    {\n", jsemit.Comment(t.ID)))
 	sb.WriteString(fmt.Sprintf("    let url = `%s`;\n", url))
 
 	sb.WriteString("    let params = { headers: {")
 	for k, v := range t.Request.Headers {
-		sb.WriteString(fmt.Sprintf(" '%s':'%s',", k, v))
+		sb.WriteString(fmt.Sprintf(" %s:%s,", jsemit.String(k), jsemit.String(v)))
 	}
 	sb.WriteString(" } };\n")
 
@@ -131,21 +174,26 @@ func generateTest(t TestCase, baseURL string) string {
 	}
 
 	sb.WriteString(fmt.Sprintf(
-		"    let res = http.request('%s', url, %s, params);\n",
-		t.Request.Method, body,
+		"    let res = http.request(%s, url, %s, params);\n",
+		jsemit.String(t.Request.Method), body,
 	))
 
 	sb.WriteString(fmt.Sprintf(
-		"    check(res, { '%s status': r => r.status === %d });\n",
-		t.ID, t.Expect.Status,
+		"    check(res, { %s: r => r.status === %d });\n",
+		jsemit.String(t.ID+" status"), t.Expect.Status,
 	))
 
 	for v, p := range t.Extract {
+		value := "JSON.parse(res.body)"
+		for _, field := range strings.Split(strings.TrimPrefix(p, "response.body."), ".") {
+			value = jsemit.Member(value, field)
+		}
 		sb.WriteString(fmt.Sprintf(
-			"    let %s = JSON.parse(res.body).%s;\n",
-			v, strings.TrimPrefix(p, "response.body."),
+			"    %s = %s;\n",
+			jsemit.Identifier(v), value,
 		))
 	}
+	sb.WriteString("    }\n")
 
 	return sb.String()
 }
@@ -158,7 +206,7 @@ func toJS(m map[string]interface{}) string {
 		if i > 0 {
 			s.WriteString(",")
 		}
-		s.WriteString(fmt.Sprintf(`"%s":"%v"`, k, v))
+		s.WriteString(fmt.Sprintf("%s:%s", jsemit.String(k), jsemit.String(fmt.Sprint(v))))
 		i++
 	}
 	s.WriteString("}")
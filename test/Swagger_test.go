@@ -0,0 +1,87 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// hostileValues look like what a tests.yaml may carry into the script.
+var hostileValues = []string{
+	"O'Brien",
+	`say "hi"`,
+	"line1\nline2",
+	"`${__ENV.SECRET}`",
+	"*/ throw new Error() /*",
+	`trailing\`,
+	" ",
+	"get-user",
+	"delete",
+}
+
+func TestGenerateScriptWithHostileValues(t *testing.T) {
+	loops := 2
+	tests := TestFile{Variables: map[string]string{}}
+	tests.API.BaseURL = "https://api.example.com/`${x}`"
+	for i, value := range hostileValues {
+		test := TestCase{
+			ID: value,
+			Request: Request{
+				Method:     value,
+				Endpoint:   "/users/{" + value + "}?q=" + value,
+				PathParams: map[string]string{value: value},
+				Headers:    map[string]string{value: value},
+				Body:       map[string]interface{}{value: value, "nested": map[string]interface{}{"a": value}},
+			},
+			Extract: map[string]string{"extracted": "response.body." + value + ".id"},
+		}
+		test.Expect.Status = 200
+		tests.Tests = append(tests.Tests, test)
+		tests.Variables[string(rune('a'+i))+value] = value
+	}
+	cfg := Config{K6: &K6Config{LoopCount: &loops, Thresholds: map[string][]string{"http_req_duration{name:" + hostileValues[0] + "}": hostileValues}}}
+
+	script, err := generateScript(tests, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkScriptSyntax(t, script)
+}
+
+func TestGenerateScriptRejectsClashingVariables(t *testing.T) {
+	tests := TestFile{Variables: map[string]string{"user-id": "1", "user_id": "2"}}
+	if _, err := generateScript(tests, Config{}); err == nil {
+		t.Fatal("user-id and user_id were both declared as user_id")
+	}
+}
+
+func TestResolve(t *testing.T) {
+	vus := 5
+	k6 := &K6Config{VUs: &vus}
+	if got := resolve(k6, func(k *K6Config) *int { return k.VUs }, 1); got != 5 {
+		t.Errorf("configured vus resolved to %d", got)
+	}
+	if got := resolve(k6, func(k *K6Config) *int { return k.Iterations }, 1); got != 1 {
+		t.Errorf("missing iterations resolved to %d", got)
+	}
+	if got := resolve(nil, func(k *K6Config) *int { return k.VUs }, 3); got != 3 {
+		t.Errorf("missing k6 section resolved to %d", got)
+	}
+}
+
+// checkScriptSyntax fails when node cannot parse script as a module.
+func checkScriptSyntax(t *testing.T, script string) {
+	t.Helper()
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("node is not installed, the script is not parsed")
+	}
+	path := filepath.Join(t.TempDir(), "script.mjs")
+	if err := os.WriteFile(path, []byte(script), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if output, err := exec.Command(node, "--check", path).CombinedOutput(); err != nil {
+		t.Fatalf("node rejected the script: %v\n%s\n%s", err, output, script)
+	}
+}
@@ -0,0 +1,232 @@
+import http from 'k6/http';
+import { check, sleep } from 'k6';
+import { htmlReport } from './bundle.js';
+import { Trend } from 'k6/metrics';
+import { SharedArray } from 'k6/data';
+import papaparse from 'https://jslib.k6.io/papaparse/5.1.1/index.js';
+import exec from 'k6/execution';
+import encoding from 'k6/encoding';
+
+export const options = {
+	insecureSkipTLSVerify: false,
+	scenarios: {
+		"getUser": {
+			executor: 'constant-arrival-rate',
+			exec: 'getUserScenario',
+			rate: 14,
+			timeUnit: '1s',
+			duration: '5m',
+			preAllocatedVUs: 7,
+			maxVUs: 35,
+		},
+		"other": {
+			executor: 'constant-arrival-rate',
+			exec: 'otherScenario',
+			rate: 2,
+			timeUnit: '1s',
+			duration: '5m',
+			preAllocatedVUs: 1,
+			maxVUs: 5,
+		},
+		"tag_orders": {
+			executor: 'constant-arrival-rate',
+			exec: 'tag_ordersScenario',
+			rate: 4,
+			timeUnit: '1s',
+			duration: '5m',
+			preAllocatedVUs: 2,
+			maxVUs: 10,
+		},
+	},
+	thresholds: {
+		'checks': [{ threshold: 'rate>0.99', abortOnFail: true, delayAbortEval: '30s' }],
+		'getUser': ['p(95)<200'],
+		'http_req_duration': [{ threshold: 'p(95)<500', abortOnFail: true, delayAbortEval: '30s' }, { threshold: 'p(99)<1500', abortOnFail: true, delayAbortEval: '30s' }],
+		'http_req_duration{name:getUser}': ['p(95)<300'],
+		'http_req_duration{operation:getUser}': ['p(95)<200'],
+		'http_req_failed': [{ threshold: 'rate<0.01', abortOnFail: true, delayAbortEval: '30s' }],
+		'http_req_failed{operation:getUser}': ['rate<0.001'],
+	},
+};
+
+// Add Trend metrics
+const putBlobTrend = new Trend('putBlob');
+const uploadDocTrend = new Trend('uploadDoc');
+const loginTrend = new Trend('login');
+const postNoteTrend = new Trend('postNote');
+const deleteItemTrend = new Trend('deleteItem');
+const searchItemsTrend = new Trend('searchItems');
+const createUserTrend = new Trend('createUser');
+const getUserTrend = new Trend('getUser');
+const putXmlTrend = new Trend('putXml');
+
+// Datasets shared by all VUs, loaded once in the init stage
+const dataset_search_json = new SharedArray('search.json', function () {
+	return JSON.parse(open('../fitness/dev/search.json'));
+});
+const dataset_users_csv = new SharedArray('users.csv', function () {
+	return papaparse.parse(open('../fitness/dev/users.csv'), { header: true, skipEmptyLines: true }).data;
+});
+
+// Files uploaded by the requests, opened once in the init stage
+const putBlob_body_file = open('../fitness/dev/putBlob_body.bin', 'b');
+const uploadDoc_doc_file = open('../fitness/dev/uploadDoc_doc.pdf', 'b');
+
+// Obtain credentials once for all VUs
+export function setup() {
+	const auth = {};
+	auth["basic"] = encoding.b64encode('alice' + ':' + 'p\'w');
+	auth["key"] = '9876';
+	const tokenRes_OAUTH = http.post('https://dev.example.com/v1/oauth/token', { grant_type: 'client_credentials', client_id: 'k6', client_secret: 'shh', scope: 'read write' });
+	check(tokenRes_OAUTH, { 'oauth_token_check': (r) => r.status == 200 });
+	if (tokenRes_OAUTH.status !== 200 || !tokenRes_OAUTH.json('access_token')) {
+		throw new Error('could not obtain an access token for oauth: status ' + tokenRes_OAUTH.status);
+	}
+	auth["oauth"] = tokenRes_OAUTH.json('access_token');
+	auth["sess"] = 's1';
+	return { auth: auth };
+}
+
+// Scenario getUser: weight 7 of 10
+export function getUserScenario(data) {
+
+	// getUser: GET /users/{id}
+	const getUser_row = dataset_users_csv[Math.floor(Math.random() * dataset_users_csv.length)];
+	const getUser_baseUrl = 'https://dev.example.com/v1' + '/users/' + encodeURIComponent(getUser_row["id"]);
+	const getUser_queryParams = `?api_key=k-1&verbose=${encodeURIComponent(getUser_row["verbose"])}`;
+	const getUser_url = getUser_baseUrl + getUser_queryParams;
+	const getUser_body = JSON.stringify(null);
+	const getUser_headers = {"Authorization":'Bearer ' + data.auth["oauth"],"X-Trace":String(getUser_row["X-Trace"])};
+	const getUser_cookies = {"session_token":"s3cr3t","theme":encodeURIComponent(getUser_row["theme"])};
+	let getUser_res = http.get(getUser_url, { headers: getUser_headers, cookies: getUser_cookies, tags: { operation: 'getUser' } });
+	getUserTrend.add(getUser_res.timings.duration);
+	check(getUser_res, {
+		'getUser_status_200_check': (r) => r.status == 200,
+		'getUser_response_schema_check': (r) => {
+			try {
+				const body = r.json();
+				return typeof body === 'object' && body !== null && !Array.isArray(body) && body["id"] !== undefined && body["name"] !== undefined && (body["id"] === undefined || body["id"] === null || (Number.isInteger(body["id"]))) && (body["name"] === undefined || body["name"] === null || (typeof body["name"] === 'string')) && (body["tags"] === undefined || body["tags"] === null || (Array.isArray(body["tags"]) && body["tags"].every((item1) => typeof item1 === 'string')));
+			} catch (e) {
+				return false;
+			}
+		},
+	}, { operation: 'getUser' });
+}
+
+// Scenario other: weight 1 of 10
+export function otherScenario(data) {
+
+	// putBlob: PUT /blob
+	const putBlob_baseUrl = 'https://dev.example.com/v1/blob';
+	const putBlob_queryParams = ``;
+	const putBlob_url = putBlob_baseUrl + putBlob_queryParams;
+	const putBlob_body = putBlob_body_file;
+	const putBlob_headers = {"Content-Type":"application/octet-stream"};
+	let putBlob_res = http.put(putBlob_url, putBlob_body, { headers: putBlob_headers });
+	putBlobTrend.add(putBlob_res.timings.duration);
+	check(putBlob_res, {
+		'putBlob_status_200_check': (r) => r.status == 200,
+	});
+
+	// uploadDoc: POST /docs
+	const uploadDoc_baseUrl = 'https://dev.example.com/v1/docs';
+	const uploadDoc_queryParams = ``;
+	const uploadDoc_url = uploadDoc_baseUrl + uploadDoc_queryParams;
+	const uploadDoc_body = { "doc": http.file(uploadDoc_doc_file, 'uploadDoc_doc.pdf', 'application/pdf'), "title": 'Q1' };
+	const uploadDoc_headers = {};
+	let uploadDoc_res = http.post(uploadDoc_url, uploadDoc_body, { headers: uploadDoc_headers });
+	uploadDocTrend.add(uploadDoc_res.timings.duration);
+	check(uploadDoc_res, {
+		'uploadDoc_status_200_check': (r) => r.status == 200,
+	});
+
+	// login: POST /login
+	const login_baseUrl = 'https://dev.example.com/v1/login';
+	const login_queryParams = ``;
+	const login_url = login_baseUrl + login_queryParams;
+	const login_body = { "meta": '{"a":1}', "remember": 'true', "user": 'bob' };
+	const login_headers = {"Content-Type":"application/x-www-form-urlencoded"};
+	let login_res = http.post(login_url, login_body, { headers: login_headers });
+	loginTrend.add(login_res.timings.duration);
+	check(login_res, {
+		'login_status_200_check': (r) => r.status == 200,
+	});
+
+	// postNote: POST /note
+	const postNote_baseUrl = 'https://dev.example.com/v1/note';
+	const postNote_queryParams = ``;
+	const postNote_url = postNote_baseUrl + postNote_queryParams;
+	const postNote_body = 'it\'s a note\nline2';
+	const postNote_headers = {"Content-Type":"text/plain"};
+	let postNote_res = http.post(postNote_url, postNote_body, { headers: postNote_headers });
+	postNoteTrend.add(postNote_res.timings.duration);
+	check(postNote_res, {
+		'postNote_status_200_check': (r) => r.status == 200,
+	});
+
+	// searchItems: GET /search/{coords}
+	if (exec.scenario.iterationInTest >= dataset_search_json.length) {
+		exec.test.abort('dataset search.json has no rows left for searchItems');
+	}
+	const searchItems_row = dataset_search_json[exec.scenario.iterationInTest];
+	const searchItems_baseUrl = 'https://dev.example.com/v1' + '/search/' + encodeURIComponent(searchItems_row["coords"]);
+	const searchItems_queryParams = `?filter[owner]=me%26you&filter[status]=open&ids=1|2|3&x=1&y=a%20b&q=${encodeURIComponent(searchItems_row["q"])}&tags=red,blue%20sky`;
+	const searchItems_url = searchItems_baseUrl + searchItems_queryParams;
+	const searchItems_body = JSON.stringify(null);
+	const searchItems_headers = {"Authorization":'Bearer ' + data.auth["oauth"],"X-Ids":String(searchItems_row["ids"])};
+	let searchItems_res = http.get(searchItems_url, { headers: searchItems_headers });
+	searchItemsTrend.add(searchItems_res.timings.duration);
+	check(searchItems_res, {
+		'searchItems_status_200_check': (r) => r.status == 200,
+	});
+
+	// createUser: POST /users
+	const createUser_row = dataset_search_json[(__VU - 1 + __ITER) % dataset_search_json.length];
+	const createUser_baseUrl = 'https://users-dev.example.com/users';
+	const createUser_queryParams = ``;
+	const createUser_url = createUser_baseUrl + createUser_queryParams;
+	const createUser_body = JSON.stringify({"age":18,"code":"AAA-1111","email":createUser_row["email"],"pet":{"name":"str"},"profile":{"nick":createUser_row["q"]},"role":"admin","score":1.5,"tags":["string","stringx"]});
+	const createUser_headers = {"Authorization":'Basic ' + data.auth["basic"],"Content-Type":"application/json"};
+	const createUser_cookies = {"SESSION":data.auth["sess"]};
+	let createUser_res = http.post(createUser_url, createUser_body, { headers: createUser_headers, cookies: createUser_cookies });
+	createUserTrend.add(createUser_res.timings.duration);
+	check(createUser_res, {
+		'createUser_status_201_check': (r) => r.status == 201,
+	});
+
+	// putXml: PUT /xml
+	const putXml_baseUrl = 'https://dev.example.com/v1/xml';
+	const putXml_queryParams = ``;
+	const putXml_url = putXml_baseUrl + putXml_queryParams;
+	const putXml_body = '<order><id>3</id><items>a&amp;b</items></order>';
+	const putXml_headers = {"Content-Type":"application/xml"};
+	let putXml_res = http.put(putXml_url, putXml_body, { headers: putXml_headers });
+	putXmlTrend.add(putXml_res.timings.duration);
+	check(putXml_res, {
+		'putXml_status_200_check': (r) => r.status == 200,
+	});
+}
+
+// Scenario tag_orders: weight 2 of 10
+export function tag_ordersScenario(data) {
+
+	// deleteItem: DELETE /orders/{orderId}/items/{itemId}
+	const deleteItem_baseUrl = 'https://orders.example.com/a/orders/ord-1/items/a%20b';
+	const deleteItem_queryParams = `?key=${encodeURIComponent(data.auth["key"])}`;
+	const deleteItem_url = deleteItem_baseUrl + deleteItem_queryParams;
+	const deleteItem_body = JSON.stringify(null);
+	const deleteItem_headers = {};
+	let deleteItem_res = http.del(deleteItem_url, null, { headers: deleteItem_headers });
+	deleteItemTrend.add(deleteItem_res.timings.duration);
+	check(deleteItem_res, {
+		'deleteItem_status_204_check': (r) => r.status == 204,
+	});
+}
+
+// Generate HTML Report
+export function handleSummary(data) {
+	return {
+		"default-summary.html": htmlReport(data),
+		"default-summary.json": JSON.stringify(data),
+	};
+}
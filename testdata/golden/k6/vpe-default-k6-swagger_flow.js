@@ -0,0 +1,130 @@
+import http from 'k6/http';
+import { check, sleep } from 'k6';
+import { htmlReport } from './bundle.js';
+import { Trend } from 'k6/metrics';
+
+export const options = {
+	insecureSkipTLSVerify: true,
+	scenarios: {
+		"default": {
+			executor: 'ramping-vus',
+			stages: [
+				{ duration: '1m', target: 1 },
+			],
+		},
+	},
+};
+
+// Add Trend metrics
+const listOrdersTrend = new Trend('listOrders');
+const createOrderTrend = new Trend('createOrder');
+const getOrderTrend = new Trend('getOrder');
+const deleteOrderTrend = new Trend('deleteOrder');
+const copyOrderTrend = new Trend('copyOrder');
+
+// Values extracted by the flow; undefined when the response does not hold them
+function extractJSON(res, selector) {
+	try {
+		const value = selector === '' ? res.json() : res.json(selector);
+		return value === null ? undefined : value;
+	} catch (e) {
+		return undefined;
+	}
+}
+
+function extractRegex(res, pattern) {
+	const match = new RegExp(pattern).exec(res.body || '');
+	if (!match) {
+		return undefined;
+	}
+	return match.length > 1 ? match[1] : match[0];
+}
+
+export default function () {
+	const flow = {};
+
+	// createOrder: POST /orders
+	const createOrder_baseUrl = 'https://flow.example.com/orders';
+	const createOrder_queryParams = ``;
+	const createOrder_url = createOrder_baseUrl + createOrder_queryParams;
+	const createOrder_body = JSON.stringify({"item":"pen","parent":"none"});
+	const createOrder_headers = {"Content-Type":"application/json"};
+	let createOrder_res = http.post(createOrder_url, createOrder_body, { headers: createOrder_headers });
+	createOrderTrend.add(createOrder_res.timings.duration);
+	check(createOrder_res, {
+		'createOrder_status_201_check': (r) => r.status == 201,
+	});
+	flow.etag = createOrder_res.headers['Etag'];
+	flow.orderId = extractJSON(createOrder_res, 'data.id');
+	flow.token = extractRegex(createOrder_res, 'token=(\\w+)');
+	check(createOrder_res, {
+		'createOrder_extract_etag_check': () => flow.etag !== undefined,
+		'createOrder_extract_orderId_check': () => flow.orderId !== undefined,
+		'createOrder_extract_token_check': () => flow.token !== undefined,
+	});
+
+	// getOrder runs only when the values it reads were extracted
+	if (flow.etag !== undefined && flow.orderId !== undefined && flow.token !== undefined) {
+		// getOrder: GET /orders/{id}
+		const getOrder_baseUrl = 'https://flow.example.com' + '/orders/' + encodeURIComponent(flow.orderId);
+		const getOrder_queryParams = `?expand=${encodeURIComponent(flow.token)}`;
+		const getOrder_url = getOrder_baseUrl + getOrder_queryParams;
+		const getOrder_body = JSON.stringify(null);
+		const getOrder_headers = {"If-None-Match":String(flow.etag)};
+		let getOrder_res = http.get(getOrder_url, { headers: getOrder_headers });
+		getOrderTrend.add(getOrder_res.timings.duration);
+		check(getOrder_res, {
+			'getOrder_status_200_check': (r) => r.status == 200,
+		});
+	}
+
+	// copyOrder runs only when the values it reads were extracted
+	if (flow.orderId !== undefined) {
+		// copyOrder: POST /orders/{id}/copy
+		const copyOrder_baseUrl = 'https://flow.example.com' + '/orders/' + encodeURIComponent(flow.orderId) + '/copy';
+		const copyOrder_queryParams = ``;
+		const copyOrder_url = copyOrder_baseUrl + copyOrder_queryParams;
+		const copyOrder_body = JSON.stringify({"source":flow.orderId});
+		const copyOrder_headers = {"Content-Type":"application/json"};
+		let copyOrder_res = http.post(copyOrder_url, copyOrder_body, { headers: copyOrder_headers });
+		copyOrderTrend.add(copyOrder_res.timings.duration);
+		check(copyOrder_res, {
+			'copyOrder_status_201_check': (r) => r.status == 201,
+		});
+	}
+
+	// deleteOrder runs only when the values it reads were extracted
+	if (flow.orderId !== undefined) {
+		// deleteOrder: DELETE /orders/{id}
+		const deleteOrder_baseUrl = 'https://flow.example.com' + '/orders/' + encodeURIComponent(flow.orderId);
+		const deleteOrder_queryParams = ``;
+		const deleteOrder_url = deleteOrder_baseUrl + deleteOrder_queryParams;
+		const deleteOrder_body = JSON.stringify(null);
+		const deleteOrder_headers = {};
+		let deleteOrder_res = http.del(deleteOrder_url, null, { headers: deleteOrder_headers });
+		deleteOrderTrend.add(deleteOrder_res.timings.duration);
+		check(deleteOrder_res, {
+			'deleteOrder_status_204_check': (r) => r.status == 204,
+		});
+	}
+
+	// listOrders: GET /orders
+	const listOrders_baseUrl = 'https://flow.example.com/orders';
+	const listOrders_queryParams = ``;
+	const listOrders_url = listOrders_baseUrl + listOrders_queryParams;
+	const listOrders_body = JSON.stringify(null);
+	const listOrders_headers = {};
+	let listOrders_res = http.get(listOrders_url, { headers: listOrders_headers });
+	listOrdersTrend.add(listOrders_res.timings.duration);
+	check(listOrders_res, {
+		'listOrders_status_200_check': (r) => r.status == 200,
+	});
+}
+
+// Generate HTML Report
+export function handleSummary(data) {
+	return {
+		"default-summary.html": htmlReport(data),
+		"default-summary.json": JSON.stringify(data),
+	};
+}
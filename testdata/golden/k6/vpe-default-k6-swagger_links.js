@@ -0,0 +1,153 @@
+import http from 'k6/http';
+import { check, sleep } from 'k6';
+import { htmlReport } from './bundle.js';
+import { Trend } from 'k6/metrics';
+
+export const options = {
+	insecureSkipTLSVerify: true,
+	scenarios: {
+		"default": {
+			executor: 'ramping-vus',
+			stages: [
+				{ duration: '1m', target: 1 },
+			],
+		},
+	},
+};
+
+// Add Trend metrics
+const createItemTrend = new Trend('createItem');
+const listOrdersTrend = new Trend('listOrders');
+const createOrderTrend = new Trend('createOrder');
+const getOrderTrend = new Trend('getOrder');
+const deleteOrderTrend = new Trend('deleteOrder');
+const copyOrderTrend = new Trend('copyOrder');
+
+// Values extracted by the flow; undefined when the response does not hold them
+function extractJSON(res, selector) {
+	try {
+		const value = selector === '' ? res.json() : res.json(selector);
+		return value === null ? undefined : value;
+	} catch (e) {
+		return undefined;
+	}
+}
+
+function extractRegex(res, pattern) {
+	const match = new RegExp(pattern).exec(res.body || '');
+	if (!match) {
+		return undefined;
+	}
+	return match.length > 1 ? match[1] : match[0];
+}
+
+export default function () {
+	const flow = {};
+
+	// createOrder: POST /orders
+	const createOrder_baseUrl = 'https://links.example.com/orders';
+	const createOrder_queryParams = ``;
+	const createOrder_url = createOrder_baseUrl + createOrder_queryParams;
+	const createOrder_body = JSON.stringify({"item":"pen"});
+	const createOrder_headers = {"Content-Type":"application/json"};
+	let createOrder_res = http.post(createOrder_url, createOrder_body, { headers: createOrder_headers });
+	createOrderTrend.add(createOrder_res.timings.duration);
+	check(createOrder_res, {
+		'createOrder_status_201_check': (r) => r.status == 201,
+	});
+	flow.createOrder_If_None_Match = createOrder_res.headers['Etag'];
+	flow.createOrder_id = extractJSON(createOrder_res, 'data.id');
+	check(createOrder_res, {
+		'createOrder_extract_createOrder_If_None_Match_check': () => flow.createOrder_If_None_Match !== undefined,
+		'createOrder_extract_createOrder_id_check': () => flow.createOrder_id !== undefined,
+	});
+
+	// getOrder runs only when the values it reads were extracted
+	if (flow.createOrder_If_None_Match !== undefined && flow.createOrder_id !== undefined) {
+		// getOrder: GET /orders/{id}
+		const getOrder_baseUrl = 'https://links.example.com' + '/orders/' + encodeURIComponent(flow.createOrder_id);
+		const getOrder_queryParams = `?expand=all`;
+		const getOrder_url = getOrder_baseUrl + getOrder_queryParams;
+		const getOrder_body = JSON.stringify(null);
+		const getOrder_headers = {"If-None-Match":String(flow.createOrder_If_None_Match)};
+		let getOrder_res = http.get(getOrder_url, { headers: getOrder_headers });
+		getOrderTrend.add(getOrder_res.timings.duration);
+		check(getOrder_res, {
+			'getOrder_status_200_check': (r) => r.status == 200,
+		});
+		flow.getOrder_id = extractJSON(getOrder_res, 'id');
+		check(getOrder_res, {
+			'getOrder_extract_getOrder_id_check': () => flow.getOrder_id !== undefined,
+		});
+	}
+
+	// copyOrder runs only when the values it reads were extracted
+	if (flow.getOrder_id !== undefined) {
+		// copyOrder: POST /orders/{id}/copy
+		const copyOrder_baseUrl = 'https://links.example.com' + '/orders/' + encodeURIComponent(flow.getOrder_id) + '/copy';
+		const copyOrder_queryParams = ``;
+		const copyOrder_url = copyOrder_baseUrl + copyOrder_queryParams;
+		const copyOrder_body = JSON.stringify({"source":"x"});
+		const copyOrder_headers = {"Content-Type":"application/json"};
+		let copyOrder_res = http.post(copyOrder_url, copyOrder_body, { headers: copyOrder_headers });
+		copyOrderTrend.add(copyOrder_res.timings.duration);
+		check(copyOrder_res, {
+			'copyOrder_status_201_check': (r) => r.status == 201,
+		});
+	}
+
+	// deleteOrder runs only when the values it reads were extracted
+	if (flow.createOrder_id !== undefined) {
+		// deleteOrder: DELETE /orders/{id}
+		const deleteOrder_baseUrl = 'https://links.example.com' + '/orders/' + encodeURIComponent(flow.createOrder_id);
+		const deleteOrder_queryParams = ``;
+		const deleteOrder_url = deleteOrder_baseUrl + deleteOrder_queryParams;
+		const deleteOrder_body = JSON.stringify(null);
+		const deleteOrder_headers = {};
+		let deleteOrder_res = http.del(deleteOrder_url, null, { headers: deleteOrder_headers });
+		deleteOrderTrend.add(deleteOrder_res.timings.duration);
+		check(deleteOrder_res, {
+			'deleteOrder_status_204_check': (r) => r.status == 204,
+		});
+	}
+
+	// createItem: POST /items
+	const createItem_baseUrl = 'https://links.example.com/items';
+	const createItem_queryParams = ``;
+	const createItem_url = createItem_baseUrl + createItem_queryParams;
+	const createItem_body = JSON.stringify(null);
+	const createItem_headers = {};
+	let createItem_res = http.post(createItem_url, createItem_body, { headers: createItem_headers });
+	createItemTrend.add(createItem_res.timings.duration);
+	check(createItem_res, {
+		'createItem_status_201_check': (r) => r.status == 201,
+		'createItem_response_schema_check': (r) => {
+			try {
+				const body = r.json();
+				return typeof body === 'object' && body !== null && !Array.isArray(body) && (body["itemId"] === undefined || body["itemId"] === null || (typeof body["itemId"] === 'string'));
+			} catch (e) {
+				return false;
+			}
+		},
+	});
+
+	// listOrders: GET /orders
+	const listOrders_baseUrl = 'https://links.example.com/orders';
+	const listOrders_queryParams = ``;
+	const listOrders_url = listOrders_baseUrl + listOrders_queryParams;
+	const listOrders_body = JSON.stringify(null);
+	const listOrders_headers = {};
+	let listOrders_res = http.get(listOrders_url, { headers: listOrders_headers });
+	listOrdersTrend.add(listOrders_res.timings.duration);
+	check(listOrders_res, {
+		'listOrders_status_200_check': (r) => r.status == 200,
+	});
+}
+
+// Generate HTML Report
+export function handleSummary(data) {
+	return {
+		"default-summary.html": htmlReport(data),
+		"default-summary.json": JSON.stringify(data),
+	};
+}
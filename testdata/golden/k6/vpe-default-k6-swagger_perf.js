@@ -0,0 +1,43 @@
+import http from 'k6/http';
+import { check, sleep } from 'k6';
+import { htmlReport } from './bundle.js';
+import { Trend } from 'k6/metrics';
+
+export const options = {
+	insecureSkipTLSVerify: true,
+	scenarios: {
+		"default": {
+			executor: 'ramping-vus',
+			stages: [
+				{ duration: '1m', target: 1 },
+			],
+		},
+	},
+};
+
+// Add Trend metrics
+const createNodeTrend = new Trend('createNode');
+
+export default function () {
+
+	// createNode: POST /nodes
+	const createNode_baseUrl = 'https://uat.example.com/nodes';
+	const createNode_queryParams = ``;
+	const createNode_url = createNode_baseUrl + createNode_queryParams;
+	const createNode_body = JSON.stringify({"name":"n1"}
+);
+	const createNode_headers = {"Content-Type":"application/json","X-Trace":"abc"};
+	let createNode_res = http.post(createNode_url, createNode_body, { headers: createNode_headers });
+	createNodeTrend.add(createNode_res.timings.duration);
+	check(createNode_res, {
+		'createNode_status_201_check': (r) => r.status == 201,
+	});
+}
+
+// Generate HTML Report
+export function handleSummary(data) {
+	return {
+		"default-summary.html": htmlReport(data),
+		"default-summary.json": JSON.stringify(data),
+	};
+}
@@ -0,0 +1,66 @@
+import http from 'k6/http';
+import { check, sleep } from 'k6';
+import { htmlReport } from './bundle.js';
+import { Trend } from 'k6/metrics';
+
+export const options = {
+	insecureSkipTLSVerify: true,
+	scenarios: {
+		"default": {
+			executor: 'ramping-vus',
+			stages: [
+				{ duration: '1m', target: 1 },
+			],
+		},
+	},
+};
+
+// Add Trend metrics
+const updatePetTrend = new Trend('updatePet');
+const uploadPhotoTrend = new Trend('uploadPhoto');
+
+// Files uploaded by the requests, opened once in the init stage
+const uploadPhoto_file_file = open('../fitness/sw-dev1/file.json', 'b');
+
+export default function () {
+
+	// updatePet: PUT /pets/{petId}
+	const updatePet_baseUrl = 'https://legacy.example.com/api/pets/7';
+	const updatePet_queryParams = `?tags=a`;
+	const updatePet_url = updatePet_baseUrl + updatePet_queryParams;
+	const updatePet_body = JSON.stringify({"name":"string"});
+	const updatePet_headers = {"Content-Type":"application/json"};
+	let updatePet_res = http.put(updatePet_url, updatePet_body, { headers: updatePet_headers });
+	updatePetTrend.add(updatePet_res.timings.duration);
+	check(updatePet_res, {
+		'updatePet_status_200_check': (r) => r.status == 200,
+		'updatePet_response_schema_check': (r) => {
+			try {
+				const body = r.json();
+				return typeof body === 'object' && body !== null && !Array.isArray(body) && body["name"] !== undefined && (body["name"] === undefined || body["name"] === null || (typeof body["name"] === 'string'));
+			} catch (e) {
+				return false;
+			}
+		},
+	});
+
+	// uploadPhoto: POST /pets/{petId}/photo
+	const uploadPhoto_baseUrl = 'https://legacy.example.com/api/pets/7/photo';
+	const uploadPhoto_queryParams = ``;
+	const uploadPhoto_url = uploadPhoto_baseUrl + uploadPhoto_queryParams;
+	const uploadPhoto_body = { "file": http.file(uploadPhoto_file_file, 'file.json', 'application/json') };
+	const uploadPhoto_headers = {};
+	let uploadPhoto_res = http.post(uploadPhoto_url, uploadPhoto_body, { headers: uploadPhoto_headers });
+	uploadPhotoTrend.add(uploadPhoto_res.timings.duration);
+	check(uploadPhoto_res, {
+		'uploadPhoto_status_201_check': (r) => r.status == 201,
+	});
+}
+
+// Generate HTML Report
+export function handleSummary(data) {
+	return {
+		"default-summary.html": htmlReport(data),
+		"default-summary.json": JSON.stringify(data),
+	};
+}
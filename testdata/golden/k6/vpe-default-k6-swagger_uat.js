@@ -0,0 +1,76 @@
+import http from 'k6/http';
+import { check, sleep } from 'k6';
+import { htmlReport } from './bundle.js';
+import { Trend } from 'k6/metrics';
+
+export const options = {
+	insecureSkipTLSVerify: true,
+	scenarios: {
+		"default": {
+			executor: 'ramping-vus',
+			stages: [
+				{ duration: '1m', target: 1 },
+			],
+		},
+	},
+};
+
+// Add Trend metrics
+const deleteItemTrend = new Trend('deleteItem');
+const createUserTrend = new Trend('createUser');
+const getUserTrend = new Trend('getUser');
+
+export default function () {
+
+	// deleteItem: DELETE /orders/{orderId}/items/{itemId}
+	const deleteItem_baseUrl = 'https://orders.example.com/b/orders/ord-1/items/a%20b';
+	const deleteItem_queryParams = ``;
+	const deleteItem_url = deleteItem_baseUrl + deleteItem_queryParams;
+	const deleteItem_body = JSON.stringify(null);
+	const deleteItem_headers = {};
+	let deleteItem_res = http.del(deleteItem_url, null, { headers: deleteItem_headers });
+	deleteItemTrend.add(deleteItem_res.timings.duration);
+	check(deleteItem_res, {
+		'deleteItem_status_204_check': (r) => r.status == 204,
+	});
+
+	// createUser: POST /users
+	const createUser_baseUrl = 'https://users-uat.example.com/users';
+	const createUser_queryParams = ``;
+	const createUser_url = createUser_baseUrl + createUser_queryParams;
+	const createUser_body = JSON.stringify({"age":18,"code":"AAA-1111","email":"user@example.com","pet":{"name":"str"},"role":"admin","score":1.5,"tags":["string","stringx"]});
+	const createUser_headers = {"Content-Type":"application/json"};
+	let createUser_res = http.post(createUser_url, createUser_body, { headers: createUser_headers });
+	createUserTrend.add(createUser_res.timings.duration);
+	check(createUser_res, {
+		'createUser_status_201_check': (r) => r.status == 201,
+	});
+
+	// getUser: GET /users/{id}
+	const getUser_baseUrl = 'https://override.example.com/us/users/42';
+	const getUser_queryParams = `?verbose=true`;
+	const getUser_url = getUser_baseUrl + getUser_queryParams;
+	const getUser_body = JSON.stringify(null);
+	const getUser_headers = {};
+	let getUser_res = http.get(getUser_url, { headers: getUser_headers });
+	getUserTrend.add(getUser_res.timings.duration);
+	check(getUser_res, {
+		'getUser_status_200_check': (r) => r.status == 200,
+		'getUser_response_schema_check': (r) => {
+			try {
+				const body = r.json();
+				return typeof body === 'object' && body !== null && !Array.isArray(body) && body["id"] !== undefined && body["name"] !== undefined && (body["id"] === undefined || body["id"] === null || (Number.isInteger(body["id"]))) && (body["name"] === undefined || body["name"] === null || (typeof body["name"] === 'string')) && (body["tags"] === undefined || body["tags"] === null || (Array.isArray(body["tags"]) && body["tags"].every((item1) => typeof item1 === 'string')));
+			} catch (e) {
+				return false;
+			}
+		},
+	});
+}
+
+// Generate HTML Report
+export function handleSummary(data) {
+	return {
+		"default-summary.html": htmlReport(data),
+		"default-summary.json": JSON.stringify(data),
+	};
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// defaultScenarioName is the scenario running every operation when no weights are configured.
+const defaultScenarioName = "default"
+
+// otherOperationsScenarioName holds the operations matched by no weight.
+const otherOperationsScenarioName = "other"
+
+var scenarioNameInvalidChars = regexp.MustCompile(`[^0-9A-Za-z_]+`)
+
+// TrafficWeights splits operations into separate k6 scenarios, set under weights in load-profile.yaml:
+//
+//	weights:
+//	  operations:
+//	    getUser: 8
+//	  tags:
+//	    orders: 2
+//	  default: 1
+//
+// Each weighted operation gets its own scenario, operations sharing a weighted tag share one, and
+// the rest run in the "other" scenario. Every scenario receives its weight's share of the load profile.
+type TrafficWeights struct {
+	Operations map[string]int `yaml:"operations" json:"operations,omitempty"`
+	Tags       map[string]int `yaml:"tags" json:"tags,omitempty"`
+	// Default weighs the operations matched by neither; it is 1 when unset
+	Default int `yaml:"default" json:"default,omitempty"`
+}
+
+func (weights TrafficWeights) configured() bool {
+	return len(weights.Operations) > 0 || len(weights.Tags) > 0
+}
+
+// trafficScenario is one generated scenario with the operations its exec function runs.
+type trafficScenario struct {
+	Name         string
+	Exec         string
+	Weight       int
+	OperationIDs []string
+}
+
+// validateTrafficWeights reports weights that are not positive, name no operation or tag of the spec,
+// or would share a scenario.
+func validateTrafficWeights(weights TrafficWeights, endpoints map[string]EndpointDetails, skipped []SkippedOperation) []string {
+	var problems []string
+
+	tags := make(map[string]bool)
+	for _, details := range endpoints {
+		for _, tag := range details.Tags {
+			tags[tag] = true
+		}
+	}
+
+	for _, operationID := range sortedIntKeys(weights.Operations) {
+		if _, ok := endpoints[operationID]; !ok {
+			problems = append(problems, "weights.operations: "+missingOperation(operationID, skipped))
+		}
+		if weights.Operations[operationID] <= 0 {
+			problems = append(problems, fmt.Sprintf("weights.operations.%s must be positive", operationID))
+		}
+	}
+	for _, tag := range sortedIntKeys(weights.Tags) {
+		if !tags[tag] {
+			problems = append(problems, fmt.Sprintf("weights.tags: no operation is tagged %s", tag))
+		}
+		if weights.Tags[tag] <= 0 {
+			problems = append(problems, fmt.Sprintf("weights.tags.%s must be positive", tag))
+		}
+	}
+	if weights.Default < 0 {
+		problems = append(problems, "weights.default must not be negative")
+	}
+
+	// Every weight needs a scenario of its own, and the unweighted operations keep "other"
+	owners := map[string]string{otherOperationsScenarioName: "the unweighted operations"}
+	claim := func(name string, owner string) {
+		if existing, taken := owners[name]; taken {
+			problems = append(problems, fmt.Sprintf("%s and %s both map to scenario %s; rename one", existing, owner, name))
+			return
+		}
+		owners[name] = owner
+	}
+	for _, operationID := range sortedIntKeys(weights.Operations) {
+		claim(scenarioName(operationID), "weights.operations."+operationID)
+	}
+	for _, tag := range sortedIntKeys(weights.Tags) {
+		claim("tag_"+scenarioName(tag), "weights.tags."+tag)
+	}
+	return problems
+}
+
+// trafficScenarios groups the operations, given in script order, into weighted scenarios. An operation
+// weight wins over a tag weight, and the first weighted tag of an operation wins over later ones.
+func trafficScenarios(weights TrafficWeights, operationIDs []string, endpoints map[string]EndpointDetails) []trafficScenario {
+	if !weights.configured() {
+		return []trafficScenario{{Name: defaultScenarioName, Weight: 1, OperationIDs: operationIDs}}
+	}
+
+	var scenarios []trafficScenario
+	byName := make(map[string]int)
+	add := func(name string, weight int, operationID string) {
+		index, ok := byName[name]
+		if !ok {
+			index = len(scenarios)
+			byName[name] = index
+			scenarios = append(scenarios, trafficScenario{Name: name, Exec: name + "Scenario", Weight: weight})
+		}
+		scenarios[index].OperationIDs = append(scenarios[index].OperationIDs, operationID)
+	}
+
+	defaultWeight := weights.Default
+	if defaultWeight == 0 {
+		defaultWeight = 1
+	}
+	for _, operationID := range operationIDs {
+		if weight, ok := weights.Operations[operationID]; ok {
+			add(scenarioName(operationID), weight, operationID)
+			continue
+		}
+		tagged := false
+		for _, tag := range endpoints[operationID].Tags {
+			if weight, ok := weights.Tags[tag]; ok {
+				add("tag_"+scenarioName(tag), weight, operationID)
+				tagged = true
+				break
+			}
+		}
+		if !tagged {
+			add(otherOperationsScenarioName, defaultWeight, operationID)
+		}
+	}
+
+	sort.SliceStable(scenarios, func(i, j int) bool { return scenarios[i].Name < scenarios[j].Name })
+	return scenarios
+}
+
+// scenarioName turns an operationId or tag into a k6 scenario name that is also usable in a function name.
+func scenarioName(name string) string {
+	converted := strings.Trim(scenarioNameInvalidChars.ReplaceAllString(name, "_"), "_")
+	if converted == "" || (converted[0] >= '0' && converted[0] <= '9') {
+		converted = "_" + converted
+	}
+	return converted
+}
+
+// scaleLoadProfile gives a scenario its share of the profile's VUs, arrival rates and iterations.
+// Counts round to the nearest whole number but never drop to zero, so every scenario still runs.
+func scaleLoadProfile(profile LoadProfile, share float64) LoadProfile {
+	scale := func(value int) int {
+		if value <= 0 {
+			return value
+		}
+		scaled := int(math.Round(float64(value) * share))
+		if scaled < 1 {
+			scaled = 1
+		}
+		return scaled
+	}
+
+	scaled := profile
+	scaled.VUs = scale(profile.VUs)
+	scaled.Rate = scale(profile.Rate)
+	scaled.Iterations = scale(profile.Iterations)
+	scaled.PreAllocatedVUs = scale(profile.PreAllocatedVUs)
+	scaled.MaxVUs = scale(profile.MaxVUs)
+	scaled.StartVUs = scale(profile.StartVUs)
+	scaled.StartRate = scale(profile.StartRate)
+	scaled.Stages = make([]LoadStage, len(profile.Stages))
+	for i, stage := range profile.Stages {
+		scaled.Stages[i] = LoadStage{Duration: stage.Duration, Target: scale(stage.Target)}
+	}
+	return scaled
+}
+
+func sortedIntKeys(values map[string]int) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}